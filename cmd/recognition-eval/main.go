@@ -0,0 +1,79 @@
+// recognition-eval 使用配置的模型重新识别评测数据集中的样本，报告总热量平均绝对误差与食物项的 precision/recall
+//
+// 用法（在项目根目录执行，图片路径相对于工作目录解析）:
+//
+//	go run ./cmd/recognition-eval -dataset recognition_eval.jsonl [-config config/config.yaml] [-model gpt-4o] [-limit 100] [-output report.json]
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"ome-app-back/config"
+	"ome-app-back/services"
+)
+
+func main() {
+	configPath := flag.String("config", "config/config.yaml", "配置文件路径")
+	datasetPath := flag.String("dataset", "", "评测数据集文件(由 /api/v1/admin/recognition/eval-dataset 导出)")
+	model := flag.String("model", "", "评测使用的模型，为空时使用配置中的模型")
+	limit := flag.Int("limit", 0, "最多评测的样本数，0表示全部")
+	output := flag.String("output", "", "评测报告JSON的输出路径，为空时只打印摘要")
+	flag.Parse()
+
+	if *datasetPath == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	cfg, err := config.Init(*configPath)
+	if err != nil {
+		log.Fatalf("配置初始化失败: %v", err)
+	}
+	if *model != "" {
+		cfg.AI.Model = *model
+	}
+
+	file, err := os.Open(*datasetPath)
+	if err != nil {
+		log.Fatalf("打开评测数据集失败: %v", err)
+	}
+	samples, err := services.ReadRecognitionEvalDataset(file)
+	file.Close()
+	if err != nil {
+		log.Fatalf("读取评测数据集失败: %v", err)
+	}
+	if *limit > 0 && len(samples) > *limit {
+		samples = samples[:*limit]
+	}
+	log.Printf("评测数据集共%d条样本, 模型: %s", len(samples), cfg.AI.Model)
+
+	aiService := services.NewAIService(&cfg.AI, &cfg.Nutrition)
+	fileService := services.NewFileService(&cfg.Upload)
+	report := services.NewRecognitionEvaluator(aiService, fileService).Evaluate(samples)
+
+	fmt.Printf("模型: %s\n", cfg.AI.Model)
+	fmt.Printf("样本: %d, 跳过: %d, 识别失败: %d\n", report.Total, report.Skipped, len(report.Failures))
+	printMetrics("重新识别", report.Replayed)
+	printMetrics("线上记录", report.Baseline)
+
+	if *output != "" {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			log.Fatalf("生成评测报告失败: %v", err)
+		}
+		if err := os.WriteFile(*output, data, 0644); err != nil {
+			log.Fatalf("写入评测报告失败: %v", err)
+		}
+		log.Printf("评测报告已写入: %s", *output)
+	}
+}
+
+// printMetrics 打印一组准确度指标
+func printMetrics(label string, m services.RecognitionEvalMetrics) {
+	fmt.Printf("[%s] 样本: %d, 热量MAE: %.2f千卡, precision: %.4f, recall: %.4f, F1: %.4f (匹配%d/识别%d/正确%d)\n",
+		label, m.Samples, m.CalorieMAE, m.Precision, m.Recall, m.F1, m.MatchedItems, m.PredictedItems, m.ExpectedItems)
+}
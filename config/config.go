@@ -5,16 +5,24 @@ import (
 	"io/ioutil"
 	"log"
 	"os"
+	"strings"
 
 	"gopkg.in/yaml.v2"
 )
 
 // Config 应用配置结构
 type Config struct {
-	Server ServerConfig `yaml:"server"`
-	DB     DBConfig     `yaml:"database"`
-	AI     AIConfig     `yaml:"ai"`
-	Upload UploadConfig `yaml:"upload"`
+	Server    ServerConfig    `yaml:"server"`
+	DB        DBConfig        `yaml:"database"`
+	AI        AIConfig        `yaml:"ai"`
+	Upload    UploadConfig    `yaml:"upload"`
+	Nutrition NutritionConfig `yaml:"nutrition"`
+	Admin     AdminConfig     `yaml:"admin"`
+}
+
+// AdminConfig 管理接口配置
+type AdminConfig struct {
+	UserIDs []int64 `yaml:"user_ids"` // 可访问管理接口的用户ID
 }
 
 // ServerConfig 服务器配置
@@ -45,12 +53,163 @@ type AIConfig struct {
 	Temperature float64 `yaml:"temperature"`
 	ProxyURL    string  `yaml:"proxy_url"`
 	TestMode    bool    `yaml:"test_mode"`
+
+	RecognitionWorkers   int `yaml:"recognition_workers"`    // 异步食物识别的工作协程数
+	RecognitionQueueSize int `yaml:"recognition_queue_size"` // 异步食物识别的排队任务上限
+	RecognitionMaxImages int `yaml:"recognition_max_images"` // 单次食物识别最多上传的图片数
+
+	RecognitionCacheDistance int `yaml:"recognition_cache_distance"` // 图片感知哈希的汉明距离不超过该值时复用历史识别结果
+	RecognitionCacheDays     int `yaml:"recognition_cache_days"`     // 识别结果缓存的回溯天数
+
+	RecognitionStaleMinutes int `yaml:"recognition_stale_minutes"` // 未完成的识别任务超过该时长未更新时视为中断
+}
+
+// GetRecognitionCacheDistance 获取复用识别结果的最大哈希距离，未配置时默认5
+func (a *AIConfig) GetRecognitionCacheDistance() int {
+	if a.RecognitionCacheDistance <= 0 {
+		return 5
+	}
+	return a.RecognitionCacheDistance
+}
+
+// GetRecognitionCacheDays 获取识别结果缓存的回溯天数，未配置时默认30
+func (a *AIConfig) GetRecognitionCacheDays() int {
+	if a.RecognitionCacheDays <= 0 {
+		return 30
+	}
+	return a.RecognitionCacheDays
+}
+
+// GetRecognitionStaleMinutes 获取识别任务视为中断的分钟数，未配置时默认30
+func (a *AIConfig) GetRecognitionStaleMinutes() int {
+	if a.RecognitionStaleMinutes <= 0 {
+		return 30
+	}
+	return a.RecognitionStaleMinutes
+}
+
+// GetRecognitionMaxImages 获取单次食物识别最多上传的图片数，未配置时默认4
+func (a *AIConfig) GetRecognitionMaxImages() int {
+	if a.RecognitionMaxImages <= 0 {
+		return 4
+	}
+	return a.RecognitionMaxImages
+}
+
+// GetRecognitionWorkers 获取异步食物识别的工作协程数，未配置时默认4
+func (a *AIConfig) GetRecognitionWorkers() int {
+	if a.RecognitionWorkers <= 0 {
+		return 4
+	}
+	return a.RecognitionWorkers
+}
+
+// GetRecognitionQueueSize 获取异步食物识别的排队任务上限，未配置时默认100
+func (a *AIConfig) GetRecognitionQueueSize() int {
+	if a.RecognitionQueueSize <= 0 {
+		return 100
+	}
+	return a.RecognitionQueueSize
 }
 
 // UploadConfig 文件上传配置
 type UploadConfig struct {
-	Dir     string `yaml:"dir"`
-	MaxSize int64  `yaml:"max_size"`
+	Dir     string      `yaml:"dir"`
+	MaxSize int64       `yaml:"max_size"`
+	Image   ImageConfig `yaml:"image"`
+}
+
+// ImageConfig 图片预处理配置
+type ImageConfig struct {
+	MaxDimension  int    `yaml:"max_dimension"`  // 处理后图片最长边像素
+	JPEGQuality   int    `yaml:"jpeg_quality"`   // 处理后JPEG压缩质量(1-100)
+	HEICConverter string `yaml:"heic_converter"` // HEIC转JPEG的外部命令，调用方式为 "命令 输入文件 输出文件"
+	MaxPixels     int    `yaml:"max_pixels"`     // 允许解码的图片最大像素数(宽×高)
+}
+
+// GetMaxDimension 获取处理后图片的最长边像素，未配置时默认1024
+func (i *ImageConfig) GetMaxDimension() int {
+	if i.MaxDimension <= 0 {
+		return 1024
+	}
+	return i.MaxDimension
+}
+
+// GetJPEGQuality 获取JPEG压缩质量，未配置或超出范围时默认80
+func (i *ImageConfig) GetJPEGQuality() int {
+	if i.JPEGQuality <= 0 || i.JPEGQuality > 100 {
+		return 80
+	}
+	return i.JPEGQuality
+}
+
+// GetHEICConverter 获取HEIC转换命令，未配置或只有空白时默认使用libheif的heif-convert
+func (i *ImageConfig) GetHEICConverter() string {
+	if strings.TrimSpace(i.HEICConverter) == "" {
+		return "heif-convert"
+	}
+	return i.HEICConverter
+}
+
+// GetMaxPixels 获取允许解码的图片最大像素数，未配置时默认5000万
+func (i *ImageConfig) GetMaxPixels() int {
+	if i.MaxPixels <= 0 {
+		return 50000000
+	}
+	return i.MaxPixels
+}
+
+// NutritionConfig 营养追踪配置
+type NutritionConfig struct {
+	Micronutrients []MicronutrientConfig `yaml:"micronutrients"`
+	BackfillDays   int                   `yaml:"backfill_days"` // 允许补录/修改的历史天数
+}
+
+// MicronutrientConfig 单项微量营养素配置
+type MicronutrientConfig struct {
+	Key           string  `yaml:"key"            json:"key"`            // 唯一标识，如 fiber_g
+	Name          string  `yaml:"name"           json:"name"`           // 显示名称
+	Unit          string  `yaml:"unit"           json:"unit"`           // 单位：g / mg / μg
+	LimitType     string  `yaml:"limit_type"     json:"limit_type"`     // min：至少摄入 / max：不超过
+	DefaultTarget float64 `yaml:"default_target" json:"default_target"` // 默认每日目标值
+	Per1000Kcal   float64 `yaml:"per_1000_kcal"  json:"per_1000_kcal"`  // 按每1000千卡计算目标，大于0时优先使用
+}
+
+// DefaultMicronutrients 未配置时使用的默认微量营养素集合
+var DefaultMicronutrients = []MicronutrientConfig{
+	{Key: "fiber_g", Name: "膳食纤维", Unit: "g", LimitType: "min", DefaultTarget: 25, Per1000Kcal: 14},
+	{Key: "sugar_g", Name: "添加糖", Unit: "g", LimitType: "max", DefaultTarget: 50, Per1000Kcal: 25},
+	{Key: "sodium_mg", Name: "钠", Unit: "mg", LimitType: "max", DefaultTarget: 2000},
+	{Key: "cholesterol_mg", Name: "胆固醇", Unit: "mg", LimitType: "max", DefaultTarget: 300},
+	{Key: "vitamin_a_ug", Name: "维生素A", Unit: "μg", LimitType: "min", DefaultTarget: 800},
+	{Key: "vitamin_c_mg", Name: "维生素C", Unit: "mg", LimitType: "min", DefaultTarget: 100},
+	{Key: "vitamin_d_ug", Name: "维生素D", Unit: "μg", LimitType: "min", DefaultTarget: 10},
+}
+
+// GetMicronutrients 获取生效的微量营养素配置，未配置时返回默认集合
+func (n *NutritionConfig) GetMicronutrients() []MicronutrientConfig {
+	if len(n.Micronutrients) == 0 {
+		return DefaultMicronutrients
+	}
+	return n.Micronutrients
+}
+
+// GetBackfillDays 获取允许补录的历史天数，未配置时默认30天
+func (n *NutritionConfig) GetBackfillDays() int {
+	if n.BackfillDays <= 0 {
+		return 30
+	}
+	return n.BackfillDays
+}
+
+// MicronutrientKeys 获取生效的微量营养素标识列表
+func (n *NutritionConfig) MicronutrientKeys() []string {
+	micronutrients := n.GetMicronutrients()
+	keys := make([]string, 0, len(micronutrients))
+	for _, m := range micronutrients {
+		keys = append(keys, m.Key)
+	}
+	return keys
 }
 
 // GetDSN 获取数据库连接字符串
@@ -146,6 +305,35 @@ func (c *Config) CheckConfiguration() []string {
 		}
 	}
 
+	// 检查图片预处理配置
+	if c.Upload.Image.HEICConverter != "" && strings.TrimSpace(c.Upload.Image.HEICConverter) == "" {
+		msg := "警告: upload.image.heic_converter 只包含空白，使用默认命令 heif-convert"
+		log.Println(msg)
+		issues = append(issues, msg)
+	}
+
+	// 检查微量营养素配置
+	seenKeys := make(map[string]bool)
+	for _, m := range c.Nutrition.Micronutrients {
+		if m.Key == "" {
+			msg := "警告: 存在未设置key的微量营养素配置"
+			log.Println(msg)
+			issues = append(issues, msg)
+			continue
+		}
+		if seenKeys[m.Key] {
+			msg := fmt.Sprintf("警告: 微量营养素 %s 重复配置", m.Key)
+			log.Println(msg)
+			issues = append(issues, msg)
+		}
+		seenKeys[m.Key] = true
+		if m.LimitType != "min" && m.LimitType != "max" {
+			msg := fmt.Sprintf("警告: 微量营养素 %s 的limit_type应为min或max", m.Key)
+			log.Println(msg)
+			issues = append(issues, msg)
+		}
+	}
+
 	if len(issues) == 0 {
 		log.Println("配置检查完成，未发现问题")
 	} else {
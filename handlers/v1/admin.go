@@ -0,0 +1,64 @@
+package v1
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"ome-app-back/services"
+)
+
+// AdminAPI 处理管理接口
+type AdminAPI struct {
+	adminService *services.AdminService
+}
+
+// NewAdminAPI 创建管理API处理实例
+func NewAdminAPI(adminService *services.AdminService) *AdminAPI {
+	return &AdminAPI{
+		adminService: adminService,
+	}
+}
+
+// RequireAdmin 校验当前用户是否为管理员，作为管理路由的中间件使用
+func (a *AdminAPI) RequireAdmin(c *gin.Context) {
+	userID := getUserID(c)
+	if userID == 0 {
+		responseError(c, http.StatusUnauthorized, "未授权")
+		c.Abort()
+		return
+	}
+	if !a.adminService.IsAdmin(userID) {
+		responseError(c, http.StatusForbidden, "无权访问管理接口")
+		c.Abort()
+		return
+	}
+	c.Next()
+}
+
+// ExportRecognitionDataset 导出识别准确度评测数据集（JSON Lines文件）
+func (a *AdminAPI) ExportRecognitionDataset(c *gin.Context) {
+	userID := getUserID(c)
+	header := c.Writer.Header()
+	header.Set("Content-Type", "application/x-ndjson; charset=utf-8")
+	header.Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", a.adminService.ExportDatasetFileName(userID)))
+
+	count, err := a.adminService.ExportRecognitionDataset(c.Writer, userID, c.Query("start_date"), c.Query("end_date"))
+	if err != nil {
+		if c.Writer.Written() {
+			// 已开始输出文件内容，无法再返回错误响应
+			log.Printf("[评测数据集] 错误: 导出中断(已导出%d条): %v", count, err)
+			return
+		}
+		header.Del("Content-Type")
+		header.Del("Content-Disposition")
+		if errors.Is(err, services.ErrInvalidExportDate) {
+			responseError(c, http.StatusBadRequest, "导出评测数据集失败", err.Error())
+			return
+		}
+		responseError(c, http.StatusInternalServerError, "导出评测数据集失败", err.Error())
+	}
+}
@@ -1,10 +1,14 @@
 package v1
 
 import (
+	"errors"
 	"io"
+	"mime/multipart"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"gorm.io/gorm"
 
 	"ome-app-back/services"
 )
@@ -97,8 +101,8 @@ func (a *ChatAPI) UpdateSessionTitle(c *gin.Context) {
 		return
 	}
 
-	if err := a.chatService.UpdateSessionTitle(sessionID, req.Title); err != nil {
-		responseError(c, http.StatusInternalServerError, "更新会话标题失败")
+	if err := a.chatService.UpdateSessionTitle(userID, sessionID, req.Title); err != nil {
+		handleChatError(c, err, "更新会话标题失败")
 		return
 	}
 
@@ -119,8 +123,8 @@ func (a *ChatAPI) DeleteSession(c *gin.Context) {
 		return
 	}
 
-	if err := a.chatService.DeleteSession(sessionID); err != nil {
-		responseError(c, http.StatusInternalServerError, "删除会话失败")
+	if err := a.chatService.DeleteSession(userID, sessionID); err != nil {
+		handleChatError(c, err, "删除会话失败")
 		return
 	}
 
@@ -141,9 +145,9 @@ func (a *ChatAPI) GetMessages(c *gin.Context) {
 		return
 	}
 
-	messages, err := a.chatService.GetMessages(sessionID)
+	messages, err := a.chatService.GetMessages(userID, sessionID)
 	if err != nil {
-		responseError(c, http.StatusInternalServerError, "获取消息列表失败")
+		handleChatError(c, err, "获取消息列表失败")
 		return
 	}
 
@@ -169,15 +173,35 @@ func (a *ChatAPI) SendMessage(c *gin.Context) {
 		return
 	}
 
-	var req SendMessageRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		responseError(c, http.StatusBadRequest, "请求参数错误")
-		return
+	// JSON 请求发送文字消息；multipart/form-data 请求可通过 images 附带同一餐的餐食照片
+	var content string
+	var files []*multipart.FileHeader
+	if c.ContentType() == binding.MIMEMultipartPOSTForm {
+		form, err := c.MultipartForm()
+		if err != nil {
+			responseError(c, http.StatusBadRequest, "请求参数错误")
+			return
+		}
+		content = c.PostForm("content")
+		files = form.File["images"]
+		for _, file := range files {
+			if file.Size > 10*1024*1024 { // 限制10MB
+				responseError(c, http.StatusBadRequest, "文件大小超过限制")
+				return
+			}
+		}
+	} else {
+		var req SendMessageRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			responseError(c, http.StatusBadRequest, "请求参数错误")
+			return
+		}
+		content = req.Content
 	}
 
-	_, responseChan, err := a.chatService.SendMessage(userID, sessionID, req.Content)
+	_, responseChan, err := a.chatService.SendMessage(userID, sessionID, content, files)
 	if err != nil {
-		responseError(c, http.StatusInternalServerError, "发送消息失败")
+		handleChatError(c, err, "发送消息失败")
 		return
 	}
 
@@ -186,10 +210,23 @@ func (a *ChatAPI) SendMessage(c *gin.Context) {
 	c.Writer.Header().Set("Connection", "keep-alive")
 
 	c.Stream(func(w io.Writer) bool {
-		if msg, ok := <-responseChan; ok {
-			c.SSEvent("message", msg)
+		if event, ok := <-responseChan; ok {
+			c.SSEvent(event.Event, event.Data)
 			return true
 		}
 		return false
 	})
 }
+
+// handleChatError 根据错误类型返回聊天接口的错误响应，会话不存在或不属于当前用户时返回404
+func handleChatError(c *gin.Context, err error, msg string) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		responseError(c, http.StatusNotFound, msg, "会话不存在")
+	case errors.Is(err, services.ErrEmptyChatMessage):
+		responseError(c, http.StatusBadRequest, msg, err.Error())
+	default:
+		// 附带的餐食图片无效等识别相关错误
+		handleRecognitionError(c, err, msg)
+	}
+}
@@ -0,0 +1,43 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"ome-app-back/pkg/errcode"
+	"ome-app-back/services"
+)
+
+// DashboardAPI 首页概览API
+type DashboardAPI struct {
+	dashboardService *services.DashboardService
+}
+
+// NewDashboardAPI 创建首页概览API实例
+func NewDashboardAPI(dashboardService *services.DashboardService) *DashboardAPI {
+	return &DashboardAPI{
+		dashboardService: dashboardService,
+	}
+}
+
+// GetToday 获取今日概览
+func (api *DashboardAPI) GetToday(c *gin.Context) {
+	userID := getUserIDFromContext(c)
+	if userID == 0 {
+		errcode.UnauthorizedTokenError.Response(c)
+		return
+	}
+
+	today, err := api.dashboardService.GetToday(userID)
+	if err != nil {
+		errcode.ServerError.WithDetails(err.Error()).Response(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"msg":  "成功",
+		"data": today,
+	})
+}
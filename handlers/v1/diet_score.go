@@ -0,0 +1,71 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"ome-app-back/services"
+)
+
+// DietScoreAPI 处理饮食评分相关接口
+type DietScoreAPI struct {
+	dietScoreService *services.DietScoreService
+}
+
+// NewDietScoreAPI 创建饮食评分API处理实例
+func NewDietScoreAPI(dietScoreService *services.DietScoreService) *DietScoreAPI {
+	return &DietScoreAPI{
+		dietScoreService: dietScoreService,
+	}
+}
+
+// GetDailyScore 获取单日饮食评分及各维度明细
+func (a *DietScoreAPI) GetDailyScore(c *gin.Context) {
+	userID := getUserID(c)
+	if userID == 0 {
+		responseError(c, http.StatusUnauthorized, "未授权")
+		return
+	}
+
+	score, err := a.dietScoreService.GetDailyScore(userID, c.Query("date"))
+	if err != nil {
+		handleDietScoreError(c, err)
+		return
+	}
+
+	responseSuccess(c, score)
+}
+
+// GetScoreHistory 获取饮食评分历史
+func (a *DietScoreAPI) GetScoreHistory(c *gin.Context) {
+	userID := getUserID(c)
+	if userID == 0 {
+		responseError(c, http.StatusUnauthorized, "未授权")
+		return
+	}
+
+	var req services.DietScoreHistoryRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		responseError(c, http.StatusBadRequest, "请求参数错误", err.Error())
+		return
+	}
+
+	history, err := a.dietScoreService.GetScoreHistory(userID, &req)
+	if err != nil {
+		handleDietScoreError(c, err)
+		return
+	}
+
+	responseSuccess(c, history)
+}
+
+// handleDietScoreError 统一处理饮食评分错误
+func handleDietScoreError(c *gin.Context, err error) {
+	if errors.Is(err, services.ErrInvalidScoreRange) {
+		responseError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	responseError(c, http.StatusInternalServerError, "获取饮食评分失败", err.Error())
+}
@@ -0,0 +1,142 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"ome-app-back/services"
+)
+
+// FavoriteFoodAPI 处理收藏食物与常吃食物相关接口
+type FavoriteFoodAPI struct {
+	favoriteService *services.FavoriteFoodService
+}
+
+// NewFavoriteFoodAPI 创建收藏食物API处理实例
+func NewFavoriteFoodAPI(favoriteService *services.FavoriteFoodService) *FavoriteFoodAPI {
+	return &FavoriteFoodAPI{
+		favoriteService: favoriteService,
+	}
+}
+
+// SaveFavorite 收藏食物
+func (a *FavoriteFoodAPI) SaveFavorite(c *gin.Context) {
+	userID := getUserID(c)
+	if userID == 0 {
+		responseError(c, http.StatusUnauthorized, "未授权")
+		return
+	}
+
+	var req services.SaveFavoriteFoodRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		responseError(c, http.StatusBadRequest, "请求参数错误", err.Error())
+		return
+	}
+
+	favorite, err := a.favoriteService.SaveFavorite(userID, &req)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			responseError(c, http.StatusNotFound, "收藏食物失败", "饮食记录不存在")
+			return
+		}
+		responseError(c, http.StatusBadRequest, "收藏食物失败", err.Error())
+		return
+	}
+
+	responseSuccess(c, favorite)
+}
+
+// GetFavorites 获取收藏食物列表
+func (a *FavoriteFoodAPI) GetFavorites(c *gin.Context) {
+	userID := getUserID(c)
+	if userID == 0 {
+		responseError(c, http.StatusUnauthorized, "未授权")
+		return
+	}
+
+	favorites, err := a.favoriteService.GetFavorites(userID)
+	if err != nil {
+		responseError(c, http.StatusInternalServerError, "获取收藏食物失败", err.Error())
+		return
+	}
+
+	responseSuccess(c, favorites)
+}
+
+// DeleteFavorite 取消收藏
+func (a *FavoriteFoodAPI) DeleteFavorite(c *gin.Context) {
+	userID := getUserID(c)
+	if userID == 0 {
+		responseError(c, http.StatusUnauthorized, "未授权")
+		return
+	}
+
+	favoriteID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		responseError(c, http.StatusBadRequest, "无效的收藏ID")
+		return
+	}
+
+	if err := a.favoriteService.DeleteFavorite(userID, favoriteID); err != nil {
+		responseError(c, http.StatusInternalServerError, "取消收藏失败", err.Error())
+		return
+	}
+
+	responseSuccess(c, nil)
+}
+
+// LogFavorite 按收藏记录饮食
+func (a *FavoriteFoodAPI) LogFavorite(c *gin.Context) {
+	userID := getUserID(c)
+	if userID == 0 {
+		responseError(c, http.StatusUnauthorized, "未授权")
+		return
+	}
+
+	favoriteID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		responseError(c, http.StatusBadRequest, "无效的收藏ID")
+		return
+	}
+
+	var req services.LogFavoriteFoodRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		responseError(c, http.StatusBadRequest, "请求参数错误", err.Error())
+		return
+	}
+
+	result, err := a.favoriteService.LogFavorite(userID, favoriteID, &req)
+	if err != nil {
+		handleNutritionError(c, err, "记录收藏食物失败")
+		return
+	}
+
+	responseSuccess(c, result)
+}
+
+// GetFrequentFoods 获取常吃食物列表
+func (a *FavoriteFoodAPI) GetFrequentFoods(c *gin.Context) {
+	userID := getUserID(c)
+	if userID == 0 {
+		responseError(c, http.StatusUnauthorized, "未授权")
+		return
+	}
+
+	var req services.FrequentFoodRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		responseError(c, http.StatusBadRequest, "请求参数错误", err.Error())
+		return
+	}
+
+	foods, err := a.favoriteService.GetFrequentFoods(userID, &req)
+	if err != nil {
+		responseError(c, http.StatusInternalServerError, "获取常吃食物失败", err.Error())
+		return
+	}
+
+	responseSuccess(c, foods)
+}
@@ -1,10 +1,10 @@
 package v1
 
 import (
-	"fmt"
+	"errors"
+	"log"
 	"net/http"
 	"path/filepath"
-	"strings"
 
 	"github.com/gin-gonic/gin"
 
@@ -36,8 +36,9 @@ func (a *FileAPI) GetFile(c *gin.Context) {
 		return
 	}
 
-	// 安全性检查：防止任意文件访问
-	if strings.Contains(filePath, "..") || !strings.HasPrefix(filePath, "uploads/") {
+	// 读取文件，仅允许访问uploads目录下非用户目录的文件
+	data, mimeType, err := a.fileService.GetPublicFile(filePath)
+	if errors.Is(err, services.ErrForbidden) {
 		c.JSON(http.StatusForbidden, gin.H{
 			"code": 403,
 			"msg":  "禁止访问该路径",
@@ -45,9 +46,6 @@ func (a *FileAPI) GetFile(c *gin.Context) {
 		})
 		return
 	}
-
-	// 读取文件
-	data, mimeType, err := a.fileService.GetFile(filePath)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{
 			"code": 404,
@@ -65,16 +63,9 @@ func (a *FileAPI) GetFile(c *gin.Context) {
 
 // GetUserFile 获取用户文件（需要验证权限）
 func (a *FileAPI) GetUserFile(c *gin.Context) {
-	// 记录请求路径
-	requestPath := c.Request.URL.Path
-	fmt.Printf("[文件访问] 请求路径: %s\n", requestPath)
-
 	// 获取用户ID和文件路径
 	userID := getUserID(c)
-	fmt.Printf("[文件访问] 获取到的用户ID: %d\n", userID)
-
 	if userID == 0 {
-		fmt.Printf("[文件访问] 未授权访问: %s\n", requestPath)
 		c.JSON(http.StatusUnauthorized, gin.H{
 			"code": 401,
 			"msg":  "未授权",
@@ -84,10 +75,7 @@ func (a *FileAPI) GetUserFile(c *gin.Context) {
 	}
 
 	filePath := c.Param("filepath")
-	fmt.Printf("[文件访问] 解析到的文件路径参数: '%s'\n", filePath)
-
 	if filePath == "" {
-		fmt.Printf("[文件访问] 文件路径为空\n")
 		c.JSON(http.StatusBadRequest, gin.H{
 			"code": 400,
 			"msg":  "文件路径不能为空",
@@ -96,18 +84,10 @@ func (a *FileAPI) GetUserFile(c *gin.Context) {
 		return
 	}
 
-	// 处理路径中的前导斜杠
-	if strings.HasPrefix(filePath, "/") {
-		filePath = filePath[1:]
-		fmt.Printf("[文件访问] 处理后的文件路径: '%s'\n", filePath)
-	}
-
-	// 权限检查：仅允许访问用户自己的文件
-	expectedPrefix := fmt.Sprintf("uploads/user_%d", userID)
-	fmt.Printf("[文件访问] 期望的路径前缀: '%s', 实际路径: '%s'\n", expectedPrefix, filePath)
-
-	if !strings.HasPrefix(filePath, expectedPrefix) {
-		fmt.Printf("[文件访问] 权限检查失败: 路径 '%s' 不符合前缀 '%s'\n", filePath, expectedPrefix)
+	// 读取文件，仅允许访问用户自己上传目录下的文件
+	data, mimeType, err := a.fileService.GetUserFile(userID, filePath)
+	if errors.Is(err, services.ErrForbidden) {
+		log.Printf("[文件访问] 用户(ID:%d)无权访问路径: %s", userID, filePath)
 		c.JSON(http.StatusForbidden, gin.H{
 			"code": 403,
 			"msg":  "无权访问该文件",
@@ -115,13 +95,8 @@ func (a *FileAPI) GetUserFile(c *gin.Context) {
 		})
 		return
 	}
-
-	fmt.Printf("[文件访问] 权限检查通过，准备读取文件: %s\n", filePath)
-
-	// 读取文件
-	data, mimeType, err := a.fileService.GetFile(filePath)
 	if err != nil {
-		fmt.Printf("[文件访问] 读取文件失败: %v\n", err)
+		log.Printf("[文件访问] 用户(ID:%d)读取文件失败: %v", userID, err)
 		c.JSON(http.StatusNotFound, gin.H{
 			"code": 404,
 			"msg":  "文件不存在或无法读取: " + err.Error(),
@@ -130,8 +105,6 @@ func (a *FileAPI) GetUserFile(c *gin.Context) {
 		return
 	}
 
-	fmt.Printf("[文件访问] 文件读取成功, 大小: %d字节, 类型: %s\n", len(data), mimeType)
-
 	// 设置内容类型并返回文件
 	c.Header("Content-Type", mimeType)
 	c.Header("Content-Disposition", "inline; filename="+filepath.Base(filePath))
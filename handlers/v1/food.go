@@ -0,0 +1,113 @@
+package v1
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"ome-app-back/services"
+)
+
+// FoodAPI 处理食物库相关接口
+type FoodAPI struct {
+	foodService *services.FoodService
+}
+
+// NewFoodAPI 创建食物库API处理实例
+func NewFoodAPI(foodService *services.FoodService) *FoodAPI {
+	return &FoodAPI{
+		foodService: foodService,
+	}
+}
+
+// SearchFoods 搜索食物库
+func (a *FoodAPI) SearchFoods(c *gin.Context) {
+	userID := getUserID(c)
+	if userID == 0 {
+		responseError(c, http.StatusUnauthorized, "未授权")
+		return
+	}
+
+	var req services.SearchFoodRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		responseError(c, http.StatusBadRequest, "请求参数错误", err.Error())
+		return
+	}
+
+	foods, err := a.foodService.SearchFoods(userID, &req)
+	if err != nil {
+		responseError(c, http.StatusInternalServerError, "搜索食物失败", err.Error())
+		return
+	}
+
+	responseSuccess(c, foods)
+}
+
+// GetFood 获取食物详情
+func (a *FoodAPI) GetFood(c *gin.Context) {
+	userID := getUserID(c)
+	if userID == 0 {
+		responseError(c, http.StatusUnauthorized, "未授权")
+		return
+	}
+
+	foodID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		responseError(c, http.StatusBadRequest, "无效的食物ID")
+		return
+	}
+
+	food, err := a.foodService.GetFood(userID, foodID)
+	if err != nil {
+		responseError(c, http.StatusNotFound, "获取食物失败", err.Error())
+		return
+	}
+
+	responseSuccess(c, food)
+}
+
+// CreateFood 创建自定义食物
+func (a *FoodAPI) CreateFood(c *gin.Context) {
+	userID := getUserID(c)
+	if userID == 0 {
+		responseError(c, http.StatusUnauthorized, "未授权")
+		return
+	}
+
+	var req services.CreateFoodRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		responseError(c, http.StatusBadRequest, "请求参数错误", err.Error())
+		return
+	}
+
+	food, err := a.foodService.CreateFood(userID, &req)
+	if err != nil {
+		responseError(c, http.StatusInternalServerError, "创建食物失败", err.Error())
+		return
+	}
+
+	responseSuccess(c, food)
+}
+
+// DeleteFood 删除自定义食物
+func (a *FoodAPI) DeleteFood(c *gin.Context) {
+	userID := getUserID(c)
+	if userID == 0 {
+		responseError(c, http.StatusUnauthorized, "未授权")
+		return
+	}
+
+	foodID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		responseError(c, http.StatusBadRequest, "无效的食物ID")
+		return
+	}
+
+	if err := a.foodService.DeleteFood(userID, foodID); err != nil {
+		responseError(c, http.StatusInternalServerError, "删除食物失败", err.Error())
+		return
+	}
+
+	responseSuccess(c, nil)
+}
@@ -1,12 +1,18 @@
 package v1
 
 import (
+	"errors"
+	"io"
+	"log"
+	"mime/multipart"
 	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 
+	"ome-app-back/models"
 	"ome-app-back/services"
 )
 
@@ -22,7 +28,15 @@ func NewFoodRecognitionAPI(recognitionService *services.FoodRecognitionService)
 	}
 }
 
-// RecognizeFood 分析上传的食物图片
+// 识别状态SSE推送参数
+const (
+	recognitionStreamTimeout   = 3 * time.Minute  // 单次连接最长等待时间
+	recognitionStreamHeartbeat = 15 * time.Second // 心跳间隔，避免连接被代理断开
+	recognitionStreamPoll      = 5 * time.Second  // 重新读取状态的间隔，状态推送因订阅者处理不及时被丢弃时兜底
+)
+
+// RecognizeFood 分析上传的食物图片，同一餐可上传多张；表单参数 async=true 时立即返回待识别记录，由后台完成识别
+// 近期识别过相同或近似图片时复用历史结果，表单参数 force=true 时强制重新识别
 func (a *FoodRecognitionAPI) RecognizeFood(c *gin.Context) {
 	userID := getUserID(c)
 	if userID == 0 {
@@ -32,30 +46,186 @@ func (a *FoodRecognitionAPI) RecognizeFood(c *gin.Context) {
 
 	// 获取会话ID
 	sessionID := c.PostForm("session_id")
+	async, _ := strconv.ParseBool(c.PostForm("async"))
+	force, _ := strconv.ParseBool(c.PostForm("force"))
 
-	// 获取上传的文件
-	file, err := c.FormFile("food_image")
+	// 获取上传的文件，food_image 为单张图片，food_images 可上传同一餐的多张图片
+	form, err := c.MultipartForm()
 	if err != nil {
 		responseError(c, http.StatusBadRequest, "获取上传文件失败")
 		return
 	}
+	files := make([]*multipart.FileHeader, 0, len(form.File["food_image"])+len(form.File["food_images"]))
+	files = append(files, form.File["food_image"]...)
+	files = append(files, form.File["food_images"]...)
+	if len(files) == 0 {
+		responseError(c, http.StatusBadRequest, "获取上传文件失败")
+		return
+	}
 
 	// 检查文件大小
-	if file.Size > 10*1024*1024 { // 限制10MB
-		responseError(c, http.StatusBadRequest, "文件大小超过限制")
-		return
+	for _, file := range files {
+		if file.Size > 10*1024*1024 { // 限制10MB
+			responseError(c, http.StatusBadRequest, "文件大小超过限制")
+			return
+		}
 	}
 
 	// 调用服务处理识别
-	result, err := a.recognitionService.RecognizeFood(userID, sessionID, file)
+	var result *models.FoodRecognitionResult
+	if async {
+		result, err = a.recognitionService.SubmitRecognition(userID, sessionID, files, force)
+	} else {
+		result, err = a.recognitionService.RecognizeFood(userID, sessionID, files, force)
+	}
+	if err != nil {
+		handleRecognitionError(c, err, "识别食物失败")
+		return
+	}
+
+	responseSuccess(c, result)
+}
+
+// RecognizeText 根据文字描述记录饮食，由AI解析为与拍照识别相同的识别记录
+func (a *FoodRecognitionAPI) RecognizeText(c *gin.Context) {
+	userID := getUserID(c)
+	if userID == 0 {
+		responseError(c, http.StatusUnauthorized, "未授权")
+		return
+	}
+
+	var req services.RecognizeTextRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		responseError(c, http.StatusBadRequest, "请求参数错误", err.Error())
+		return
+	}
+
+	result, err := a.recognitionService.RecognizeText(userID, &req)
+	if err != nil {
+		handleRecognitionError(c, err, "解析饮食描述失败")
+		return
+	}
+
+	responseSuccess(c, result)
+}
+
+// RetryRecognition 重新识别失败的记录，以异步任务方式执行
+func (a *FoodRecognitionAPI) RetryRecognition(c *gin.Context) {
+	userID := getUserID(c)
+	if userID == 0 {
+		responseError(c, http.StatusUnauthorized, "未授权")
+		return
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		responseError(c, http.StatusBadRequest, "无效的ID参数")
+		return
+	}
+
+	result, err := a.recognitionService.RetryRecognition(id, userID)
 	if err != nil {
-		responseError(c, http.StatusInternalServerError, "识别食物失败: "+err.Error())
+		handleRecognitionError(c, err, "重试识别失败")
 		return
 	}
 
 	responseSuccess(c, result)
 }
 
+// StreamRecognition 以SSE推送识别记录的状态变化，识别完成或失败后关闭连接
+func (a *FoodRecognitionAPI) StreamRecognition(c *gin.Context) {
+	userID := getUserID(c)
+	if userID == 0 {
+		responseError(c, http.StatusUnauthorized, "未授权")
+		return
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		responseError(c, http.StatusBadRequest, "无效的ID参数")
+		return
+	}
+
+	current, updates, cancel, err := a.recognitionService.WatchRecognition(id, userID)
+	if err != nil {
+		handleRecognitionError(c, err, "获取识别状态失败")
+		return
+	}
+	defer cancel()
+
+	c.Writer.Header().Set("Content-Type", "text/event-stream")
+	c.Writer.Header().Set("Cache-Control", "no-cache")
+	c.Writer.Header().Set("Connection", "keep-alive")
+
+	// 先推送当前状态，已结束时直接关闭连接
+	c.SSEvent("status", current)
+	c.Writer.Flush()
+	if updates == nil {
+		return
+	}
+
+	timeout := time.NewTimer(recognitionStreamTimeout)
+	defer timeout.Stop()
+	heartbeat := time.NewTicker(recognitionStreamHeartbeat)
+	defer heartbeat.Stop()
+	poll := time.NewTicker(recognitionStreamPoll)
+	defer poll.Stop()
+
+	lastStatus := current.Status
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case result, ok := <-updates:
+			if !ok {
+				return false
+			}
+			lastStatus = result.Status
+			c.SSEvent("status", result)
+			return !services.IsRecognitionFinished(result.Status)
+		case <-poll.C:
+			result, err := a.recognitionService.GetRecognitionByID(userID, id)
+			if err != nil {
+				log.Printf("[食物识别] 警告: 重新读取识别记录(ID:%d)状态失败: %v", id, err)
+				return true
+			}
+			if result.Status == lastStatus {
+				return true
+			}
+			lastStatus = result.Status
+			c.SSEvent("status", result)
+			return !services.IsRecognitionFinished(result.Status)
+		case <-heartbeat.C:
+			c.SSEvent("ping", time.Now().Unix())
+			return true
+		case <-timeout.C:
+			c.SSEvent("timeout", gin.H{"id": id})
+			return false
+		case <-c.Request.Context().Done():
+			return false
+		}
+	})
+}
+
+// handleRecognitionError 统一处理食物识别相关业务错误，其余错误按营养业务错误处理
+func handleRecognitionError(c *gin.Context, err error, msg string) {
+	var outputErr *services.AIOutputError
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		responseError(c, http.StatusNotFound, msg, "识别记录不存在")
+	case errors.Is(err, services.ErrInvalidImage), errors.Is(err, services.ErrUnsupportedImageFormat), errors.Is(err, services.ErrInvalidImageCount),
+		errors.Is(err, services.ErrInvalidFoodText),
+		errors.Is(err, services.ErrRecognitionNotRetryable), errors.Is(err, services.ErrRecognitionNotReady),
+		errors.Is(err, services.ErrRecognitionAdopted), errors.Is(err, services.ErrInvalidRecognitionItem):
+		responseError(c, http.StatusBadRequest, msg, err.Error())
+	case errors.Is(err, services.ErrRecognitionQueueFull):
+		responseError(c, http.StatusServiceUnavailable, msg, err.Error())
+	case errors.As(err, &outputErr):
+		// AI返回的内容经修复后仍无法使用
+		responseError(c, http.StatusBadGateway, msg+": "+outputErr.Kind.Error(), outputErr.Problems...)
+	default:
+		handleNutritionError(c, err, msg)
+	}
+}
+
 // GetRecognitionByID 获取食物识别记录详情
 func (a *FoodRecognitionAPI) GetRecognitionByID(c *gin.Context) {
 	userID := getUserID(c)
@@ -73,9 +243,9 @@ func (a *FoodRecognitionAPI) GetRecognitionByID(c *gin.Context) {
 	}
 
 	// 获取记录详情
-	result, err := a.recognitionService.GetRecognitionByID(id)
+	result, err := a.recognitionService.GetRecognitionByID(userID, id)
 	if err != nil {
-		responseError(c, http.StatusInternalServerError, "获取识别记录失败")
+		handleRecognitionError(c, err, "获取识别记录失败")
 		return
 	}
 
@@ -100,7 +270,65 @@ func (a *FoodRecognitionAPI) GetTodayRecognitions(c *gin.Context) {
 	responseSuccess(c, results)
 }
 
-// SaveRecognitionToNutrition 保存食物识别结果到用户营养摄入
+// ConfirmRecognitionItems 确认识别结果中的食物（通常为低置信度的食物），不修改营养数值
+func (a *FoodRecognitionAPI) ConfirmRecognitionItems(c *gin.Context) {
+	userID := getUserID(c)
+	if userID == 0 {
+		responseError(c, http.StatusUnauthorized, "未授权")
+		return
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		responseError(c, http.StatusBadRequest, "无效的ID参数")
+		return
+	}
+
+	var req services.ConfirmRecognitionItemsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		responseError(c, http.StatusBadRequest, "请求参数错误", err.Error())
+		return
+	}
+
+	result, err := a.recognitionService.ConfirmRecognitionItems(userID, id, &req)
+	if err != nil {
+		handleRecognitionError(c, err, "确认识别结果失败")
+		return
+	}
+
+	responseSuccess(c, result)
+}
+
+// SubmitRecognitionFeedback 提交识别准确度评分及修正后的正确结果
+func (a *FoodRecognitionAPI) SubmitRecognitionFeedback(c *gin.Context) {
+	userID := getUserID(c)
+	if userID == 0 {
+		responseError(c, http.StatusUnauthorized, "未授权")
+		return
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		responseError(c, http.StatusBadRequest, "无效的ID参数")
+		return
+	}
+
+	var req services.RecognitionFeedbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		responseError(c, http.StatusBadRequest, "请求参数错误", err.Error())
+		return
+	}
+
+	result, err := a.recognitionService.SubmitRecognitionFeedback(userID, id, &req)
+	if err != nil {
+		handleRecognitionError(c, err, "提交识别反馈失败")
+		return
+	}
+
+	responseSuccess(c, result)
+}
+
+// SaveRecognitionToNutrition 采用食物识别结果到用户营养摄入，重复请求不会重复累加
 func (a *FoodRecognitionAPI) SaveRecognitionToNutrition(c *gin.Context) {
 	userID := getUserID(c)
 	if userID == 0 {
@@ -116,14 +344,75 @@ func (a *FoodRecognitionAPI) SaveRecognitionToNutrition(c *gin.Context) {
 		return
 	}
 
+	// 请求体可选，未传时采用到今天并按识别时间推断餐次
+	var req services.AdoptRecognitionRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			responseError(c, http.StatusBadRequest, "请求参数错误", err.Error())
+			return
+		}
+	}
+
 	// 保存到营养摄入
-	err = a.recognitionService.SaveRecognitionToNutrition(id, userID)
+	result, err := a.recognitionService.SaveRecognitionToNutrition(id, userID, &req)
 	if err != nil {
-		responseError(c, http.StatusInternalServerError, "保存到营养摄入失败: "+err.Error())
+		handleRecognitionError(c, err, "保存到营养摄入失败")
 		return
 	}
 
-	responseSuccess(c, nil)
+	responseSuccess(c, result)
+}
+
+// UnadoptRecognition 取消采用食物识别结果，从营养摄入中扣除
+func (a *FoodRecognitionAPI) UnadoptRecognition(c *gin.Context) {
+	userID := getUserID(c)
+	if userID == 0 {
+		responseError(c, http.StatusUnauthorized, "未授权")
+		return
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		responseError(c, http.StatusBadRequest, "无效的ID参数")
+		return
+	}
+
+	result, err := a.recognitionService.UnadoptRecognition(id, userID)
+	if err != nil {
+		handleRecognitionError(c, err, "取消采用失败")
+		return
+	}
+
+	responseSuccess(c, result)
+}
+
+// UpdateRecognitionItems 修改识别结果的食物列表（增删食物、调整克数和营养数值）
+func (a *FoodRecognitionAPI) UpdateRecognitionItems(c *gin.Context) {
+	userID := getUserID(c)
+	if userID == 0 {
+		responseError(c, http.StatusUnauthorized, "未授权")
+		return
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		responseError(c, http.StatusBadRequest, "无效的ID参数")
+		return
+	}
+
+	var req services.UpdateRecognitionItemsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		responseError(c, http.StatusBadRequest, "请求参数错误", err.Error())
+		return
+	}
+
+	result, err := a.recognitionService.UpdateRecognitionItems(userID, id, &req)
+	if err != nil {
+		handleRecognitionError(c, err, "修改识别结果失败")
+		return
+	}
+
+	responseSuccess(c, result)
 }
 
 // GetAdoptedRecognitions 获取用户已采用的食物识别记录
@@ -146,9 +435,9 @@ func (a *FoodRecognitionAPI) GetAdoptedRecognitions(c *gin.Context) {
 		pageSize = 20
 	}
 
-	// 获取日期范围参数
-	startDate := c.DefaultQuery("start_date", time.Now().AddDate(0, 0, -30).Format("2006-01-02"))
-	endDate := c.DefaultQuery("end_date", time.Now().Format("2006-01-02"))
+	// 获取日期范围参数，为空时由服务按用户时区默认最近30天
+	startDate := c.Query("start_date")
+	endDate := c.Query("end_date")
 
 	// 调用服务获取记录
 	results, err := a.recognitionService.GetAdoptedRecognitions(userID, page, pageSize, startDate, endDate)
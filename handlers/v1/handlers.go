@@ -16,6 +16,17 @@ type Handlers struct {
 	Mood            *MoodAPI
 	Weight          *WeightAPI
 	Height          *HeightAPI
+	Water           *WaterAPI
+	Dashboard       *DashboardAPI
+	NutritionReport *NutritionReportAPI
+	Food            *FoodAPI
+	Recipe          *RecipeAPI
+	MealPlan        *MealPlanAPI
+	Recommendation  *RecommendationAPI
+	UserSetting     *UserSettingAPI
+	FavoriteFood    *FavoriteFoodAPI
+	DietScore       *DietScoreAPI
+	Admin           *AdminAPI
 }
 
 // NewHandlers 创建新的Handlers实例
@@ -30,6 +41,17 @@ func NewHandlers(
 	moodService *services.MoodService,
 	weightService *services.WeightService,
 	heightService *services.HeightService,
+	waterService *services.WaterService,
+	dashboardService *services.DashboardService,
+	nutritionReportService *services.NutritionReportService,
+	foodService *services.FoodService,
+	recipeService *services.RecipeService,
+	mealPlanService *services.MealPlanService,
+	recommendationService *services.RecommendationService,
+	userSettingService *services.UserSettingService,
+	favoriteFoodService *services.FavoriteFoodService,
+	dietScoreService *services.DietScoreService,
+	adminService *services.AdminService,
 ) *Handlers {
 	return &Handlers{
 		User:            NewUserAPI(userService),
@@ -42,5 +64,16 @@ func NewHandlers(
 		Mood:            NewMoodAPI(moodService),
 		Weight:          NewWeightAPI(weightService),
 		Height:          NewHeightAPI(heightService),
+		Water:           NewWaterAPI(waterService),
+		Dashboard:       NewDashboardAPI(dashboardService),
+		NutritionReport: NewNutritionReportAPI(nutritionReportService),
+		Food:            NewFoodAPI(foodService),
+		Recipe:          NewRecipeAPI(recipeService),
+		MealPlan:        NewMealPlanAPI(mealPlanService),
+		Recommendation:  NewRecommendationAPI(recommendationService),
+		UserSetting:     NewUserSettingAPI(userSettingService),
+		FavoriteFood:    NewFavoriteFoodAPI(favoriteFoodService),
+		DietScore:       NewDietScoreAPI(dietScoreService),
+		Admin:           NewAdminAPI(adminService),
 	}
 }
@@ -1,10 +1,12 @@
 package v1
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 
 	"ome-app-back/pkg/errcode"
 	"ome-app-back/services"
@@ -110,6 +112,10 @@ func (api *HeightAPI) DeleteHeight(c *gin.Context) {
 
 	err = api.heightService.DeleteHeight(userID, heightID)
 	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			errcode.NotFound.WithDetails("身高记录不存在").Response(c)
+			return
+		}
 		errcode.ServerError.WithDetails(err.Error()).Response(c)
 		return
 	}
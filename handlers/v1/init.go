@@ -17,5 +17,16 @@ func Init(services *services.Services) *Handlers {
 		Mood:            NewMoodAPI(services.MoodService),
 		Weight:          NewWeightAPI(services.WeightService),
 		Height:          NewHeightAPI(services.HeightService),
+		Water:           NewWaterAPI(services.WaterService),
+		Dashboard:       NewDashboardAPI(services.DashboardService),
+		NutritionReport: NewNutritionReportAPI(services.NutritionReportService),
+		Food:            NewFoodAPI(services.FoodService),
+		Recipe:          NewRecipeAPI(services.RecipeService),
+		MealPlan:        NewMealPlanAPI(services.MealPlanService),
+		Recommendation:  NewRecommendationAPI(services.RecommendationService),
+		UserSetting:     NewUserSettingAPI(services.UserSettingService),
+		FavoriteFood:    NewFavoriteFoodAPI(services.FavoriteFoodService),
+		DietScore:       NewDietScoreAPI(services.DietScoreService),
+		Admin:           NewAdminAPI(services.AdminService),
 	}
 }
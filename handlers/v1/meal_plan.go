@@ -0,0 +1,176 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"ome-app-back/services"
+)
+
+// MealPlanAPI 处理饮食计划相关接口
+type MealPlanAPI struct {
+	mealPlanService *services.MealPlanService
+}
+
+// NewMealPlanAPI 创建饮食计划API处理实例
+func NewMealPlanAPI(mealPlanService *services.MealPlanService) *MealPlanAPI {
+	return &MealPlanAPI{
+		mealPlanService: mealPlanService,
+	}
+}
+
+// GenerateMealPlan 生成一周饮食计划
+func (a *MealPlanAPI) GenerateMealPlan(c *gin.Context) {
+	userID := getUserID(c)
+	if userID == 0 {
+		responseError(c, http.StatusUnauthorized, "未授权")
+		return
+	}
+
+	var req services.GenerateMealPlanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		responseError(c, http.StatusBadRequest, "请求参数错误", err.Error())
+		return
+	}
+
+	plan, err := a.mealPlanService.GenerateMealPlan(userID, &req)
+	if err != nil {
+		handleMealPlanError(c, err, "生成饮食计划失败")
+		return
+	}
+
+	responseSuccess(c, plan)
+}
+
+// GetMealPlans 获取饮食计划列表
+func (a *MealPlanAPI) GetMealPlans(c *gin.Context) {
+	userID := getUserID(c)
+	if userID == 0 {
+		responseError(c, http.StatusUnauthorized, "未授权")
+		return
+	}
+
+	var req services.MealPlanListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		responseError(c, http.StatusBadRequest, "请求参数错误", err.Error())
+		return
+	}
+
+	plans, err := a.mealPlanService.GetMealPlans(userID, &req)
+	if err != nil {
+		responseError(c, http.StatusInternalServerError, "获取饮食计划列表失败", err.Error())
+		return
+	}
+
+	responseSuccess(c, plans)
+}
+
+// GetLatestMealPlan 获取最新的饮食计划
+func (a *MealPlanAPI) GetLatestMealPlan(c *gin.Context) {
+	userID := getUserID(c)
+	if userID == 0 {
+		responseError(c, http.StatusUnauthorized, "未授权")
+		return
+	}
+
+	plan, err := a.mealPlanService.GetLatestMealPlan(userID)
+	if err != nil {
+		responseError(c, http.StatusNotFound, "获取饮食计划失败", err.Error())
+		return
+	}
+
+	responseSuccess(c, plan)
+}
+
+// GetMealPlan 获取饮食计划详情
+func (a *MealPlanAPI) GetMealPlan(c *gin.Context) {
+	userID := getUserID(c)
+	if userID == 0 {
+		responseError(c, http.StatusUnauthorized, "未授权")
+		return
+	}
+
+	planID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		responseError(c, http.StatusBadRequest, "无效的计划ID")
+		return
+	}
+
+	plan, err := a.mealPlanService.GetMealPlan(userID, planID)
+	if err != nil {
+		responseError(c, http.StatusNotFound, "获取饮食计划失败", err.Error())
+		return
+	}
+
+	responseSuccess(c, plan)
+}
+
+// RegenerateMeal 重新生成计划中的某一餐
+func (a *MealPlanAPI) RegenerateMeal(c *gin.Context) {
+	userID := getUserID(c)
+	if userID == 0 {
+		responseError(c, http.StatusUnauthorized, "未授权")
+		return
+	}
+
+	itemID, err := strconv.ParseInt(c.Param("item_id"), 10, 64)
+	if err != nil {
+		responseError(c, http.StatusBadRequest, "无效的餐次ID")
+		return
+	}
+
+	var req services.RegenerateMealRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		responseError(c, http.StatusBadRequest, "请求参数错误", err.Error())
+		return
+	}
+
+	item, err := a.mealPlanService.RegenerateMeal(userID, itemID, &req)
+	if err != nil {
+		handleMealPlanError(c, err, "重新生成餐次失败")
+		return
+	}
+
+	responseSuccess(c, item)
+}
+
+// LogPlannedMeal 将计划中的一餐记录到饮食记录
+func (a *MealPlanAPI) LogPlannedMeal(c *gin.Context) {
+	userID := getUserID(c)
+	if userID == 0 {
+		responseError(c, http.StatusUnauthorized, "未授权")
+		return
+	}
+
+	itemID, err := strconv.ParseInt(c.Param("item_id"), 10, 64)
+	if err != nil {
+		responseError(c, http.StatusBadRequest, "无效的餐次ID")
+		return
+	}
+
+	var req services.LogPlannedMealRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		responseError(c, http.StatusBadRequest, "请求参数错误", err.Error())
+		return
+	}
+
+	result, err := a.mealPlanService.LogPlannedMeal(userID, itemID, &req)
+	if err != nil {
+		handleNutritionError(c, err, "记录计划餐次失败")
+		return
+	}
+
+	responseSuccess(c, result)
+}
+
+// handleMealPlanError 统一处理饮食计划生成相关错误
+func handleMealPlanError(c *gin.Context, err error, msg string) {
+	if errors.Is(err, services.ErrMealPlanInvalid) {
+		responseError(c, http.StatusBadGateway, msg, err.Error())
+		return
+	}
+	handleNutritionError(c, err, msg)
+}
@@ -4,11 +4,13 @@ import (
 	"errors"
 	"log"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 
+	"ome-app-back/models"
 	"ome-app-back/services"
 )
 
@@ -70,6 +72,8 @@ type UpdateNutritionInput struct {
 	ProteinIntakeG float64 `json:"protein_intake_g"`
 	CarbIntakeG    float64 `json:"carb_intake_g"`
 	FatIntakeG     float64 `json:"fat_intake_g"`
+
+	MicronutrientIntake models.Micronutrients `json:"micronutrient_intake"` // 可选，微量营养素摄入量
 }
 
 // UpdateTodayNutrition 更新今日营养摄入量
@@ -92,6 +96,7 @@ func (a *NutritionAPI) UpdateTodayNutrition(c *gin.Context) {
 		input.ProteinIntakeG,
 		input.CarbIntakeG,
 		input.FatIntakeG,
+		input.MicronutrientIntake,
 	)
 	if err != nil {
 		responseError(c, http.StatusInternalServerError, "更新营养数据失败", err.Error())
@@ -162,6 +167,165 @@ func (a *NutritionAPI) GetWeekSummary(c *gin.Context) {
 	responseSuccess(c, summary)
 }
 
+// GetNutritionByDate 获取指定日期的营养数据
+func (a *NutritionAPI) GetNutritionByDate(c *gin.Context) {
+	userID := getUserID(c)
+	if userID == 0 {
+		responseError(c, http.StatusUnauthorized, "未授权")
+		return
+	}
+
+	nutrition, err := a.nutritionService.GetNutritionByDate(userID, c.Param("date"))
+	if err != nil {
+		log.Printf("[营养API] 用户(ID:%d)获取%s营养数据失败: %v", userID, c.Param("date"), err)
+		handleNutritionError(c, err, "获取营养数据失败")
+		return
+	}
+
+	responseSuccess(c, nutrition)
+}
+
+// UpdateNutritionByDate 更新指定日期的营养摄入量
+func (a *NutritionAPI) UpdateNutritionByDate(c *gin.Context) {
+	userID := getUserID(c)
+	if userID == 0 {
+		responseError(c, http.StatusUnauthorized, "未授权")
+		return
+	}
+
+	var input UpdateNutritionInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		responseError(c, http.StatusBadRequest, "请求参数错误")
+		return
+	}
+
+	nutrition, err := a.nutritionService.UpdateNutritionByDate(
+		userID,
+		c.Param("date"),
+		input.CaloriesIntake,
+		input.ProteinIntakeG,
+		input.CarbIntakeG,
+		input.FatIntakeG,
+		input.MicronutrientIntake,
+	)
+	if err != nil {
+		handleNutritionError(c, err, "更新营养数据失败")
+		return
+	}
+
+	responseSuccess(c, nutrition)
+}
+
+// GetNutritionEntries 获取指定日期的饮食记录条目
+func (a *NutritionAPI) GetNutritionEntries(c *gin.Context) {
+	userID := getUserID(c)
+	if userID == 0 {
+		responseError(c, http.StatusUnauthorized, "未授权")
+		return
+	}
+
+	entries, err := a.nutritionService.GetNutritionEntries(userID, c.Param("date"))
+	if err != nil {
+		responseError(c, http.StatusBadRequest, "获取饮食记录失败", err.Error())
+		return
+	}
+
+	responseSuccess(c, entries)
+}
+
+// AddNutritionEntry 向指定日期添加饮食记录条目
+func (a *NutritionAPI) AddNutritionEntry(c *gin.Context) {
+	userID := getUserID(c)
+	if userID == 0 {
+		responseError(c, http.StatusUnauthorized, "未授权")
+		return
+	}
+
+	var req services.AddNutritionEntryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		responseError(c, http.StatusBadRequest, "请求参数错误", err.Error())
+		return
+	}
+
+	result, err := a.nutritionService.AddNutritionEntry(userID, c.Param("date"), &req)
+	if err != nil {
+		handleNutritionError(c, err, "添加饮食记录失败")
+		return
+	}
+
+	responseSuccess(c, result)
+}
+
+// DeleteNutritionEntry 删除饮食记录条目
+func (a *NutritionAPI) DeleteNutritionEntry(c *gin.Context) {
+	userID := getUserID(c)
+	if userID == 0 {
+		responseError(c, http.StatusUnauthorized, "未授权")
+		return
+	}
+
+	entryID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		responseError(c, http.StatusBadRequest, "无效的ID参数")
+		return
+	}
+
+	nutrition, err := a.nutritionService.DeleteNutritionEntry(userID, entryID)
+	if err != nil {
+		handleNutritionError(c, err, "删除饮食记录失败")
+		return
+	}
+
+	responseSuccess(c, nutrition)
+}
+
+// CopyMeal 复制历史餐次到指定日期（默认今天）
+func (a *NutritionAPI) CopyMeal(c *gin.Context) {
+	userID := getUserID(c)
+	if userID == 0 {
+		responseError(c, http.StatusUnauthorized, "未授权")
+		return
+	}
+
+	var req services.CopyMealRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		responseError(c, http.StatusBadRequest, "请求参数错误", err.Error())
+		return
+	}
+
+	result, err := a.nutritionService.CopyMeal(userID, &req)
+	if err != nil {
+		handleNutritionError(c, err, "复制餐次失败")
+		return
+	}
+
+	responseSuccess(c, result)
+}
+
+// handleNutritionError 统一处理营养相关业务错误
+func handleNutritionError(c *gin.Context, err error, msg string) {
+	switch {
+	case errors.Is(err, services.ErrNoHealthAnalysis):
+		c.JSON(http.StatusOK, gin.H{
+			"code":    10001, // 使用特定错误码标识需要健康分析
+			"msg":     "请先生成健康分析",
+			"data":    nil,
+			"details": []string{"用户尚未生成健康分析报告，无法创建营养记录"},
+		})
+	case errors.Is(err, services.ErrNutritionDateOutOfRange), errors.Is(err, services.ErrNoEntriesToCopy):
+		responseError(c, http.StatusBadRequest, msg, err.Error())
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		responseError(c, http.StatusNotFound, msg, "记录不存在")
+	default:
+		responseError(c, http.StatusInternalServerError, msg, err.Error())
+	}
+}
+
+// GetMicronutrientOptions 获取追踪的微量营养素配置
+func (a *NutritionAPI) GetMicronutrientOptions(c *gin.Context) {
+	responseSuccess(c, a.nutritionService.GetMicronutrientOptions())
+}
+
 // 辅助函数，获取用户ID
 func getUserID(c *gin.Context) int64 {
 	value, exists := c.Get("user_id")
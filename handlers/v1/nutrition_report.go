@@ -0,0 +1,83 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"ome-app-back/services"
+)
+
+// NutritionReportAPI 处理营养报表相关接口
+type NutritionReportAPI struct {
+	reportService *services.NutritionReportService
+}
+
+// NewNutritionReportAPI 创建营养报表API处理实例
+func NewNutritionReportAPI(reportService *services.NutritionReportService) *NutritionReportAPI {
+	return &NutritionReportAPI{
+		reportService: reportService,
+	}
+}
+
+// GetNutritionReportInput 获取营养报表的请求参数
+type GetNutritionReportInput struct {
+	StartDate string `form:"start_date"`                                      // 格式: 2023-04-01
+	EndDate   string `form:"end_date"`                                        // 格式: 2023-04-30
+	Month     string `form:"month"`                                           // 格式: 2023-04，指定后忽略 start_date/end_date
+	Period    string `form:"period" binding:"omitempty,oneof=day week month"` // 分桶周期，默认 day
+}
+
+// GetNutritionReport 获取营养报表
+func (a *NutritionReportAPI) GetNutritionReport(c *gin.Context) {
+	userID := getUserID(c)
+	if userID == 0 {
+		responseError(c, http.StatusUnauthorized, "未授权")
+		return
+	}
+
+	var input GetNutritionReportInput
+	if err := c.ShouldBindQuery(&input); err != nil {
+		responseError(c, http.StatusBadRequest, "请求参数错误", err.Error())
+		return
+	}
+
+	var startDate, endDate time.Time
+	switch {
+	case input.Month != "":
+		month, err := time.Parse("2006-01", input.Month)
+		if err != nil {
+			responseError(c, http.StatusBadRequest, "月份格式错误")
+			return
+		}
+		startDate = month
+		endDate = month.AddDate(0, 1, -1)
+	case input.StartDate != "" && input.EndDate != "":
+		var err error
+		startDate, err = time.Parse("2006-01-02", input.StartDate)
+		if err != nil {
+			responseError(c, http.StatusBadRequest, "开始日期格式错误")
+			return
+		}
+		endDate, err = time.Parse("2006-01-02", input.EndDate)
+		if err != nil {
+			responseError(c, http.StatusBadRequest, "结束日期格式错误")
+			return
+		}
+	}
+	// 未指定范围时由服务按用户时区默认取最近30天
+
+	report, err := a.reportService.GetReport(userID, startDate, endDate, input.Period)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidReportRange) {
+			responseError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		responseError(c, http.StatusInternalServerError, "获取营养报表失败", err.Error())
+		return
+	}
+
+	responseSuccess(c, report)
+}
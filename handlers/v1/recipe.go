@@ -0,0 +1,188 @@
+package v1
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"ome-app-back/services"
+)
+
+// RecipeAPI 处理食谱相关接口
+type RecipeAPI struct {
+	recipeService *services.RecipeService
+}
+
+// NewRecipeAPI 创建食谱API处理实例
+func NewRecipeAPI(recipeService *services.RecipeService) *RecipeAPI {
+	return &RecipeAPI{
+		recipeService: recipeService,
+	}
+}
+
+// CreateRecipe 创建食谱
+func (a *RecipeAPI) CreateRecipe(c *gin.Context) {
+	userID := getUserID(c)
+	if userID == 0 {
+		responseError(c, http.StatusUnauthorized, "未授权")
+		return
+	}
+
+	var req services.SaveRecipeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		responseError(c, http.StatusBadRequest, "请求参数错误", err.Error())
+		return
+	}
+
+	recipe, err := a.recipeService.CreateRecipe(userID, &req)
+	if err != nil {
+		responseError(c, http.StatusBadRequest, "创建食谱失败", err.Error())
+		return
+	}
+
+	responseSuccess(c, recipe)
+}
+
+// UpdateRecipe 更新食谱
+func (a *RecipeAPI) UpdateRecipe(c *gin.Context) {
+	userID := getUserID(c)
+	if userID == 0 {
+		responseError(c, http.StatusUnauthorized, "未授权")
+		return
+	}
+
+	recipeID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		responseError(c, http.StatusBadRequest, "无效的食谱ID")
+		return
+	}
+
+	var req services.SaveRecipeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		responseError(c, http.StatusBadRequest, "请求参数错误", err.Error())
+		return
+	}
+
+	recipe, err := a.recipeService.UpdateRecipe(userID, recipeID, &req)
+	if err != nil {
+		responseError(c, http.StatusBadRequest, "更新食谱失败", err.Error())
+		return
+	}
+
+	responseSuccess(c, recipe)
+}
+
+// GetRecipe 获取食谱详情
+func (a *RecipeAPI) GetRecipe(c *gin.Context) {
+	userID := getUserID(c)
+	if userID == 0 {
+		responseError(c, http.StatusUnauthorized, "未授权")
+		return
+	}
+
+	recipeID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		responseError(c, http.StatusBadRequest, "无效的食谱ID")
+		return
+	}
+
+	recipe, err := a.recipeService.GetRecipe(userID, recipeID)
+	if err != nil {
+		responseError(c, http.StatusNotFound, "获取食谱失败", err.Error())
+		return
+	}
+
+	responseSuccess(c, recipe)
+}
+
+// GetMyRecipes 获取我的食谱列表
+func (a *RecipeAPI) GetMyRecipes(c *gin.Context) {
+	userID := getUserID(c)
+	if userID == 0 {
+		responseError(c, http.StatusUnauthorized, "未授权")
+		return
+	}
+
+	recipes, err := a.recipeService.GetMyRecipes(userID)
+	if err != nil {
+		responseError(c, http.StatusInternalServerError, "获取食谱列表失败", err.Error())
+		return
+	}
+
+	responseSuccess(c, recipes)
+}
+
+// GetPublicRecipes 搜索公开分享的食谱
+func (a *RecipeAPI) GetPublicRecipes(c *gin.Context) {
+	userID := getUserID(c)
+	if userID == 0 {
+		responseError(c, http.StatusUnauthorized, "未授权")
+		return
+	}
+
+	var req services.PublicRecipeRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		responseError(c, http.StatusBadRequest, "请求参数错误", err.Error())
+		return
+	}
+
+	recipes, err := a.recipeService.GetPublicRecipes(&req)
+	if err != nil {
+		responseError(c, http.StatusInternalServerError, "获取公开食谱失败", err.Error())
+		return
+	}
+
+	responseSuccess(c, recipes)
+}
+
+// DeleteRecipe 删除食谱
+func (a *RecipeAPI) DeleteRecipe(c *gin.Context) {
+	userID := getUserID(c)
+	if userID == 0 {
+		responseError(c, http.StatusUnauthorized, "未授权")
+		return
+	}
+
+	recipeID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		responseError(c, http.StatusBadRequest, "无效的食谱ID")
+		return
+	}
+
+	if err := a.recipeService.DeleteRecipe(userID, recipeID); err != nil {
+		responseError(c, http.StatusInternalServerError, "删除食谱失败", err.Error())
+		return
+	}
+
+	responseSuccess(c, nil)
+}
+
+// LogRecipe 按份数记录食谱到饮食记录
+func (a *RecipeAPI) LogRecipe(c *gin.Context) {
+	userID := getUserID(c)
+	if userID == 0 {
+		responseError(c, http.StatusUnauthorized, "未授权")
+		return
+	}
+
+	recipeID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		responseError(c, http.StatusBadRequest, "无效的食谱ID")
+		return
+	}
+
+	var req services.LogRecipeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		responseError(c, http.StatusBadRequest, "请求参数错误", err.Error())
+		return
+	}
+
+	result, err := a.recipeService.LogRecipe(userID, recipeID, &req)
+	if err != nil {
+		handleNutritionError(c, err, "记录食谱失败")
+		return
+	}
+
+	responseSuccess(c, result)
+}
@@ -0,0 +1,44 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"ome-app-back/services"
+)
+
+// RecommendationAPI 处理今日推荐相关接口
+type RecommendationAPI struct {
+	recommendationService *services.RecommendationService
+}
+
+// NewRecommendationAPI 创建今日推荐API处理实例
+func NewRecommendationAPI(recommendationService *services.RecommendationService) *RecommendationAPI {
+	return &RecommendationAPI{
+		recommendationService: recommendationService,
+	}
+}
+
+// GetTodayRecommendations 获取今日推荐
+func (a *RecommendationAPI) GetTodayRecommendations(c *gin.Context) {
+	userID := getUserID(c)
+	if userID == 0 {
+		responseError(c, http.StatusUnauthorized, "未授权")
+		return
+	}
+
+	var req services.RecommendationRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		responseError(c, http.StatusBadRequest, "请求参数错误", err.Error())
+		return
+	}
+
+	result, err := a.recommendationService.GetTodayRecommendations(userID, &req)
+	if err != nil {
+		handleNutritionError(c, err, "获取今日推荐失败")
+		return
+	}
+
+	responseSuccess(c, result)
+}
@@ -0,0 +1,89 @@
+package v1
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"ome-app-back/models/constant"
+	"ome-app-back/pkg/errcode"
+	"ome-app-back/services"
+)
+
+// UserSettingAPI 用户设置API
+type UserSettingAPI struct {
+	userSettingService *services.UserSettingService
+}
+
+// NewUserSettingAPI 创建用户设置API实例
+func NewUserSettingAPI(userSettingService *services.UserSettingService) *UserSettingAPI {
+	return &UserSettingAPI{
+		userSettingService: userSettingService,
+	}
+}
+
+// GetSettings 获取用户设置
+func (api *UserSettingAPI) GetSettings(c *gin.Context) {
+	userID := getUserIDFromContext(c)
+	if userID == 0 {
+		errcode.UnauthorizedTokenError.Response(c)
+		return
+	}
+
+	setting, err := api.userSettingService.GetSettings(userID)
+	if err != nil {
+		errcode.ServerError.WithDetails(err.Error()).Response(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"msg":  "成功",
+		"data": setting,
+	})
+}
+
+// UpdateSettings 更新用户设置
+func (api *UserSettingAPI) UpdateSettings(c *gin.Context) {
+	userID := getUserIDFromContext(c)
+	if userID == 0 {
+		errcode.UnauthorizedTokenError.Response(c)
+		return
+	}
+
+	var req services.UpdateUserSettingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		errcode.InvalidParams.WithDetails(err.Error()).Response(c)
+		return
+	}
+
+	setting, err := api.userSettingService.UpdateSettings(userID, &req)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidTimezone) {
+			errcode.InvalidParams.WithDetails(err.Error()).Response(c)
+			return
+		}
+		errcode.ServerError.WithDetails(err.Error()).Response(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"msg":  "成功",
+		"data": setting,
+	})
+}
+
+// SyncTimezone 根据请求头中的时区自动更新用户时区，作为认证路由的中间件使用，失败不影响请求
+func (api *UserSettingAPI) SyncTimezone(c *gin.Context) {
+	timezone := c.GetHeader(constant.TimezoneHeader)
+	userID := getUserIDFromContext(c)
+	if timezone != "" && userID != 0 {
+		if err := api.userSettingService.SyncTimezone(userID, timezone); err != nil {
+			log.Printf("[用户设置] 用户(ID:%d)同步时区%s失败: %v", userID, timezone, err)
+		}
+	}
+	c.Next()
+}
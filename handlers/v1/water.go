@@ -0,0 +1,160 @@
+package v1
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"ome-app-back/pkg/errcode"
+	"ome-app-back/services"
+)
+
+// WaterAPI 饮水API
+type WaterAPI struct {
+	waterService *services.WaterService
+}
+
+// NewWaterAPI 创建饮水API实例
+func NewWaterAPI(waterService *services.WaterService) *WaterAPI {
+	return &WaterAPI{
+		waterService: waterService,
+	}
+}
+
+// CreateWater 创建饮水记录
+func (api *WaterAPI) CreateWater(c *gin.Context) {
+	userID := getUserIDFromContext(c)
+	if userID == 0 {
+		errcode.UnauthorizedTokenError.Response(c)
+		return
+	}
+
+	var req services.CreateWaterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		errcode.InvalidParams.WithDetails(err.Error()).Response(c)
+		return
+	}
+
+	record, err := api.waterService.CreateWater(userID, &req)
+	if err != nil {
+		errcode.ServerError.WithDetails(err.Error()).Response(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"msg":  "成功",
+		"data": record,
+	})
+}
+
+// GetTodayWater 获取今日饮水情况
+func (api *WaterAPI) GetTodayWater(c *gin.Context) {
+	userID := getUserIDFromContext(c)
+	if userID == 0 {
+		errcode.UnauthorizedTokenError.Response(c)
+		return
+	}
+
+	today, err := api.waterService.GetTodayWater(userID)
+	if err != nil {
+		errcode.ServerError.WithDetails(err.Error()).Response(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"msg":  "成功",
+		"data": today,
+	})
+}
+
+// GetWaterHistory 获取饮水历史记录
+func (api *WaterAPI) GetWaterHistory(c *gin.Context) {
+	userID := getUserIDFromContext(c)
+	if userID == 0 {
+		errcode.UnauthorizedTokenError.Response(c)
+		return
+	}
+
+	var req services.WaterHistoryRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		errcode.InvalidParams.WithDetails(err.Error()).Response(c)
+		return
+	}
+
+	history, err := api.waterService.GetWaterHistory(userID, &req)
+	if err != nil {
+		errcode.ServerError.WithDetails(err.Error()).Response(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"msg":  "成功",
+		"data": history,
+	})
+}
+
+// GetWaterStatistics 获取饮水统计分析
+func (api *WaterAPI) GetWaterStatistics(c *gin.Context) {
+	userID := getUserIDFromContext(c)
+	if userID == 0 {
+		errcode.UnauthorizedTokenError.Response(c)
+		return
+	}
+
+	var req services.WaterStatisticsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		errcode.InvalidParams.WithDetails(err.Error()).Response(c)
+		return
+	}
+
+	stats, err := api.waterService.GetWaterStatistics(userID, &req)
+	if err != nil {
+		errcode.ServerError.WithDetails(err.Error()).Response(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"msg":  "成功",
+		"data": stats,
+	})
+}
+
+// DeleteWater 删除饮水记录
+func (api *WaterAPI) DeleteWater(c *gin.Context) {
+	userID := getUserIDFromContext(c)
+	if userID == 0 {
+		errcode.UnauthorizedTokenError.Response(c)
+		return
+	}
+
+	recordID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		errcode.InvalidParams.WithDetails("饮水记录ID格式错误").Response(c)
+		return
+	}
+
+	if err := api.waterService.DeleteWater(userID, recordID); err != nil {
+		errcode.ServerError.WithDetails(err.Error()).Response(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"msg":  "成功",
+		"data": nil,
+	})
+}
+
+// GetWaterOptions 获取饮水快捷选项
+func (api *WaterAPI) GetWaterOptions(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"msg":  "成功",
+		"data": api.waterService.GetWaterOptions(),
+	})
+}
@@ -0,0 +1,10 @@
+package models
+
+// AllergenWarning 过敏原/不耐受警告
+type AllergenWarning struct {
+	FoodName     string `json:"food_name"`     // 触发警告的食物
+	Intolerance  string `json:"intolerance"`   // 命中的用户不耐受项
+	Allergen     string `json:"allergen"`      // 过敏原代码，用户自定义不耐受项时为空
+	AllergenName string `json:"allergen_name"` // 过敏原名称
+	Source       string `json:"source"`        // 警告来源: keyword / ai
+}
@@ -21,6 +21,19 @@ type ChatMessage struct {
 	Role      ChatRole  `json:"role" gorm:"type:varchar(10);not null"`                // 角色
 	Content   string    `json:"content" gorm:"type:text;not null"`                    // 消息内容
 	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+
+	Attachments   []ChatAttachment `json:"attachments,omitempty" gorm:"serializer:json"` // 消息附带的图片
+	RecognitionID *int64           `json:"recognition_id,omitempty" gorm:"index"`        // 关联的食物识别记录ID
+}
+
+// ChatAttachmentImage 图片附件类型
+const ChatAttachmentImage = "image"
+
+// ChatAttachment 聊天消息附件
+type ChatAttachment struct {
+	Type        string `json:"type"`                   // 附件类型: image
+	URL         string `json:"url"`                    // 预处理后的图片URL
+	OriginalURL string `json:"original_url,omitempty"` // 原图URL
 }
 
 // TableName 表名
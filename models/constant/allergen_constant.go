@@ -0,0 +1,76 @@
+package constant
+
+// Allergen 常见过敏原/不耐受类别
+type Allergen struct {
+	Key      string   // 过敏原代码，AI识别时使用
+	Name     string   // 中文名称
+	Aliases  []string // 用户填写不耐受项时可能使用的同义词
+	Keywords []string // 食物名称中出现即视为含有该过敏原的关键词
+}
+
+// Allergens 过敏原映射表
+var Allergens = []Allergen{
+	{
+		Key:      "lactose",
+		Name:     "乳糖",
+		Aliases:  []string{"乳糖", "乳制品", "奶制品", "牛奶", "lactose", "dairy", "milk"},
+		Keywords: []string{"牛奶", "酸奶", "奶酪", "芝士", "奶油", "黄油", "奶昔", "奶茶", "拿铁", "炼乳", "乳清", "冰淇淋", "双皮奶", "milk", "cheese", "yogurt"},
+	},
+	{
+		Key:      "gluten",
+		Name:     "麸质",
+		Aliases:  []string{"麸质", "小麦", "面筋", "gluten", "wheat"},
+		Keywords: []string{"小麦", "面粉", "面条", "面包", "吐司", "馒头", "包子", "饺子", "馄饨", "烧卖", "面筋", "烤麸", "意面", "拉面", "蛋糕", "饼干", "披萨", "三明治", "汉堡", "油条", "啤酒", "bread", "pasta", "noodle"},
+	},
+	{
+		Key:      "peanut",
+		Name:     "花生",
+		Aliases:  []string{"花生", "peanut", "peanuts"},
+		Keywords: []string{"花生", "宫保", "peanut"},
+	},
+	{
+		Key:      "tree_nut",
+		Name:     "坚果",
+		Aliases:  []string{"坚果", "树坚果", "nut", "nuts", "tree nut"},
+		Keywords: []string{"坚果", "杏仁", "核桃", "腰果", "榛子", "开心果", "碧根果", "夏威夷果", "松子", "almond", "walnut", "cashew"},
+	},
+	{
+		Key:      "egg",
+		Name:     "鸡蛋",
+		Aliases:  []string{"鸡蛋", "蛋类", "蛋", "egg", "eggs"},
+		Keywords: []string{"鸡蛋", "鸭蛋", "鹌鹑蛋", "蛋黄", "蛋清", "蛋饼", "蛋挞", "蛋糕", "荷包蛋", "水煮蛋", "煎蛋", "炒蛋", "蒸蛋", "蛋花", "蛋炒饭", "蛋黄酱", "egg"},
+	},
+	{
+		Key:      "soy",
+		Name:     "大豆",
+		Aliases:  []string{"大豆", "黄豆", "豆制品", "soy", "soybean"},
+		Keywords: []string{"大豆", "黄豆", "豆腐", "豆浆", "豆干", "豆皮", "腐竹", "毛豆", "酱油", "味噌", "tofu", "soy"},
+	},
+	{
+		Key:      "shellfish",
+		Name:     "甲壳类/贝类",
+		Aliases:  []string{"海鲜", "甲壳类", "贝类", "虾", "蟹", "shellfish", "seafood"},
+		Keywords: []string{"虾", "蟹", "扇贝", "干贝", "蛤蜊", "花甲", "牡蛎", "生蚝", "青口", "鲍鱼", "螺", "鱿鱼", "章鱼", "墨鱼", "shrimp", "crab"},
+	},
+	{
+		Key:      "fish",
+		Name:     "鱼类",
+		Aliases:  []string{"鱼", "鱼类", "fish"},
+		Keywords: []string{"鱼肉", "鱼片", "鱼丸", "鱼汤", "烤鱼", "三文鱼", "鲑鱼", "鲈鱼", "鳕鱼", "金枪鱼", "鲫鱼", "草鱼", "鲤鱼", "带鱼", "黄鱼", "秋刀鱼", "fish", "salmon", "tuna"},
+	},
+	{
+		Key:      "sesame",
+		Name:     "芝麻",
+		Aliases:  []string{"芝麻", "sesame"},
+		Keywords: []string{"芝麻", "麻酱", "香油", "sesame"},
+	},
+}
+
+// NoIntoleranceValues 表示"无不耐受"的填写值，检查时忽略
+var NoIntoleranceValues = []string{"无", "没有", "暂无", "none", "no"}
+
+// AllergenWarningSource 过敏原警告来源常量
+const (
+	AllergenSourceKeyword = "keyword" // 食物名称匹配映射表
+	AllergenSourceAI      = "ai"      // AI识别时标记
+)
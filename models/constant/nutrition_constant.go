@@ -0,0 +1,45 @@
+package constant
+
+// MealType 餐次常量
+const (
+	MealTypeBreakfast = "breakfast"
+	MealTypeLunch     = "lunch"
+	MealTypeDinner    = "dinner"
+	MealTypeSnack     = "snack"
+)
+
+// MealTypes 所有餐次选项
+var MealTypes = []string{
+	MealTypeBreakfast,
+	MealTypeLunch,
+	MealTypeDinner,
+	MealTypeSnack,
+}
+
+// ValidMealTypesMap 有效餐次映射（用于快速验证）
+var ValidMealTypesMap = func() map[string]bool {
+	m := make(map[string]bool)
+	for _, mealType := range MealTypes {
+		m[mealType] = true
+	}
+	return m
+}()
+
+// NutritionEntrySource 饮食记录来源常量
+const (
+	EntrySourceManual      = "manual"      // 手动录入
+	EntrySourceRecognition = "recognition" // 食物识别采用
+	EntrySourceRecipe      = "recipe"      // 按食谱记录
+	EntrySourceMealPlan    = "meal_plan"   // 按饮食计划记录
+	EntrySourceFavorite    = "favorite"    // 从收藏记录
+	EntrySourceCopy        = "copy"        // 从历史餐次复制
+)
+
+// ExerciseCalorieMode 运动消耗回补到热量预算的方式
+const (
+	ExerciseCalorieModeNone    = "none"    // 不计入
+	ExerciseCalorieModePartial = "partial" // 按比例计入
+	ExerciseCalorieModeFull    = "full"    // 全部计入
+
+	DefaultExerciseCalorieRatio = 0.5 // partial 模式默认计入比例
+)
@@ -0,0 +1,18 @@
+package constant
+
+// RecognitionStatus 食物识别任务状态常量
+const (
+	RecognitionStatusPending    = "pending"    // 已提交，等待处理
+	RecognitionStatusProcessing = "processing" // AI识别中
+	RecognitionStatusCompleted  = "completed"  // 识别完成
+	RecognitionStatusFailed     = "failed"     // 识别失败，可重试
+)
+
+// RecognitionLowConfidence 识别置信度低于该值且用户未确认的食物需提示用户核对
+const RecognitionLowConfidence = 0.6
+
+// RecognitionSource 食物识别的输入来源常量
+const (
+	RecognitionSourceImage = "image" // 拍照识别
+	RecognitionSourceText  = "text"  // 文字描述识别
+)
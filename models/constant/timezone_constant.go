@@ -0,0 +1,7 @@
+package constant
+
+// 用户时区相关常量
+const (
+	DefaultTimezone = "Asia/Shanghai" // 用户未设置时区时使用的默认时区
+	TimezoneHeader  = "X-Timezone"    // 客户端上报IANA时区的请求头
+)
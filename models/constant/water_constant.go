@@ -0,0 +1,13 @@
+package constant
+
+// QuickAddWaterAmounts 快捷添加的饮水量选项(毫升)
+var QuickAddWaterAmounts = []int{150, 250, 350, 500}
+
+// 每日饮水目标计算参数
+const (
+	DefaultDailyWaterML      = 2000 // 无体重记录时的默认每日饮水目标(毫升)
+	WaterMLPerKG             = 35   // 每公斤体重每日需水量(毫升)
+	WaterMLPerExerciseMinute = 12   // 每分钟运动额外需水量(毫升)
+	MaxDailyWaterTargetML    = 5000 // 每日饮水目标上限(毫升)
+	MaxSingleWaterRecordML   = 2000 // 单次饮水记录上限(毫升)
+)
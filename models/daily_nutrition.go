@@ -1,6 +1,7 @@
 package models
 
 import (
+	"math"
 	"time"
 )
 
@@ -16,19 +17,157 @@ type DailyNutrition struct {
 	CarbIntakeG    float64 `json:"carb_intake_g" gorm:"type:decimal(6,2);default:0"`
 	FatIntakeG     float64 `json:"fat_intake_g" gorm:"type:decimal(6,2);default:0"`
 
+	// 微量营养素摄入量（按配置的营养素标识存储，如 fiber_g、sodium_mg）
+	MicronutrientIntake Micronutrients `json:"micronutrient_intake" gorm:"serializer:json"`
+
 	// 目标摄入量（根据健康分析生成）
 	TargetCalories float64 `json:"target_calories" gorm:"type:decimal(6,2);default:0"`
 	TargetProteinG float64 `json:"target_protein_g" gorm:"type:decimal(6,2);default:0"`
 	TargetCarbG    float64 `json:"target_carb_g" gorm:"type:decimal(6,2);default:0"`
 	TargetFatG     float64 `json:"target_fat_g" gorm:"type:decimal(6,2);default:0"`
 
+	// 微量营养素目标（根据健康分析生成）
+	MicronutrientTargets Micronutrients `json:"micronutrient_targets" gorm:"serializer:json"`
+
 	// 完成率与统计
 	CaloriesCompletionRate float64 `json:"calories_completion_rate" gorm:"type:decimal(5,2)"` // 热量目标完成率(%)
+	ProteinCompletionRate  float64 `json:"protein_completion_rate" gorm:"type:decimal(5,2)"`  // 蛋白质目标完成率(%)
+	CarbCompletionRate     float64 `json:"carb_completion_rate" gorm:"type:decimal(5,2)"`     // 碳水目标完成率(%)
+	FatCompletionRate      float64 `json:"fat_completion_rate" gorm:"type:decimal(5,2)"`      // 脂肪目标完成率(%)
 
 	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
 	UpdatedAt time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+
+	// 能量收支（结合当日运动消耗实时计算，不入库）
+	EnergyBalance *EnergyBalance `json:"energy_balance,omitempty" gorm:"-"`
 }
 
 func (DailyNutrition) TableName() string {
 	return "daily_nutrition"
 }
+
+// EnergyBalance 每日能量收支
+type EnergyBalance struct {
+	TargetCalories    float64 `json:"target_calories"`       // 目标热量(千卡)
+	IntakeCalories    float64 `json:"intake_calories"`       // 摄入热量(千卡)
+	BurnedCalories    float64 `json:"burned_calories"`       // 运动消耗(千卡)
+	EarnedCalories    float64 `json:"earned_calories"`       // 按设置计入预算的运动消耗(千卡)
+	NetCalories       float64 `json:"net_calories"`          // 净摄入 = 摄入 - 运动消耗
+	RemainingCalories float64 `json:"remaining_calories"`    // 剩余预算 = 目标 + 计入的运动消耗 - 摄入，负数表示超出
+	Mode              string  `json:"exercise_calorie_mode"` // 运动消耗回补方式
+}
+
+// NewEnergyBalance 根据目标、摄入、运动消耗及计入比例计算能量收支
+func NewEnergyBalance(target, intake, burned, factor float64, mode string) *EnergyBalance {
+	earned := burned * factor
+	return &EnergyBalance{
+		TargetCalories:    roundTwo(target),
+		IntakeCalories:    roundTwo(intake),
+		BurnedCalories:    roundTwo(burned),
+		EarnedCalories:    roundTwo(earned),
+		NetCalories:       roundTwo(intake - burned),
+		RemainingCalories: roundTwo(target + earned - intake),
+		Mode:              mode,
+	}
+}
+
+// UpdateCompletionRate 根据摄入量与目标值重新计算热量及各宏量营养素的完成率
+func (n *DailyNutrition) UpdateCompletionRate() {
+	n.CaloriesCompletionRate = completionRate(n.CaloriesIntake, n.TargetCalories)
+	n.ProteinCompletionRate = completionRate(n.ProteinIntakeG, n.TargetProteinG)
+	n.CarbCompletionRate = completionRate(n.CarbIntakeG, n.TargetCarbG)
+	n.FatCompletionRate = completionRate(n.FatIntakeG, n.TargetFatG)
+}
+
+// ApplyTargets 更新目标摄入量并重新计算完成率
+func (n *DailyNutrition) ApplyTargets(calories, proteinG, carbG, fatG float64, micronutrientTargets Micronutrients) {
+	n.TargetCalories = calories
+	n.TargetProteinG = proteinG
+	n.TargetCarbG = carbG
+	n.TargetFatG = fatG
+	n.MicronutrientTargets = micronutrientTargets
+	n.UpdateCompletionRate()
+}
+
+// AddEntry 将饮食记录条目累加到当日营养摄入
+func (n *DailyNutrition) AddEntry(entry *NutritionEntry) {
+	n.CaloriesIntake += entry.CaloriesIntake
+	n.ProteinIntakeG += entry.ProteinIntakeG
+	n.CarbIntakeG += entry.CarbIntakeG
+	n.FatIntakeG += entry.FatIntakeG
+	n.MicronutrientIntake = n.MicronutrientIntake.Add(entry.Micronutrients)
+	n.UpdateCompletionRate()
+}
+
+// RemoveEntry 从当日营养摄入中扣除饮食记录条目，结果不会小于0
+func (n *DailyNutrition) RemoveEntry(entry *NutritionEntry) {
+	n.CaloriesIntake = nonNegative(n.CaloriesIntake - entry.CaloriesIntake)
+	n.ProteinIntakeG = nonNegative(n.ProteinIntakeG - entry.ProteinIntakeG)
+	n.CarbIntakeG = nonNegative(n.CarbIntakeG - entry.CarbIntakeG)
+	n.FatIntakeG = nonNegative(n.FatIntakeG - entry.FatIntakeG)
+	n.MicronutrientIntake = n.MicronutrientIntake.Subtract(entry.Micronutrients)
+	n.UpdateCompletionRate()
+}
+
+// Micronutrients 微量营养素数值表，键为营养素标识，值为对应单位下的数值
+type Micronutrients map[string]float64
+
+// Add 累加另一组微量营养素数值
+func (m Micronutrients) Add(other Micronutrients) Micronutrients {
+	result := make(Micronutrients, len(m))
+	for key, value := range m {
+		result[key] = value
+	}
+	for key, value := range other {
+		result[key] += value
+	}
+	return result
+}
+
+// Subtract 扣除另一组微量营养素数值，结果不会小于0
+func (m Micronutrients) Subtract(other Micronutrients) Micronutrients {
+	result := make(Micronutrients, len(m))
+	for key, value := range m {
+		result[key] = nonNegative(value - other[key])
+	}
+	return result
+}
+
+// Scale 按比例缩放所有微量营养素数值
+func (m Micronutrients) Scale(factor float64) Micronutrients {
+	result := make(Micronutrients, len(m))
+	for key, value := range m {
+		result[key] = value * factor
+	}
+	return result
+}
+
+// Pick 仅保留指定标识的营养素，缺失的补0
+func (m Micronutrients) Pick(keys []string) Micronutrients {
+	result := make(Micronutrients, len(keys))
+	for _, key := range keys {
+		result[key] = m[key]
+	}
+	return result
+}
+
+// completionRate 计算完成率(%)，保留两位小数；目标值无效时返回0
+func completionRate(intake, target float64) float64 {
+	if target <= 0 {
+		return 0
+	}
+	return math.Round(intake/target*10000) / 100
+}
+
+// nonNegative 将负数截断为0
+func nonNegative(value float64) float64 {
+	if value < 0 {
+		return 0
+	}
+	return value
+}
+
+// roundTwo 保留两位小数
+func roundTwo(value float64) float64 {
+	return math.Round(value*100) / 100
+}
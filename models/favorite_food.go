@@ -0,0 +1,45 @@
+package models
+
+import (
+	"time"
+)
+
+// FavoriteFood 用户收藏的食物，可一键记录到饮食，同名同份量的收藏只保留一条
+type FavoriteFood struct {
+	ID       int64  `json:"id" gorm:"primaryKey"`
+	UserID   int64  `json:"user_id" gorm:"not null;uniqueIndex:idx_favorite_user_food,priority:1"`
+	FoodName string `json:"food_name" gorm:"size:100;not null;uniqueIndex:idx_favorite_user_food,priority:2"`
+	Quantity string `json:"quantity" gorm:"size:50;uniqueIndex:idx_favorite_user_food,priority:3"` // 份量描述
+	MealType string `json:"meal_type" gorm:"size:16"`                                              // 默认餐次，可为空
+
+	CaloriesIntake float64        `json:"calories_intake" gorm:"type:decimal(6,2);default:0"`
+	ProteinIntakeG float64        `json:"protein_intake_g" gorm:"type:decimal(6,2);default:0"`
+	CarbIntakeG    float64        `json:"carb_intake_g" gorm:"type:decimal(6,2);default:0"`
+	FatIntakeG     float64        `json:"fat_intake_g" gorm:"type:decimal(6,2);default:0"`
+	Micronutrients Micronutrients `json:"micronutrients" gorm:"serializer:json"`
+
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+func (FavoriteFood) TableName() string {
+	return "favorite_foods"
+}
+
+// FrequentFood 根据用户饮食记录和已采用的食物识别记录自动统计的常吃食物
+type FrequentFood struct {
+	FoodName string `json:"food_name"`
+	Quantity string `json:"quantity"`  // 最近一次记录的份量
+	MealType string `json:"meal_type"` // 最常记录的餐次
+
+	CaloriesIntake float64        `json:"calories_intake"`
+	ProteinIntakeG float64        `json:"protein_intake_g"`
+	CarbIntakeG    float64        `json:"carb_intake_g"`
+	FatIntakeG     float64        `json:"fat_intake_g"`
+	Micronutrients Micronutrients `json:"micronutrients"`
+
+	Count          int     `json:"count"`            // 统计周期内记录次数
+	LastLoggedDate string  `json:"last_logged_date"` // 最近一次记录日期
+	Score          float64 `json:"score"`            // 综合频率与新近程度的排序分
+	IsFavorite     bool    `json:"is_favorite"`      // 是否已收藏
+}
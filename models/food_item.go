@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+)
+
+// FoodItem 食物库条目，营养数值均按每100克计算
+type FoodItem struct {
+	ID       int64  `json:"id" gorm:"primaryKey"`
+	Name     string `json:"name" gorm:"size:100;not null;index"`
+	Category string `json:"category" gorm:"size:50"`
+
+	CaloriesPer100G float64        `json:"calories_per_100g" gorm:"type:decimal(7,2);default:0"`
+	ProteinPer100G  float64        `json:"protein_per_100g" gorm:"type:decimal(6,2);default:0"`
+	CarbPer100G     float64        `json:"carb_per_100g" gorm:"type:decimal(6,2);default:0"`
+	FatPer100G      float64        `json:"fat_per_100g" gorm:"type:decimal(6,2);default:0"`
+	Micronutrients  Micronutrients `json:"micronutrients" gorm:"serializer:json"` // 每100克微量营养素含量
+
+	CreatedBy int64 `json:"created_by" gorm:"index;default:0"` // 创建用户ID，0表示系统内置食物
+
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+func (FoodItem) TableName() string {
+	return "food_items"
+}
@@ -2,24 +2,51 @@ package models
 
 import (
 	"time"
+
+	"ome-app-back/models/constant"
 )
 
 // FoodRecognition 食物识别记录
 type FoodRecognition struct {
-	ID              int64     `json:"id" gorm:"primaryKey"`
-	UserID          int64     `json:"user_id" gorm:"index;not null"`                            // 用户ID
-	SessionID       string    `json:"session_id" gorm:"size:50;index:idx_session_recognition;"` // 相关会话ID
-	ImageURL        string    `json:"image_url" gorm:"size:255;not null"`                       // 图片URL
-	RecognizedFoods string    `json:"recognized_foods" gorm:"type:text"`                        // 识别出的食物列表(JSON格式)
-	CaloriesIntake  float64   `json:"calories_intake" gorm:"type:numeric(6,2);default:0"`       // 估算热量(千卡)
-	ProteinIntakeG  float64   `json:"protein_intake_g" gorm:"type:numeric(6,2);default:0"`      // 估算蛋白质(克)
-	CarbIntakeG     float64   `json:"carb_intake_g" gorm:"type:numeric(6,2);default:0"`         // 估算碳水(克)
-	FatIntakeG      float64   `json:"fat_intake_g" gorm:"type:numeric(6,2);default:0"`          // 估算脂肪(克)
-	AIResponse      string    `json:"ai_response" gorm:"type:text"`                             // AI返回的完整响应
-	IsAdopted       bool      `json:"is_adopted" gorm:"default:false"`                          // 用户是否采用此记录到营养摄入
-	RecordDate      time.Time `json:"record_date" gorm:"type:date;not null"`                    // 记录日期
-	CreatedAt       time.Time `json:"created_at" gorm:"autoCreateTime"`
-	UpdatedAt       time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+	ID                int64          `json:"id" gorm:"primaryKey"`
+	UserID            int64          `json:"user_id" gorm:"index;not null"`                            // 用户ID
+	SessionID         string         `json:"session_id" gorm:"size:50;index:idx_session_recognition;"` // 相关会话ID
+	Source            string         `json:"source" gorm:"size:20;default:'image'"`                    // 输入来源: image/text
+	InputText         string         `json:"input_text" gorm:"type:text"`                              // 文字识别时用户输入的描述
+	ImageURL          string         `json:"image_url" gorm:"size:255;not null"`                       // 预处理后的图片URL，多图时为第一张
+	OriginalImageURL  string         `json:"original_image_url" gorm:"size:255"`                       // 原图URL(已清除GPS信息)，多图时为第一张
+	ImageURLs         []string       `json:"image_urls" gorm:"serializer:json"`                        // 同一餐的全部预处理后图片URL
+	OriginalImageURLs []string       `json:"original_image_urls" gorm:"serializer:json"`               // 同一餐的全部原图URL
+	ImageHashes       []string       `json:"-" gorm:"serializer:json"`                                 // 各图片的感知哈希，用于识别结果缓存
+	CachedFromID      *int64         `json:"cached_from_id"`                                           // 复用识别结果的来源记录ID，为空表示经AI识别
+	CaloriesIntake    float64        `json:"calories_intake" gorm:"type:numeric(6,2);default:0"`       // 估算热量(千卡)
+	ProteinIntakeG    float64        `json:"protein_intake_g" gorm:"type:numeric(6,2);default:0"`      // 估算蛋白质(克)
+	CarbIntakeG       float64        `json:"carb_intake_g" gorm:"type:numeric(6,2);default:0"`         // 估算碳水(克)
+	FatIntakeG        float64        `json:"fat_intake_g" gorm:"type:numeric(6,2);default:0"`          // 估算脂肪(克)
+	Micronutrients    Micronutrients `json:"micronutrients" gorm:"serializer:json"`                    // 估算微量营养素
+	AIResponse        string         `json:"ai_response" gorm:"type:text"`                             // AI返回的完整响应
+	IsAdopted         bool           `json:"is_adopted" gorm:"default:false"`                          // 用户是否采用此记录到营养摄入
+	IsEdited          bool           `json:"is_edited" gorm:"default:false"`                           // 用户是否修改过识别结果
+	EditedAt          *time.Time     `json:"edited_at"`                                                // 最近一次修改时间
+	RecordDate        time.Time      `json:"record_date" gorm:"type:date;not null"`                    // 记录日期
+	Status            string         `json:"status" gorm:"size:20;default:'completed';index"`          // 识别状态: pending/processing/completed/failed
+	ErrorMessage      string         `json:"error_message" gorm:"size:500"`                            // 识别失败原因
+	Attempts          int            `json:"attempts" gorm:"default:0"`                                // 已尝试识别的次数
+	CompletedAt       *time.Time     `json:"completed_at"`                                             // 识别完成时间
+	CreatedAt         time.Time      `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt         time.Time      `json:"updated_at" gorm:"autoUpdateTime"`
+
+	// 识别出的食物，包括当前结果与首次修改时保存的AI原始结果
+	Items []FoodRecognitionItem `json:"-" gorm:"foreignKey:RecognitionID"`
+
+	// AI原始营养摘要，首次修改时保存，用于审计和识别准确度分析
+	OriginalNutrition *FoodRecognitionNutrition `json:"-" gorm:"serializer:json"`
+
+	// 用户对识别准确度的反馈，GroundTruth 为用户确认的正确结果，与 AIResponse 一起构成评测样本
+	FeedbackRating  int                     `json:"-" gorm:"default:0"` // 准确度评分1-5，0表示未评价
+	FeedbackComment string                  `json:"-" gorm:"size:500"`
+	GroundTruth     *RecognitionGroundTruth `json:"-" gorm:"serializer:json"`
+	FeedbackAt      *time.Time              `json:"-" gorm:"index"`
 }
 
 // TableName 表名
@@ -27,15 +54,160 @@ func (FoodRecognition) TableName() string {
 	return "food_recognitions"
 }
 
+// AllImageURLs 返回识别记录的全部图片URL，兼容只有单张图片的早期记录
+func (r *FoodRecognition) AllImageURLs() []string {
+	if len(r.ImageURLs) > 0 {
+		return r.ImageURLs
+	}
+	if r.ImageURL == "" {
+		return []string{}
+	}
+	return []string{r.ImageURL}
+}
+
+// AllOriginalImageURLs 返回识别记录的全部原图URL，兼容早期记录
+func (r *FoodRecognition) AllOriginalImageURLs() []string {
+	if len(r.OriginalImageURLs) > 0 {
+		return r.OriginalImageURLs
+	}
+	if r.OriginalImageURL == "" {
+		return []string{}
+	}
+	return []string{r.OriginalImageURL}
+}
+
+// CurrentFoods 返回当前的食物列表（用户修改后的结果，未修改时为AI识别结果），需预加载 Items
+func (r *FoodRecognition) CurrentFoods() []RecognizedFoodItem {
+	return r.foods(false)
+}
+
+// OriginalFoods 返回首次修改前的AI原始食物列表，未修改过时为空，需预加载 Items
+func (r *FoodRecognition) OriginalFoods() []RecognizedFoodItem {
+	return r.foods(true)
+}
+
+func (r *FoodRecognition) foods(original bool) []RecognizedFoodItem {
+	foods := make([]RecognizedFoodItem, 0, len(r.Items))
+	for _, item := range r.Items {
+		if item.IsOriginal == original {
+			foods = append(foods, item.ToRecognizedFood())
+		}
+	}
+	return foods
+}
+
+// FoodRecognitionItem 识别出的单个食物，营养数值为该食物份量对应的总量
+type FoodRecognitionItem struct {
+	ID            int64 `json:"id" gorm:"primaryKey"`
+	RecognitionID int64 `json:"recognition_id" gorm:"not null;index"`
+	IsOriginal    bool  `json:"is_original" gorm:"default:false"` // 是否为首次修改时保存的AI原始结果
+	Position      int   `json:"position" gorm:"default:0"`        // 在食物列表中的顺序，从0开始
+
+	Name           string         `json:"name" gorm:"size:100;not null"`
+	Quantity       string         `json:"quantity" gorm:"size:100"`
+	WeightG        float64        `json:"weight_g" gorm:"type:decimal(7,2);default:0"`
+	Calories       float64        `json:"calories" gorm:"type:decimal(7,2);default:0"`
+	ProteinG       float64        `json:"protein_g" gorm:"type:decimal(6,2);default:0"`
+	CarbG          float64        `json:"carb_g" gorm:"type:decimal(6,2);default:0"`
+	FatG           float64        `json:"fat_g" gorm:"type:decimal(6,2);default:0"`
+	Micronutrients Micronutrients `json:"micronutrients" gorm:"serializer:json"`
+	Allergens      []string       `json:"allergens" gorm:"serializer:json"`
+
+	Confidence float64 `json:"confidence" gorm:"type:decimal(4,3);default:0"` // AI识别置信度(0-1)
+	Confirmed  bool    `json:"confirmed" gorm:"default:false"`                // 用户是否已确认或修正
+	FoodItemID int64   `json:"food_item_id" gorm:"default:0"`                 // 匹配到的食物库条目ID，0表示未匹配
+
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+func (FoodRecognitionItem) TableName() string {
+	return "food_recognition_items"
+}
+
+// NewFoodRecognitionItems 将食物列表转换为待保存的识别食物行
+func NewFoodRecognitionItems(recognitionID int64, foods []RecognizedFoodItem, original bool) []FoodRecognitionItem {
+	items := make([]FoodRecognitionItem, 0, len(foods))
+	for i, food := range foods {
+		items = append(items, FoodRecognitionItem{
+			RecognitionID:  recognitionID,
+			IsOriginal:     original,
+			Position:       i,
+			Name:           food.Name,
+			Quantity:       food.Quantity,
+			WeightG:        food.WeightG,
+			Calories:       food.Calories,
+			ProteinG:       food.ProteinG,
+			CarbG:          food.CarbG,
+			FatG:           food.FatG,
+			Micronutrients: food.Micronutrients,
+			Allergens:      food.Allergens,
+			Confidence:     food.Confidence,
+			Confirmed:      food.Confirmed,
+			FoodItemID:     food.FoodItemID,
+		})
+	}
+	return items
+}
+
+// ToRecognizedFood 转换为前端展示的食物项，置信度低于阈值且未经用户确认时标记为低置信度
+func (i *FoodRecognitionItem) ToRecognizedFood() RecognizedFoodItem {
+	return RecognizedFoodItem{
+		Name:           i.Name,
+		Quantity:       i.Quantity,
+		Calories:       i.Calories,
+		WeightG:        i.WeightG,
+		ProteinG:       i.ProteinG,
+		CarbG:          i.CarbG,
+		FatG:           i.FatG,
+		Micronutrients: i.Micronutrients,
+		Allergens:      i.Allergens,
+		FoodItemID:     i.FoodItemID,
+		Confidence:     i.Confidence,
+		Confirmed:      i.Confirmed,
+		LowConfidence:  !i.Confirmed && i.Confidence < constant.RecognitionLowConfidence,
+	}
+}
+
 // FoodRecognitionResult 食物识别结果(用于前端显示)
 type FoodRecognitionResult struct {
-	ID               int64                    `json:"id"`                // 识别记录ID
-	ImageURL         string                   `json:"image_url"`         // 图片URL
-	RecognizedFoods  []RecognizedFoodItem     `json:"recognized_foods"`  // 识别出的食物列表
-	NutritionSummary FoodRecognitionNutrition `json:"nutrition_summary"` // 营养摘要
-	AIAnalysis       string                   `json:"ai_analysis"`       // AI分析结果
-	IsAdopted        bool                     `json:"is_adopted"`        // 是否已保存到营养摄入
-	RecordDate       string                   `json:"record_date"`       // 记录日期
+	ID                int64                    `json:"id"`                       // 识别记录ID
+	Source            string                   `json:"source"`                   // 输入来源: image/text
+	InputText         string                   `json:"input_text,omitempty"`     // 文字识别时用户输入的描述
+	ImageURL          string                   `json:"image_url"`                // 预处理后的图片URL
+	OriginalImageURL  string                   `json:"original_image_url"`       // 原图URL
+	ImageURLs         []string                 `json:"image_urls"`               // 同一餐的全部图片URL
+	OriginalImageURLs []string                 `json:"original_image_urls"`      // 同一餐的全部原图URL
+	RecognizedFoods   []RecognizedFoodItem     `json:"recognized_foods"`         // 识别出的食物列表
+	LowConfidence     int                      `json:"low_confidence_count"`     // 低置信度且未经确认的食物数量
+	NutritionSummary  FoodRecognitionNutrition `json:"nutrition_summary"`        // 营养摘要
+	AIAnalysis        string                   `json:"ai_analysis"`              // AI分析结果
+	IsAdopted         bool                     `json:"is_adopted"`               // 是否已保存到营养摄入
+	IsEdited          bool                     `json:"is_edited"`                // 是否修改过识别结果
+	RecordDate        string                   `json:"record_date"`              // 记录日期
+	Status            string                   `json:"status"`                   // 识别状态: pending/processing/completed/failed
+	ErrorMessage      string                   `json:"error_message,omitempty"`  // 识别失败原因
+	CacheHit          bool                     `json:"cache_hit"`                // 是否复用了相似图片的历史识别结果
+	CachedFromID      *int64                   `json:"cached_from_id,omitempty"` // 复用识别结果的来源记录ID
+
+	OriginalFoods     []RecognizedFoodItem      `json:"original_foods,omitempty"`     // 修改前的AI原始食物列表
+	OriginalNutrition *FoodRecognitionNutrition `json:"original_nutrition,omitempty"` // 修改前的AI原始营养摘要
+
+	AllergenWarnings []AllergenWarning `json:"allergen_warnings,omitempty"` // 过敏原/不耐受警告
+
+	Feedback *RecognitionFeedback `json:"feedback,omitempty"` // 用户提交的准确度反馈
+}
+
+// RecognitionFeedback 用户对识别结果的准确度反馈
+type RecognitionFeedback struct {
+	Rating      int       `json:"rating"`            // 准确度评分1-5
+	Comment     string    `json:"comment,omitempty"` // 文字说明
+	SubmittedAt time.Time `json:"submitted_at"`      // 最近一次提交时间
+}
+
+// RecognitionGroundTruth 一餐的识别结果（食物列表与营养总量），用于保存用户修正后的正确结果
+type RecognitionGroundTruth struct {
+	Foods     []RecognizedFoodItem     `json:"foods"`
+	Nutrition FoodRecognitionNutrition `json:"nutrition"`
 }
 
 // RecognizedFoodItem 识别出的食物项
@@ -43,6 +215,21 @@ type RecognizedFoodItem struct {
 	Name     string  `json:"name"`     // 食物名称
 	Quantity string  `json:"quantity"` // 数量描述
 	Calories float64 `json:"calories"` // 估算热量
+
+	// 该食物的重量与宏量营养素，早期识别记录中可能为空
+	WeightG        float64        `json:"weight_g"`
+	ProteinG       float64        `json:"protein_g"`
+	CarbG          float64        `json:"carb_g"`
+	FatG           float64        `json:"fat_g"`
+	Micronutrients Micronutrients `json:"micronutrients,omitempty"`
+
+	Allergens []string `json:"allergens,omitempty"` // AI标记的可能过敏原代码
+
+	FoodItemID int64 `json:"food_item_id,omitempty"` // 匹配到的食物库条目ID，营养数值按食物库计算
+
+	Confidence    float64 `json:"confidence"`     // AI识别置信度(0-1)，用户新增的食物为1
+	Confirmed     bool    `json:"confirmed"`      // 用户是否已确认或修正
+	LowConfidence bool    `json:"low_confidence"` // 置信度低且未经确认，需提示用户核对
 }
 
 // FoodRecognitionNutrition 食物识别的营养摘要
@@ -51,4 +238,6 @@ type FoodRecognitionNutrition struct {
 	ProteinIntakeG float64 `json:"protein_intake_g"` // 蛋白质(克)
 	CarbIntakeG    float64 `json:"carb_intake_g"`    // 碳水(克)
 	FatIntakeG     float64 `json:"fat_intake_g"`     // 脂肪(克)
+
+	Micronutrients Micronutrients `json:"micronutrients,omitempty"` // 微量营养素
 }
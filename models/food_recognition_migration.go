@@ -0,0 +1,102 @@
+package models
+
+import (
+	"encoding/json"
+	"log"
+
+	"gorm.io/gorm"
+)
+
+// legacyRecognitionBatchSize 每批迁移的识别记录数
+const legacyRecognitionBatchSize = 200
+
+// legacyRecognitionFoods 早期以JSON文本保存在识别记录中的食物列表
+type legacyRecognitionFoods struct {
+	ID              int64
+	RecognizedFoods *string
+	OriginalFoods   *string
+}
+
+// migrateLegacyRecognitionFoods 将早期保存在 food_recognitions.recognized_foods / original_foods 中的JSON食物列表
+// 迁移为 food_recognition_items 行，迁移后清空原字段，因此每条记录只会迁移一次
+func migrateLegacyRecognitionFoods(db *gorm.DB) error {
+	migrator := db.Migrator()
+	if !migrator.HasColumn("food_recognitions", "recognized_foods") {
+		return nil
+	}
+	columns := []string{"id", "recognized_foods"}
+	hasOriginal := migrator.HasColumn("food_recognitions", "original_foods")
+	if hasOriginal {
+		columns = append(columns, "original_foods")
+	}
+
+	var lastID int64
+	migrated := 0
+	for {
+		var rows []legacyRecognitionFoods
+		err := db.Table("food_recognitions").Select(columns).
+			Where("id > ? AND recognized_foods IS NOT NULL", lastID).
+			Order("id ASC").Limit(legacyRecognitionBatchSize).
+			Find(&rows).Error
+		if err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			break
+		}
+
+		for _, row := range rows {
+			lastID = row.ID
+			current, err := parseLegacyFoods(row.RecognizedFoods)
+			if err != nil {
+				log.Printf("识别记录(ID:%d)的食物列表格式错误，跳过迁移: %v", row.ID, err)
+				continue
+			}
+			original, err := parseLegacyFoods(row.OriginalFoods)
+			if err != nil {
+				log.Printf("识别记录(ID:%d)的原始食物列表格式错误，跳过迁移: %v", row.ID, err)
+				continue
+			}
+
+			items := append(NewFoodRecognitionItems(row.ID, current, false), NewFoodRecognitionItems(row.ID, original, true)...)
+			// 早期记录没有置信度，视为无需再提示用户核对
+			for i := range items {
+				items[i].Confidence = 1
+			}
+			updates := map[string]interface{}{"recognized_foods": nil}
+			if hasOriginal {
+				updates["original_foods"] = nil
+			}
+
+			err = db.Transaction(func(tx *gorm.DB) error {
+				if len(items) > 0 {
+					if err := tx.Create(&items).Error; err != nil {
+						return err
+					}
+				}
+				return tx.Table("food_recognitions").Where("id = ?", row.ID).Updates(updates).Error
+			})
+			if err != nil {
+				return err
+			}
+			migrated++
+		}
+	}
+
+	if migrated > 0 {
+		log.Printf("已将%d条识别记录的食物列表迁移至 food_recognition_items", migrated)
+	}
+	return nil
+}
+
+// parseLegacyFoods 解析JSON格式的食物列表，为空时返回空列表
+func parseLegacyFoods(raw *string) ([]RecognizedFoodItem, error) {
+	var foods []RecognizedFoodItem
+	if raw == nil || *raw == "" {
+		return foods, nil
+	}
+	if err := json.Unmarshal([]byte(*raw), &foods); err != nil {
+		return nil, err
+	}
+	return foods, nil
+}
@@ -19,6 +19,8 @@ type HealthAnalysis struct {
 
 	RecommendedCalories float64 `json:"recommended_calories" gorm:"type:numeric(6,2)"` // 推荐每日摄入热量
 
+	MicronutrientTargets Micronutrients `json:"micronutrient_targets" gorm:"serializer:json"` // 每日微量营养素目标
+
 	AnalysisContent string `json:"analysis_content" gorm:"type:text"` // 分析结果文本内容
 
 	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
@@ -15,16 +15,30 @@ func Init(db *gorm.DB) error {
 		&UserHeight{},
 		&HealthAnalysis{},
 		&DailyNutrition{},
+		&NutritionEntry{},
 		&ChatSession{},
 		&ChatMessage{},
 		&FoodRecognition{},
+		&FoodRecognitionItem{},
 		&UserExercise{},
 		&MoodRecord{},
+		&WaterRecord{},
+		&FoodItem{},
+		&Recipe{},
+		&RecipeIngredient{},
+		&MealPlan{},
+		&MealPlanItem{},
+		&UserSetting{},
+		&FavoriteFood{},
 	)
 	if err != nil {
 		log.Printf("数据库自动迁移失败: %v", err)
 		return err
 	}
+	if err := migrateLegacyRecognitionFoods(db); err != nil {
+		log.Printf("迁移识别记录食物列表失败: %v", err)
+		return err
+	}
 	log.Println("数据库自动迁移成功")
 	return nil
 }
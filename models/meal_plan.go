@@ -0,0 +1,57 @@
+package models
+
+import (
+	"time"
+)
+
+// MealPlan 用户一周饮食计划（由AI生成）
+type MealPlan struct {
+	ID        int64     `json:"id" gorm:"primaryKey"`
+	UserID    int64     `json:"user_id" gorm:"not null;index"`
+	StartDate time.Time `json:"start_date" gorm:"type:date;not null"`
+	EndDate   time.Time `json:"end_date" gorm:"type:date;not null"`
+
+	// 生成计划时使用的每日目标与偏好
+	TargetCalories   float64  `json:"target_calories" gorm:"type:decimal(6,2);default:0"`
+	TargetProteinG   float64  `json:"target_protein_g" gorm:"type:decimal(6,2);default:0"`
+	TargetCarbG      float64  `json:"target_carb_g" gorm:"type:decimal(6,2);default:0"`
+	TargetFatG       float64  `json:"target_fat_g" gorm:"type:decimal(6,2);default:0"`
+	DietType         string   `json:"diet_type" gorm:"type:varchar(16)"`
+	FoodIntolerances []string `json:"food_intolerances" gorm:"serializer:json"`
+
+	Meals []MealPlanItem `json:"meals" gorm:"foreignKey:PlanID"`
+
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+func (MealPlan) TableName() string {
+	return "meal_plans"
+}
+
+// MealPlanItem 饮食计划中的一餐
+type MealPlanItem struct {
+	ID          int64     `json:"id" gorm:"primaryKey"`
+	PlanID      int64     `json:"plan_id" gorm:"not null;index"`
+	UserID      int64     `json:"user_id" gorm:"not null;index"`
+	Date        time.Time `json:"date" gorm:"type:date;not null"`
+	MealType    string    `json:"meal_type" gorm:"size:16;not null"` // breakfast / lunch / dinner / snack
+	Name        string    `json:"name" gorm:"size:100;not null"`
+	Description string    `json:"description" gorm:"type:text"`
+	Ingredients []string  `json:"ingredients" gorm:"serializer:json"`
+
+	Calories float64 `json:"calories" gorm:"type:decimal(6,2);default:0"`
+	ProteinG float64 `json:"protein_g" gorm:"type:decimal(6,2);default:0"`
+	CarbG    float64 `json:"carb_g" gorm:"type:decimal(6,2);default:0"`
+	FatG     float64 `json:"fat_g" gorm:"type:decimal(6,2);default:0"`
+
+	LoggedEntryID int64      `json:"logged_entry_id" gorm:"default:0"` // 已记录到饮食记录的条目ID，0表示未记录
+	LoggedAt      *time.Time `json:"logged_at"`
+
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+func (MealPlanItem) TableName() string {
+	return "meal_plan_items"
+}
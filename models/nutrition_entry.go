@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+)
+
+// NutritionEntry 用户饮食记录条目（每日营养摄入的明细）
+type NutritionEntry struct {
+	ID       int64     `json:"id" gorm:"primaryKey"`
+	UserID   int64     `json:"user_id" gorm:"not null;index:idx_entry_user_date,priority:1"`
+	Date     time.Time `json:"date" gorm:"type:date;not null;index:idx_entry_user_date,priority:2"`
+	MealType string    `json:"meal_type" gorm:"size:16;not null"` // breakfast / lunch / dinner / snack
+	FoodName string    `json:"food_name" gorm:"size:100;not null"`
+	Quantity string    `json:"quantity" gorm:"size:50"` // 份量描述
+
+	CaloriesIntake float64        `json:"calories_intake" gorm:"type:decimal(6,2);default:0"`
+	ProteinIntakeG float64        `json:"protein_intake_g" gorm:"type:decimal(6,2);default:0"`
+	CarbIntakeG    float64        `json:"carb_intake_g" gorm:"type:decimal(6,2);default:0"`
+	FatIntakeG     float64        `json:"fat_intake_g" gorm:"type:decimal(6,2);default:0"`
+	Micronutrients Micronutrients `json:"micronutrients" gorm:"serializer:json"`
+
+	Source   string `json:"source" gorm:"size:16;not null;default:manual"` // 记录来源：manual / recognition ...
+	SourceID int64  `json:"source_id,omitempty" gorm:"default:0"`          // 来源记录ID
+
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+func (NutritionEntry) TableName() string {
+	return "nutrition_entries"
+}
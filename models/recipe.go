@@ -0,0 +1,75 @@
+package models
+
+import (
+	"time"
+)
+
+// Recipe 用户食谱（由多种食材组成的复合食物）
+type Recipe struct {
+	ID          int64   `json:"id" gorm:"primaryKey"`
+	UserID      int64   `json:"user_id" gorm:"not null;index"`
+	Name        string  `json:"name" gorm:"size:100;not null"`
+	Description string  `json:"description" gorm:"type:text"`
+	Servings    float64 `json:"servings" gorm:"type:decimal(5,2);not null"` // 食谱总份数
+	IsPublic    bool    `json:"is_public" gorm:"default:false;index"`       // 是否公开分享
+
+	// 整道食谱的总重量与每份营养（根据食材计算）
+	TotalWeightG             float64        `json:"total_weight_g" gorm:"type:decimal(8,2);default:0"`
+	CaloriesPerServing       float64        `json:"calories_per_serving" gorm:"type:decimal(7,2);default:0"`
+	ProteinPerServingG       float64        `json:"protein_per_serving_g" gorm:"type:decimal(6,2);default:0"`
+	CarbPerServingG          float64        `json:"carb_per_serving_g" gorm:"type:decimal(6,2);default:0"`
+	FatPerServingG           float64        `json:"fat_per_serving_g" gorm:"type:decimal(6,2);default:0"`
+	MicronutrientsPerServing Micronutrients `json:"micronutrients_per_serving" gorm:"serializer:json"`
+
+	Ingredients []RecipeIngredient `json:"ingredients" gorm:"foreignKey:RecipeID"`
+
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+func (Recipe) TableName() string {
+	return "recipes"
+}
+
+// RecipeIngredient 食谱食材，营养数值为该食材用量对应的总量
+type RecipeIngredient struct {
+	ID         int64   `json:"id" gorm:"primaryKey"`
+	RecipeID   int64   `json:"recipe_id" gorm:"not null;index"`
+	FoodItemID int64   `json:"food_item_id" gorm:"default:0"` // 食物库条目ID，0表示自定义食材
+	Name       string  `json:"name" gorm:"size:100;not null"`
+	Grams      float64 `json:"grams" gorm:"type:decimal(7,2);not null"`
+
+	Calories       float64        `json:"calories" gorm:"type:decimal(7,2);default:0"`
+	ProteinG       float64        `json:"protein_g" gorm:"type:decimal(6,2);default:0"`
+	CarbG          float64        `json:"carb_g" gorm:"type:decimal(6,2);default:0"`
+	FatG           float64        `json:"fat_g" gorm:"type:decimal(6,2);default:0"`
+	Micronutrients Micronutrients `json:"micronutrients" gorm:"serializer:json"`
+}
+
+func (RecipeIngredient) TableName() string {
+	return "recipe_ingredients"
+}
+
+// CalculateNutrition 根据食材汇总计算总重量及每份营养
+func (r *Recipe) CalculateNutrition() {
+	var calories, protein, carb, fat, weight float64
+	micronutrients := Micronutrients{}
+	for _, ingredient := range r.Ingredients {
+		weight += ingredient.Grams
+		calories += ingredient.Calories
+		protein += ingredient.ProteinG
+		carb += ingredient.CarbG
+		fat += ingredient.FatG
+		micronutrients = micronutrients.Add(ingredient.Micronutrients)
+	}
+
+	r.TotalWeightG = weight
+	if r.Servings <= 0 {
+		return
+	}
+	r.CaloriesPerServing = calories / r.Servings
+	r.ProteinPerServingG = protein / r.Servings
+	r.CarbPerServingG = carb / r.Servings
+	r.FatPerServingG = fat / r.Servings
+	r.MicronutrientsPerServing = micronutrients.Scale(1 / r.Servings)
+}
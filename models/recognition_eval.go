@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// RecognitionEvalSample 识别准确度评测数据集中的一条样本，导出为JSON Lines的一行
+type RecognitionEvalSample struct {
+	RecognitionID int64  `json:"recognition_id"` // 识别记录ID
+	Source        string `json:"source"`         // 输入来源: image/text
+
+	ImagePaths         []string `json:"image_paths,omitempty"`          // 预处理后的图片路径（识别时发送给AI的图片）
+	OriginalImagePaths []string `json:"original_image_paths,omitempty"` // 原图路径
+	InputText          string   `json:"input_text,omitempty"`           // 文字记录的描述
+
+	AIResponse string                 `json:"ai_response"` // AI返回的原始内容
+	AIOutput   RecognitionGroundTruth `json:"ai_output"`   // AI识别结果（用户修改前）
+	Corrected  RecognitionGroundTruth `json:"corrected"`   // 用户修正后的正确结果
+
+	Rating     int       `json:"rating"`            // 用户准确度评分1-5
+	Comment    string    `json:"comment,omitempty"` // 用户反馈说明
+	FeedbackAt time.Time `json:"feedback_at"`       // 反馈时间
+}
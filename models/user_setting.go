@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+)
+
+// UserSetting 用户个性化设置，每个用户一条
+type UserSetting struct {
+	ID     int64 `json:"id" gorm:"primaryKey"`
+	UserID int64 `json:"user_id" gorm:"uniqueIndex;not null"`
+
+	ExerciseCalorieMode  string  `json:"exercise_calorie_mode" gorm:"size:16;not null;default:none"`           // 运动消耗回补方式: none / partial / full
+	ExerciseCalorieRatio float64 `json:"exercise_calorie_ratio" gorm:"type:numeric(3,2);not null;default:0.5"` // partial 模式下计入预算的比例
+	Timezone             string  `json:"timezone" gorm:"size:64"`                                              // IANA时区，如 Asia/Shanghai，为空时使用默认时区
+
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+func (UserSetting) TableName() string {
+	return "user_settings"
+}
@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+)
+
+// WaterRecord 饮水记录表
+type WaterRecord struct {
+	ID         int64     `json:"id" gorm:"primaryKey"`
+	UserID     int64     `json:"user_id" gorm:"index;not null"`
+	AmountML   int       `json:"amount_ml" gorm:"not null"` // 饮水量(毫升)
+	RecordTime time.Time `json:"record_time" gorm:"not null"`
+	CreatedAt  time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+func (WaterRecord) TableName() string {
+	return "water_records"
+}
@@ -36,10 +36,10 @@ func (d *ChatDAO) CreateSession(userID int64, title string) (*models.ChatSession
 	return &session, nil
 }
 
-// GetSession 获取会话信息
-func (d *ChatDAO) GetSession(sessionID string) (*models.ChatSession, error) {
+// GetUserSession 获取用户的会话信息，会话不存在或不属于该用户时返回 gorm.ErrRecordNotFound
+func (d *ChatDAO) GetUserSession(sessionID string, userID int64) (*models.ChatSession, error) {
 	var session models.ChatSession
-	if err := d.db.Where("id = ?", sessionID).First(&session).Error; err != nil {
+	if err := d.db.Where("id = ? AND user_id = ?", sessionID, userID).First(&session).Error; err != nil {
 		return nil, err
 	}
 	return &session, nil
@@ -57,23 +57,27 @@ func (d *ChatDAO) ListUserSessions(userID int64) ([]models.ChatSession, error) {
 	return sessions, nil
 }
 
-// UpdateSessionTitle 更新会话标题
-func (d *ChatDAO) UpdateSessionTitle(sessionID string, title string) error {
+// UpdateSessionTitle 更新用户会话的标题
+func (d *ChatDAO) UpdateSessionTitle(sessionID string, userID int64, title string) error {
 	return d.db.Model(&models.ChatSession{}).
-		Where("id = ?", sessionID).
+		Where("id = ? AND user_id = ?", sessionID, userID).
 		Update("title", title).Error
 }
 
-// DeleteSession 删除会话及其消息
-func (d *ChatDAO) DeleteSession(sessionID string) error {
+// DeleteSession 删除用户的会话及其消息，会话不存在或不属于该用户时返回 gorm.ErrRecordNotFound
+func (d *ChatDAO) DeleteSession(sessionID string, userID int64) error {
 	// 在事务中删除会话和相关消息
 	return d.db.Transaction(func(tx *gorm.DB) error {
-		// 先删除会话相关的所有消息
-		if err := tx.Where("session_id = ?", sessionID).Delete(&models.ChatMessage{}).Error; err != nil {
-			return err
+		// 先删除会话，确认会话属于该用户
+		result := tx.Where("id = ? AND user_id = ?", sessionID, userID).Delete(&models.ChatSession{})
+		if result.Error != nil {
+			return result.Error
 		}
-		// 删除会话
-		if err := tx.Where("id = ?", sessionID).Delete(&models.ChatSession{}).Error; err != nil {
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		// 删除会话相关的所有消息
+		if err := tx.Where("session_id = ?", sessionID).Delete(&models.ChatMessage{}).Error; err != nil {
 			return err
 		}
 		return nil
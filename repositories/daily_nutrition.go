@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"ome-app-back/models"
 )
@@ -28,6 +29,8 @@ type CreateNutritionParams struct {
 	TargetProteinG float64
 	TargetCarbG    float64
 	TargetFatG     float64
+
+	TargetMicronutrients models.Micronutrients
 }
 
 // GetOrCreate 获取或创建指定日期的营养记录
@@ -52,6 +55,8 @@ func (d *DailyNutritionDAO) GetOrCreate(userID int64, date time.Time, targetPara
 			TargetProteinG: targetParams.TargetProteinG,
 			TargetCarbG:    targetParams.TargetCarbG,
 			TargetFatG:     targetParams.TargetFatG,
+
+			MicronutrientTargets: targetParams.TargetMicronutrients,
 		}).
 		FirstOrCreate(&nutritionRecord).Error
 
@@ -87,13 +92,60 @@ func (d *DailyNutritionDAO) GetByDate(userID int64, date time.Time) (*models.Dai
 // Update 更新当日营养摄入数据
 func (d *DailyNutritionDAO) Update(nutrition *models.DailyNutrition) error {
 	// 计算热量完成率
-	if nutrition.TargetCalories > 0 {
-		nutrition.CaloriesCompletionRate = (nutrition.CaloriesIntake / nutrition.TargetCalories) * 100
-	}
+	nutrition.UpdateCompletionRate()
 
 	return d.db.Save(nutrition).Error
 }
 
+// updateLockedNutrition 在事务中加行锁重新读取当日营养数据，在最新数据上执行 apply 后保存
+// 调用方持有的 nutrition 可能已过期，直接保存会覆盖并发事务的累加结果；读取后 nutrition 更新为保存后的数据
+func updateLockedNutrition(tx *gorm.DB, nutrition *models.DailyNutrition, apply func(*models.DailyNutrition)) error {
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ?", nutrition.ID).
+		First(nutrition).Error
+	if err != nil {
+		return err
+	}
+	apply(nutrition)
+	return tx.Save(nutrition).Error
+}
+
+// UpdateTargetsFromDate 将新的目标值同步到指定日期及之后已存在的营养记录，并重新计算完成率
+func (d *DailyNutritionDAO) UpdateTargetsFromDate(userID int64, fromDate time.Time, targetParams *CreateNutritionParams) (int, error) {
+	dateStr := fromDate.Format("2006-01-02")
+
+	// 在事务中加行锁读取，避免用过期的摄入量覆盖并发的饮食记录累加
+	var records []models.DailyNutrition
+	err := d.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id = ? AND DATE(date) >= ?", userID, dateStr).
+			Find(&records).Error
+		if err != nil {
+			return err
+		}
+		for i := range records {
+			records[i].ApplyTargets(
+				targetParams.TargetCalories,
+				targetParams.TargetProteinG,
+				targetParams.TargetCarbG,
+				targetParams.TargetFatG,
+				targetParams.TargetMicronutrients,
+			)
+			if err := tx.Save(&records[i]).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("[营养DAO] 用户(ID:%d)同步%s起的营养目标失败: %v", userID, dateStr, err)
+		return 0, err
+	}
+
+	log.Printf("[营养DAO] 用户(ID:%d)已同步%s起的%d条营养记录目标", userID, dateStr, len(records))
+	return len(records), nil
+}
+
 // GetHistory 获取用户营养历史记录
 func (d *DailyNutritionDAO) GetHistory(userID int64, startDate, endDate time.Time) ([]models.DailyNutrition, error) {
 	var records []models.DailyNutrition
@@ -104,15 +156,14 @@ func (d *DailyNutritionDAO) GetHistory(userID int64, startDate, endDate time.Tim
 	return records, err
 }
 
-// GetWeekSummary 获取用户一周营养摄入统计
-func (d *DailyNutritionDAO) GetWeekSummary(userID int64) (map[string]float64, error) {
+// GetWeekSummary 获取用户截至 today 的一周营养摄入统计，micronutrientKeys 为需要统计的微量营养素标识
+func (d *DailyNutritionDAO) GetWeekSummary(userID int64, today time.Time, micronutrientKeys []string) (map[string]float64, error) {
 	// 获取最近7天数据
-	now := time.Now()
-	startDate := now.AddDate(0, 0, -6) // 6天前
+	startDate := today.AddDate(0, 0, -6) // 6天前
 
 	var records []models.DailyNutrition
 	err := d.db.Where("user_id = ? AND date BETWEEN ? AND ?",
-		userID, startDate, now).Find(&records).Error
+		userID, startDate, today).Find(&records).Error
 
 	if err != nil {
 		return nil, err
@@ -126,6 +177,9 @@ func (d *DailyNutritionDAO) GetWeekSummary(userID int64) (map[string]float64, er
 		"avg_fat":             0,
 		"avg_completion_rate": 0,
 	}
+	for _, key := range micronutrientKeys {
+		summary["avg_"+key] = 0
+	}
 
 	if len(records) > 0 {
 		for _, r := range records {
@@ -134,6 +188,9 @@ func (d *DailyNutritionDAO) GetWeekSummary(userID int64) (map[string]float64, er
 			summary["avg_carb"] += r.CarbIntakeG
 			summary["avg_fat"] += r.FatIntakeG
 			summary["avg_completion_rate"] += r.CaloriesCompletionRate
+			for _, key := range micronutrientKeys {
+				summary["avg_"+key] += r.MicronutrientIntake[key]
+			}
 		}
 
 		days := float64(len(records))
@@ -142,6 +199,9 @@ func (d *DailyNutritionDAO) GetWeekSummary(userID int64) (map[string]float64, er
 		summary["avg_carb"] /= days
 		summary["avg_fat"] /= days
 		summary["avg_completion_rate"] /= days
+		for _, key := range micronutrientKeys {
+			summary["avg_"+key] /= days
+		}
 	}
 
 	return summary, nil
@@ -0,0 +1,71 @@
+package repositories
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+
+	"ome-app-back/models"
+)
+
+// FavoriteFoodDAO 处理收藏食物数据访问
+type FavoriteFoodDAO struct {
+	db *gorm.DB
+}
+
+// NewFavoriteFoodDAO 创建收藏食物DAO实例
+func NewFavoriteFoodDAO(db *gorm.DB) *FavoriteFoodDAO {
+	return &FavoriteFoodDAO{db: db}
+}
+
+// Save 保存收藏食物，同名同份量的收藏已存在时更新原记录
+func (d *FavoriteFoodDAO) Save(favorite *models.FavoriteFood) error {
+	var existing models.FavoriteFood
+	err := d.db.Where("user_id = ? AND food_name = ? AND quantity = ?",
+		favorite.UserID, favorite.FoodName, favorite.Quantity).
+		First(&existing).Error
+	if err == nil {
+		favorite.ID = existing.ID
+		favorite.CreatedAt = existing.CreatedAt
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	return d.db.Save(favorite).Error
+}
+
+// GetByID 获取用户的收藏食物
+func (d *FavoriteFoodDAO) GetByID(userID, favoriteID int64) (*models.FavoriteFood, error) {
+	var favorite models.FavoriteFood
+	err := d.db.Where("id = ? AND user_id = ?", favoriteID, userID).First(&favorite).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("收藏不存在")
+		}
+		return nil, err
+	}
+	return &favorite, nil
+}
+
+// ListByUser 获取用户的收藏食物列表，最近收藏的在前
+func (d *FavoriteFoodDAO) ListByUser(userID int64) ([]models.FavoriteFood, error) {
+	var favorites []models.FavoriteFood
+	err := d.db.Where("user_id = ?", userID).
+		Order("updated_at DESC").
+		Find(&favorites).Error
+	if err != nil {
+		return nil, err
+	}
+	return favorites, nil
+}
+
+// Delete 删除用户的收藏食物
+func (d *FavoriteFoodDAO) Delete(userID, favoriteID int64) error {
+	result := d.db.Where("id = ? AND user_id = ?", favoriteID, userID).Delete(&models.FavoriteFood{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("记录不存在或无权限删除")
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package repositories
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+
+	"ome-app-back/models"
+)
+
+// FoodItemDAO 处理食物库数据访问
+type FoodItemDAO struct {
+	db *gorm.DB
+}
+
+// NewFoodItemDAO 创建食物库DAO实例
+func NewFoodItemDAO(db *gorm.DB) *FoodItemDAO {
+	return &FoodItemDAO{db: db}
+}
+
+// Create 创建食物库条目
+func (d *FoodItemDAO) Create(item *models.FoodItem) error {
+	return d.db.Create(item).Error
+}
+
+// GetByID 获取用户可见的食物库条目（系统内置或用户自己创建的）
+func (d *FoodItemDAO) GetByID(userID, itemID int64) (*models.FoodItem, error) {
+	var item models.FoodItem
+	err := d.db.Where("id = ? AND (created_by = 0 OR created_by = ?)", itemID, userID).First(&item).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("食物不存在")
+		}
+		return nil, err
+	}
+	return &item, nil
+}
+
+// Search 按名称搜索用户可见的食物库条目
+func (d *FoodItemDAO) Search(userID int64, keyword, category string, limit int) ([]models.FoodItem, error) {
+	var items []models.FoodItem
+	query := d.db.Where("created_by = 0 OR created_by = ?", userID)
+	if keyword != "" {
+		query = query.Where("name LIKE ?", "%"+keyword+"%")
+	}
+	if category != "" {
+		query = query.Where("category = ?", category)
+	}
+
+	err := query.Order("created_by DESC, id ASC").Limit(limit).Find(&items).Error
+	if err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// Delete 删除用户自建的食物库条目
+func (d *FoodItemDAO) Delete(userID, itemID int64) error {
+	result := d.db.Where("id = ? AND created_by = ?", itemID, userID).Delete(&models.FoodItem{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("记录不存在或无权限删除")
+	}
+	return nil
+}
@@ -1,12 +1,12 @@
 package repositories
 
 import (
-	"encoding/json"
 	"time"
 
 	"gorm.io/gorm"
 
 	"ome-app-back/models"
+	"ome-app-back/models/constant"
 )
 
 // FoodRecognitionDAO 处理食物识别相关的数据访问
@@ -19,25 +19,37 @@ func NewFoodRecognitionDAO(db *gorm.DB) *FoodRecognitionDAO {
 	return &FoodRecognitionDAO{db: db}
 }
 
-// CreateRecognition 创建食物识别记录
-func (d *FoodRecognitionDAO) CreateRecognition(userID int64, sessionID, imageURL string, foods []models.RecognizedFoodItem, nutrition models.FoodRecognitionNutrition, aiResponse string) (*models.FoodRecognition, error) {
-	// 将食物列表转为JSON字符串存储
-	foodsJSON, err := json.Marshal(foods)
-	if err != nil {
+// CreateRecognition 创建待识别的食物识别记录，一餐可包含多张图片，recordDate 为用户时区下的记录日期
+func (d *FoodRecognitionDAO) CreateRecognition(userID int64, sessionID string, imageURLs, originalImageURLs, imageHashes []string, recordDate time.Time) (*models.FoodRecognition, error) {
+	recognition := models.FoodRecognition{
+		UserID:            userID,
+		SessionID:         sessionID,
+		Source:            constant.RecognitionSourceImage,
+		ImageURL:          imageURLs[0],
+		OriginalImageURL:  originalImageURLs[0],
+		ImageURLs:         imageURLs,
+		OriginalImageURLs: originalImageURLs,
+		ImageHashes:       imageHashes,
+		RecordDate:        recordDate,
+		Status:            constant.RecognitionStatusPending,
+	}
+
+	if err := d.db.Create(&recognition).Error; err != nil {
 		return nil, err
 	}
 
+	return &recognition, nil
+}
+
+// CreateTextRecognition 根据用户输入的文字描述创建待识别的记录
+func (d *FoodRecognitionDAO) CreateTextRecognition(userID int64, sessionID, text string, recordDate time.Time) (*models.FoodRecognition, error) {
 	recognition := models.FoodRecognition{
-		UserID:          userID,
-		SessionID:       sessionID,
-		ImageURL:        imageURL,
-		RecognizedFoods: string(foodsJSON),
-		CaloriesIntake:  nutrition.CaloriesIntake,
-		ProteinIntakeG:  nutrition.ProteinIntakeG,
-		CarbIntakeG:     nutrition.CarbIntakeG,
-		FatIntakeG:      nutrition.FatIntakeG,
-		AIResponse:      aiResponse,
-		RecordDate:      time.Now().Truncate(24 * time.Hour), // 当天日期，去除时分秒
+		UserID:     userID,
+		SessionID:  sessionID,
+		Source:     constant.RecognitionSourceText,
+		InputText:  text,
+		RecordDate: recordDate,
+		Status:     constant.RecognitionStatusPending,
 	}
 
 	if err := d.db.Create(&recognition).Error; err != nil {
@@ -47,10 +59,147 @@ func (d *FoodRecognitionDAO) CreateRecognition(userID int64, sessionID, imageURL
 	return &recognition, nil
 }
 
-// GetRecognitionByID 通过ID获取食物识别记录
+// StartRecognition 将待识别的记录标记为识别中并累加尝试次数，记录已被其他任务处理时返回 false
+func (d *FoodRecognitionDAO) StartRecognition(id int64) (bool, error) {
+	result := d.db.Model(&models.FoodRecognition{}).
+		Where("id = ? AND status = ?", id, constant.RecognitionStatusPending).
+		Updates(map[string]interface{}{
+			"status":        constant.RecognitionStatusProcessing,
+			"attempts":      gorm.Expr("attempts + 1"),
+			"error_message": "",
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// CompleteRecognition 在事务中保存识别出的食物及营养摘要，并将识别中的记录标记为识别完成
+// attempt 为本次识别开始时的尝试次数；记录已超时被标记为失败或已重试时丢弃结果并返回 false
+func (d *FoodRecognitionDAO) CompleteRecognition(id int64, attempt int, foods []models.RecognizedFoodItem, nutrition models.FoodRecognitionNutrition, aiResponse string) (bool, error) {
+	conds := map[string]interface{}{"id": id, "status": constant.RecognitionStatusProcessing, "attempts": attempt}
+	return d.completeRecognition(conds, id, foods, models.FoodRecognition{
+		CaloriesIntake: nutrition.CaloriesIntake,
+		ProteinIntakeG: nutrition.ProteinIntakeG,
+		CarbIntakeG:    nutrition.CarbIntakeG,
+		FatIntakeG:     nutrition.FatIntakeG,
+		Micronutrients: nutrition.Micronutrients,
+		AIResponse:     aiResponse,
+	})
+}
+
+// CompleteFromCache 复用来源记录的AI识别结果完成待识别的记录，不调用AI；source 需预加载 Items
+// 记录已不是待识别状态时返回 false
+func (d *FoodRecognitionDAO) CompleteFromCache(id int64, source *models.FoodRecognition) (bool, error) {
+	conds := map[string]interface{}{"id": id, "status": constant.RecognitionStatusPending}
+	return d.completeRecognition(conds, id, source.CurrentFoods(), models.FoodRecognition{
+		CaloriesIntake: source.CaloriesIntake,
+		ProteinIntakeG: source.ProteinIntakeG,
+		CarbIntakeG:    source.CarbIntakeG,
+		FatIntakeG:     source.FatIntakeG,
+		Micronutrients: source.Micronutrients,
+		AIResponse:     source.AIResponse,
+		CachedFromID:   &source.ID,
+	})
+}
+
+// completeRecognition 在事务中将满足 conds 的识别记录标记为识别完成，保存营养摘要、AI分析和缓存来源并替换食物行
+// 没有满足条件的记录时不做任何修改并返回 false
+func (d *FoodRecognitionDAO) completeRecognition(conds map[string]interface{}, id int64, foods []models.RecognizedFoodItem, values models.FoodRecognition) (bool, error) {
+	completed := false
+	err := d.db.Transaction(func(tx *gorm.DB) error {
+		// 使用结构体更新，使微量营养素按JSON序列化保存
+		now := time.Now()
+		values.Status = constant.RecognitionStatusCompleted
+		values.CompletedAt = &now
+		result := tx.Model(&models.FoodRecognition{}).
+			Where(conds).
+			Select("calories_intake", "protein_intake_g", "carb_intake_g", "fat_intake_g",
+				"micronutrients", "ai_response", "cached_from_id", "status", "completed_at").
+			Updates(&values)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		if err := tx.Where("recognition_id = ?", id).Delete(&models.FoodRecognitionItem{}).Error; err != nil {
+			return err
+		}
+		items := models.NewFoodRecognitionItems(id, foods, false)
+		if len(items) > 0 {
+			if err := tx.Create(&items).Error; err != nil {
+				return err
+			}
+		}
+		completed = true
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	return completed, nil
+}
+
+// FailRecognition 将识别记录标记为失败并记录原因
+func (d *FoodRecognitionDAO) FailRecognition(id int64, message string) error {
+	return d.db.Model(&models.FoodRecognition{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status":        constant.RecognitionStatusFailed,
+			"error_message": truncateRunes(message, 500),
+		}).Error
+}
+
+// RetryRecognition 将识别失败的记录重新置为待识别，记录不是失败状态时返回 false
+func (d *FoodRecognitionDAO) RetryRecognition(id int64) (bool, error) {
+	result := d.db.Model(&models.FoodRecognition{}).
+		Where("id = ? AND status = ?", id, constant.RecognitionStatusFailed).
+		Updates(map[string]interface{}{
+			"status":        constant.RecognitionStatusPending,
+			"error_message": "",
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// FailInterruptedRecognitions 将 before 之前最后更新、仍未完成的识别任务标记为失败，返回受影响的记录数
+func (d *FoodRecognitionDAO) FailInterruptedRecognitions(before time.Time, message string) (int64, error) {
+	result := d.db.Model(&models.FoodRecognition{}).
+		Where("status IN ? AND updated_at < ?", []string{constant.RecognitionStatusPending, constant.RecognitionStatusProcessing}, before).
+		Updates(map[string]interface{}{
+			"status":        constant.RecognitionStatusFailed,
+			"error_message": message,
+		})
+	return result.RowsAffected, result.Error
+}
+
+// truncateRunes 按字符截断字符串，避免超出字段长度
+func truncateRunes(s string, maxLen int) string {
+	runes := []rune(s)
+	if len(runes) <= maxLen {
+		return s
+	}
+	return string(runes[:maxLen])
+}
+
+// withItems 预加载识别记录的食物行，按列表顺序排列
+func (d *FoodRecognitionDAO) withItems() *gorm.DB {
+	return d.db.Preload("Items", func(db *gorm.DB) *gorm.DB {
+		return db.Order("position ASC, id ASC")
+	})
+}
+
+// GetRecognitionByID 通过ID获取食物识别记录，包含识别出的食物
 func (d *FoodRecognitionDAO) GetRecognitionByID(id int64) (*models.FoodRecognition, error) {
 	var recognition models.FoodRecognition
-	if err := d.db.First(&recognition, id).Error; err != nil {
+	if err := d.withItems().First(&recognition, id).Error; err != nil {
+		return nil, err
+	}
+	return &recognition, nil
+}
+
+// GetUserRecognition 获取用户的食物识别记录，记录不存在或不属于该用户时返回 gorm.ErrRecordNotFound
+func (d *FoodRecognitionDAO) GetUserRecognition(id, userID int64) (*models.FoodRecognition, error) {
+	var recognition models.FoodRecognition
+	if err := d.withItems().Where("id = ? AND user_id = ?", id, userID).First(&recognition).Error; err != nil {
 		return nil, err
 	}
 	return &recognition, nil
@@ -62,7 +211,7 @@ func (d *FoodRecognitionDAO) GetUserRecognitionsByDate(userID int64, date time.T
 	nextDay := dateOnly.AddDate(0, 0, 1)
 
 	var recognitions []models.FoodRecognition
-	err := d.db.Where("user_id = ? AND record_date >= ? AND record_date < ?",
+	err := d.withItems().Where("user_id = ? AND record_date >= ? AND record_date < ?",
 		userID, dateOnly, nextDay).
 		Order("created_at DESC").
 		Find(&recognitions).Error
@@ -72,16 +221,15 @@ func (d *FoodRecognitionDAO) GetUserRecognitionsByDate(userID int64, date time.T
 	return recognitions, nil
 }
 
-// GetUserTodayRecognitions 获取用户今天的所有食物识别记录
-func (d *FoodRecognitionDAO) GetUserTodayRecognitions(userID int64) ([]models.FoodRecognition, error) {
-	today := time.Now().Truncate(24 * time.Hour)
+// GetUserTodayRecognitions 获取用户今天的所有食物识别记录，today 为用户时区下的今天日期
+func (d *FoodRecognitionDAO) GetUserTodayRecognitions(userID int64, today time.Time) ([]models.FoodRecognition, error) {
 	return d.GetUserRecognitionsByDate(userID, today)
 }
 
 // GetUserRecentRecognitions 获取用户最近的食物识别记录
 func (d *FoodRecognitionDAO) GetUserRecentRecognitions(userID int64, limit int) ([]models.FoodRecognition, error) {
 	var recognitions []models.FoodRecognition
-	err := d.db.Where("user_id = ?", userID).
+	err := d.withItems().Where("user_id = ?", userID).
 		Order("created_at DESC").
 		Limit(limit).
 		Find(&recognitions).Error
@@ -91,41 +239,212 @@ func (d *FoodRecognitionDAO) GetUserRecentRecognitions(userID int64, limit int)
 	return recognitions, nil
 }
 
-// UpdateAdoptionStatus 更新识别记录的采用状态
-func (d *FoodRecognitionDAO) UpdateAdoptionStatus(id int64, isAdopted bool) error {
-	return d.db.Model(&models.FoodRecognition{}).
-		Where("id = ?", id).
-		Update("is_adopted", isAdopted).Error
+// GetSessionRecognitions 获取用户在指定聊天会话中已完成的最近识别记录
+func (d *FoodRecognitionDAO) GetSessionRecognitions(userID int64, sessionID string, limit int) ([]models.FoodRecognition, error) {
+	var recognitions []models.FoodRecognition
+	err := d.withItems().Where("user_id = ? AND session_id = ? AND status = ?", userID, sessionID, constant.RecognitionStatusCompleted).
+		Order("id DESC").
+		Limit(limit).
+		Find(&recognitions).Error
+	if err != nil {
+		return nil, err
+	}
+	return recognitions, nil
+}
+
+// GetCacheCandidates 获取用户指定时间之后经AI识别完成、未被用户修改、且记录了图片哈希的识别记录，用于复用识别结果
+// 修改过的记录保存的是用户的修正而非AI识别结果，不作为缓存来源
+func (d *FoodRecognitionDAO) GetCacheCandidates(userID int64, since time.Time, limit int) ([]models.FoodRecognition, error) {
+	var recognitions []models.FoodRecognition
+	err := d.withItems().Where("user_id = ? AND status = ? AND is_edited = ? AND cached_from_id IS NULL AND image_hashes IS NOT NULL AND created_at >= ?",
+		userID, constant.RecognitionStatusCompleted, false, since).
+		Order("created_at DESC").
+		Limit(limit).
+		Find(&recognitions).Error
+	if err != nil {
+		return nil, err
+	}
+	return recognitions, nil
+}
+
+// ReplaceItems 在事务中用修改后的食物列表替换当前结果并保存识别记录
+// preserveOriginal 为 true 时（首次修改）将当前的AI识别结果保留为原始结果，否则直接删除
+func (d *FoodRecognitionDAO) ReplaceItems(recognition *models.FoodRecognition, foods []models.RecognizedFoodItem, preserveOriginal bool) error {
+	return d.db.Transaction(func(tx *gorm.DB) error {
+		current := tx.Model(&models.FoodRecognitionItem{}).Where("recognition_id = ? AND is_original = ?", recognition.ID, false)
+		var err error
+		if preserveOriginal {
+			err = current.Update("is_original", true).Error
+		} else {
+			err = current.Delete(&models.FoodRecognitionItem{}).Error
+		}
+		if err != nil {
+			return err
+		}
+
+		items := models.NewFoodRecognitionItems(recognition.ID, foods, false)
+		if len(items) > 0 {
+			if err := tx.Create(&items).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Omit("Items").Save(recognition).Error
+	})
+}
+
+// ConfirmItems 将当前结果中指定位置的食物标记为用户已确认，返回实际更新的数量
+func (d *FoodRecognitionDAO) ConfirmItems(recognitionID int64, positions []int) (int64, error) {
+	result := d.db.Model(&models.FoodRecognitionItem{}).
+		Where("recognition_id = ? AND is_original = ? AND position IN ?", recognitionID, false, positions).
+		Update("confirmed", true)
+	return result.RowsAffected, result.Error
+}
+
+// SaveFeedback 保存用户对识别结果的准确度反馈及修正后的正确结果，重复提交时覆盖
+func (d *FoodRecognitionDAO) SaveFeedback(id int64, rating int, comment string, truth *models.RecognitionGroundTruth) error {
+	now := time.Now()
+	return d.db.Model(&models.FoodRecognition{ID: id}).
+		Select("feedback_rating", "feedback_comment", "ground_truth", "feedback_at").
+		Updates(&models.FoodRecognition{
+			FeedbackRating:  rating,
+			FeedbackComment: comment,
+			GroundTruth:     truth,
+			FeedbackAt:      &now,
+		}).Error
+}
+
+// GetFeedbackRecognitions 按ID游标分批获取指定时间范围内提交过反馈的识别记录，用于导出评测数据集
+func (d *FoodRecognitionDAO) GetFeedbackRecognitions(afterID int64, startTime, endTime time.Time, limit int) ([]models.FoodRecognition, error) {
+	var recognitions []models.FoodRecognition
+	err := d.withItems().Where("id > ? AND ground_truth IS NOT NULL AND feedback_at >= ? AND feedback_at < ?", afterID, startTime, endTime).
+		Order("id ASC").
+		Limit(limit).
+		Find(&recognitions).Error
+	if err != nil {
+		return nil, err
+	}
+	return recognitions, nil
+}
+
+// Adopt 在事务中将识别记录标记为已采用（记录日期改为采用日期），创建对应的饮食记录条目并累加当日营养数据
+// 识别记录已被采用时不做任何修改，返回 false
+func (d *FoodRecognitionDAO) Adopt(recognitionID int64, entry *models.NutritionEntry, nutrition *models.DailyNutrition) (bool, error) {
+	adopted := false
+	err := d.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.FoodRecognition{}).
+			Where("id = ? AND user_id = ? AND is_adopted = ?", recognitionID, entry.UserID, false).
+			Updates(map[string]interface{}{"is_adopted": true, "record_date": entry.Date})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		if err := tx.Create(entry).Error; err != nil {
+			return err
+		}
+		err := updateLockedNutrition(tx, nutrition, func(n *models.DailyNutrition) {
+			n.AddEntry(entry)
+		})
+		if err != nil {
+			return err
+		}
+		adopted = true
+		return nil
+	})
+	return adopted, err
+}
+
+// Unadopt 在事务中取消采用识别记录，删除对应的饮食记录条目并从当日营养数据中扣除
+// entry.ID 为0表示早期采用时没有生成条目，只扣除营养数据；识别记录未被采用时不做任何修改，返回 false
+func (d *FoodRecognitionDAO) Unadopt(recognitionID int64, entry *models.NutritionEntry, nutrition *models.DailyNutrition) (bool, error) {
+	unadopted := false
+	err := d.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.FoodRecognition{}).
+			Where("id = ? AND user_id = ? AND is_adopted = ?", recognitionID, entry.UserID, true).
+			Update("is_adopted", false)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		if entry.ID > 0 {
+			if err := tx.Where("id = ? AND user_id = ?", entry.ID, entry.UserID).Delete(&models.NutritionEntry{}).Error; err != nil {
+				return err
+			}
+		}
+		err := updateLockedNutrition(tx, nutrition, func(n *models.DailyNutrition) {
+			n.RemoveEntry(entry)
+		})
+		if err != nil {
+			return err
+		}
+		unadopted = true
+		return nil
+	})
+	return unadopted, err
 }
 
 // ConvertToResult 将数据库记录转换为前端可用的结果对象
 func (d *FoodRecognitionDAO) ConvertToResult(recognition *models.FoodRecognition) (*models.FoodRecognitionResult, error) {
-	var foods []models.RecognizedFoodItem
-	if err := json.Unmarshal([]byte(recognition.RecognizedFoods), &foods); err != nil {
-		return nil, err
+	foods := recognition.CurrentFoods()
+	lowConfidence := 0
+	for _, food := range foods {
+		if food.LowConfidence {
+			lowConfidence++
+		}
 	}
 
 	result := &models.FoodRecognitionResult{
-		ID:              recognition.ID,
-		ImageURL:        recognition.ImageURL,
-		RecognizedFoods: foods,
+		ID:                recognition.ID,
+		Source:            recognition.Source,
+		InputText:         recognition.InputText,
+		ImageURL:          recognition.ImageURL,
+		OriginalImageURL:  recognition.OriginalImageURL,
+		ImageURLs:         recognition.AllImageURLs(),
+		OriginalImageURLs: recognition.AllOriginalImageURLs(),
+		RecognizedFoods:   foods,
+		LowConfidence:     lowConfidence,
 		NutritionSummary: models.FoodRecognitionNutrition{
 			CaloriesIntake: recognition.CaloriesIntake,
 			ProteinIntakeG: recognition.ProteinIntakeG,
 			CarbIntakeG:    recognition.CarbIntakeG,
 			FatIntakeG:     recognition.FatIntakeG,
+			Micronutrients: recognition.Micronutrients,
 		},
 		AIAnalysis: recognition.AIResponse,
 		IsAdopted:  recognition.IsAdopted,
+		IsEdited:   recognition.IsEdited,
 		RecordDate: recognition.RecordDate.Format("2006-01-02"),
+
+		Status:       recognition.Status,
+		ErrorMessage: recognition.ErrorMessage,
+		CacheHit:     recognition.CachedFromID != nil,
+		CachedFromID: recognition.CachedFromID,
+
+		OriginalNutrition: recognition.OriginalNutrition,
+	}
+	if recognition.FeedbackAt != nil {
+		result.Feedback = &models.RecognitionFeedback{
+			Rating:      recognition.FeedbackRating,
+			Comment:     recognition.FeedbackComment,
+			SubmittedAt: *recognition.FeedbackAt,
+		}
+	}
+
+	if original := recognition.OriginalFoods(); len(original) > 0 {
+		result.OriginalFoods = original
 	}
 
 	return result, nil
 }
 
 // SummarizeTodayNutrition 汇总用户今日所有食物识别的营养摄入
-func (d *FoodRecognitionDAO) SummarizeTodayNutrition(userID int64) (models.FoodRecognitionNutrition, error) {
-	records, err := d.GetUserTodayRecognitions(userID)
+func (d *FoodRecognitionDAO) SummarizeTodayNutrition(userID int64, today time.Time) (models.FoodRecognitionNutrition, error) {
+	records, err := d.GetUserTodayRecognitions(userID, today)
 	if err != nil {
 		return models.FoodRecognitionNutrition{}, err
 	}
@@ -136,6 +455,7 @@ func (d *FoodRecognitionDAO) SummarizeTodayNutrition(userID int64) (models.FoodR
 		summary.ProteinIntakeG += record.ProteinIntakeG
 		summary.CarbIntakeG += record.CarbIntakeG
 		summary.FatIntakeG += record.FatIntakeG
+		summary.Micronutrients = summary.Micronutrients.Add(record.Micronutrients)
 	}
 
 	return summary, nil
@@ -160,7 +480,9 @@ func (d *FoodRecognitionDAO) GetUserAdoptedRecognitions(userID int64, page, page
 	}
 
 	// 查询数据
-	err = query.Order("record_date DESC, created_at DESC").
+	err = query.Preload("Items", func(db *gorm.DB) *gorm.DB {
+		return db.Order("position ASC, id ASC")
+	}).Order("record_date DESC, created_at DESC").
 		Offset(offset).
 		Limit(pageSize).
 		Find(&recognitions).Error
@@ -177,7 +499,7 @@ func (d *FoodRecognitionDAO) GetUserAdoptedRecognitionsByDateRange(userID int64,
 	var recognitions []models.FoodRecognition
 
 	// 查询数据
-	err := d.db.Where("user_id = ? AND is_adopted = ? AND record_date >= ? AND record_date <= ?",
+	err := d.withItems().Where("user_id = ? AND is_adopted = ? AND record_date >= ? AND record_date <= ?",
 		userID, true, startDate, endDate).
 		Order("record_date DESC, created_at DESC").
 		Find(&recognitions).Error
@@ -1,6 +1,8 @@
 package repositories
 
 import (
+	"time"
+
 	"gorm.io/gorm"
 
 	"ome-app-back/models"
@@ -33,6 +35,32 @@ func (d *HealthAnalysisDAO) GetLatestByUserID(userID int64) (*models.HealthAnaly
 	return &analysis, nil
 }
 
+// GetActiveByDate 获取指定日期当天生效的健康分析（即当天结束前最新生成的一条）
+func (d *HealthAnalysisDAO) GetActiveByDate(userID int64, date time.Time) (*models.HealthAnalysis, error) {
+	nextDay := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location()).AddDate(0, 0, 1)
+
+	var analysis models.HealthAnalysis
+	err := d.db.Where("user_id = ? AND created_at < ?", userID, nextDay).
+		Order("created_at DESC").
+		First(&analysis).Error
+	if err != nil {
+		return nil, err
+	}
+	return &analysis, nil
+}
+
+// GetEarliestByUserID 获取用户最早的健康分析
+func (d *HealthAnalysisDAO) GetEarliestByUserID(userID int64) (*models.HealthAnalysis, error) {
+	var analysis models.HealthAnalysis
+	err := d.db.Where("user_id = ?", userID).
+		Order("created_at ASC").
+		First(&analysis).Error
+	if err != nil {
+		return nil, err
+	}
+	return &analysis, nil
+}
+
 // GetHistory 获取用户健康分析历史记录
 func (d *HealthAnalysisDAO) GetHistory(userID int64, limit int) ([]models.HealthAnalysis, error) {
 	var analyses []models.HealthAnalysis
@@ -12,10 +12,17 @@ type Repositories struct {
 	UserGoalDAO        *UserGoalDAO
 	HealthAnalysisDAO  *HealthAnalysisDAO
 	DailyNutritionDAO  *DailyNutritionDAO
+	NutritionEntryDAO  *NutritionEntryDAO
 	ChatDAO            *ChatDAO
 	FoodRecognitionDAO *FoodRecognitionDAO
 	UserExerciseDAO    *UserExerciseDAO
 	MoodRecordDAO      *MoodRecordDAO
+	WaterRecordDAO     *WaterRecordDAO
+	FoodItemDAO        *FoodItemDAO
+	RecipeDAO          *RecipeDAO
+	MealPlanDAO        *MealPlanDAO
+	UserSettingDAO     *UserSettingDAO
+	FavoriteFoodDAO    *FavoriteFoodDAO
 }
 
 // Init 初始化所有数据访问对象
@@ -27,9 +34,16 @@ func Init(db *gorm.DB) *Repositories {
 		UserGoalDAO:        NewUserGoalDAO(db),
 		HealthAnalysisDAO:  NewHealthAnalysisDAO(db),
 		DailyNutritionDAO:  NewDailyNutritionDAO(db),
+		NutritionEntryDAO:  NewNutritionEntryDAO(db),
 		ChatDAO:            NewChatDAO(db),
 		FoodRecognitionDAO: NewFoodRecognitionDAO(db),
 		UserExerciseDAO:    NewUserExerciseDAO(db),
 		MoodRecordDAO:      NewMoodRecordDAO(db),
+		WaterRecordDAO:     NewWaterRecordDAO(db),
+		FoodItemDAO:        NewFoodItemDAO(db),
+		RecipeDAO:          NewRecipeDAO(db),
+		MealPlanDAO:        NewMealPlanDAO(db),
+		UserSettingDAO:     NewUserSettingDAO(db),
+		FavoriteFoodDAO:    NewFavoriteFoodDAO(db),
 	}
 }
@@ -0,0 +1,99 @@
+package repositories
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+
+	"ome-app-back/models"
+)
+
+// MealPlanDAO 处理饮食计划数据访问
+type MealPlanDAO struct {
+	db *gorm.DB
+}
+
+// NewMealPlanDAO 创建饮食计划DAO实例
+func NewMealPlanDAO(db *gorm.DB) *MealPlanDAO {
+	return &MealPlanDAO{db: db}
+}
+
+// Create 在事务中创建饮食计划及其餐次
+func (d *MealPlanDAO) Create(plan *models.MealPlan) error {
+	return d.db.Transaction(func(tx *gorm.DB) error {
+		return tx.Create(plan).Error
+	})
+}
+
+// GetByID 获取用户的饮食计划，包含全部餐次
+func (d *MealPlanDAO) GetByID(userID, planID int64) (*models.MealPlan, error) {
+	var plan models.MealPlan
+	err := d.db.Preload("Meals", func(db *gorm.DB) *gorm.DB {
+		return db.Order("date ASC, id ASC")
+	}).Where("id = ? AND user_id = ?", planID, userID).First(&plan).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("饮食计划不存在")
+		}
+		return nil, err
+	}
+	return &plan, nil
+}
+
+// GetLatest 获取用户最新的饮食计划，包含全部餐次
+func (d *MealPlanDAO) GetLatest(userID int64) (*models.MealPlan, error) {
+	var plan models.MealPlan
+	err := d.db.Preload("Meals", func(db *gorm.DB) *gorm.DB {
+		return db.Order("date ASC, id ASC")
+	}).Where("user_id = ?", userID).Order("created_at DESC").First(&plan).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("尚未生成饮食计划")
+		}
+		return nil, err
+	}
+	return &plan, nil
+}
+
+// List 获取用户的饮食计划列表（不含餐次）
+func (d *MealPlanDAO) List(userID int64, limit int) ([]models.MealPlan, error) {
+	var plans []models.MealPlan
+	err := d.db.Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Limit(limit).
+		Find(&plans).Error
+	if err != nil {
+		return nil, err
+	}
+	return plans, nil
+}
+
+// GetItem 获取用户饮食计划中的一餐
+func (d *MealPlanDAO) GetItem(userID, itemID int64) (*models.MealPlanItem, error) {
+	var item models.MealPlanItem
+	err := d.db.Where("id = ? AND user_id = ?", itemID, userID).First(&item).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("计划餐次不存在")
+		}
+		return nil, err
+	}
+	return &item, nil
+}
+
+// GetItemsByDate 获取饮食计划中某一天的所有餐次
+func (d *MealPlanDAO) GetItemsByDate(planID int64, date string) ([]models.MealPlanItem, error) {
+	var items []models.MealPlanItem
+	err := d.db.Where("plan_id = ? AND DATE(date) = ?", planID, date).
+		Order("id ASC").
+		Find(&items).Error
+	if err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// UpdateItem 更新饮食计划中的一餐
+func (d *MealPlanDAO) UpdateItem(item *models.MealPlanItem) error {
+	return d.db.Save(item).Error
+}
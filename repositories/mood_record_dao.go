@@ -54,9 +54,9 @@ func (d *MoodRecordDAO) GetHistory(userID int64, startDate, endDate time.Time, l
 	return moods, nil
 }
 
-// GetTodayMoods 获取今日情绪记录
-func (d *MoodRecordDAO) GetTodayMoods(userID int64) ([]models.MoodRecord, error) {
-	today := time.Now()
+// GetTodayMoods 获取今日情绪记录，按 now 所在时区划分当天
+func (d *MoodRecordDAO) GetTodayMoods(userID int64, now time.Time) ([]models.MoodRecord, error) {
+	today := now
 	startOfDay := time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, today.Location())
 	endOfDay := startOfDay.Add(24 * time.Hour)
 
@@ -0,0 +1,121 @@
+package repositories
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+
+	"ome-app-back/models"
+	"ome-app-back/models/constant"
+)
+
+// NutritionEntryDAO 处理饮食记录条目数据访问
+type NutritionEntryDAO struct {
+	db *gorm.DB
+}
+
+// NewNutritionEntryDAO 创建饮食记录条目DAO实例
+func NewNutritionEntryDAO(db *gorm.DB) *NutritionEntryDAO {
+	return &NutritionEntryDAO{db: db}
+}
+
+// GetByID 根据ID获取用户的饮食记录条目，不存在或不属于该用户时返回 gorm.ErrRecordNotFound
+func (d *NutritionEntryDAO) GetByID(userID, entryID int64) (*models.NutritionEntry, error) {
+	var entry models.NutritionEntry
+	err := d.db.Where("id = ? AND user_id = ?", entryID, userID).First(&entry).Error
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// GetBySource 根据来源获取饮食记录条目，不存在时返回 nil
+func (d *NutritionEntryDAO) GetBySource(userID int64, source string, sourceID int64) (*models.NutritionEntry, error) {
+	var entry models.NutritionEntry
+	err := d.db.Where("user_id = ? AND source = ? AND source_id = ?", userID, source, sourceID).
+		Order("id DESC").
+		First(&entry).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// ListByDate 获取用户某天的饮食记录条目
+func (d *NutritionEntryDAO) ListByDate(userID int64, date time.Time) ([]models.NutritionEntry, error) {
+	var entries []models.NutritionEntry
+	err := d.db.Where("user_id = ? AND DATE(date) = ?", userID, date.Format("2006-01-02")).
+		Order("created_at ASC").
+		Find(&entries).Error
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// ListByDateRange 获取用户日期范围内的饮食记录条目，按日期倒序
+func (d *NutritionEntryDAO) ListByDateRange(userID int64, startDate, endDate time.Time) ([]models.NutritionEntry, error) {
+	var entries []models.NutritionEntry
+	err := d.db.Where("user_id = ? AND date BETWEEN ? AND ?", userID, startDate, endDate).
+		Order("date DESC, created_at DESC").
+		Find(&entries).Error
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// AddEntry 在事务中创建饮食记录条目并保存更新后的当日营养数据
+func (d *NutritionEntryDAO) AddEntry(entry *models.NutritionEntry, nutrition *models.DailyNutrition) error {
+	return d.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(entry).Error; err != nil {
+			return err
+		}
+		return updateLockedNutrition(tx, nutrition, func(n *models.DailyNutrition) {
+			n.AddEntry(entry)
+		})
+	})
+}
+
+// AddEntries 在事务中批量创建饮食记录条目并保存更新后的当日营养数据
+func (d *NutritionEntryDAO) AddEntries(entries []models.NutritionEntry, nutrition *models.DailyNutrition) error {
+	return d.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&entries).Error; err != nil {
+			return err
+		}
+		return updateLockedNutrition(tx, nutrition, func(n *models.DailyNutrition) {
+			for i := range entries {
+				n.AddEntry(&entries[i])
+			}
+		})
+	})
+}
+
+// RemoveEntry 在事务中删除饮食记录条目并保存更新后的当日营养数据
+// 删除的是采用食物识别产生的条目时，同时将识别记录恢复为未采用
+func (d *NutritionEntryDAO) RemoveEntry(entry *models.NutritionEntry, nutrition *models.DailyNutrition) error {
+	return d.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("id = ? AND user_id = ?", entry.ID, entry.UserID).Delete(&models.NutritionEntry{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		if entry.Source == constant.EntrySourceRecognition && entry.SourceID > 0 {
+			err := tx.Model(&models.FoodRecognition{}).
+				Where("id = ? AND user_id = ?", entry.SourceID, entry.UserID).
+				Update("is_adopted", false).Error
+			if err != nil {
+				return err
+			}
+		}
+		return updateLockedNutrition(tx, nutrition, func(n *models.DailyNutrition) {
+			n.RemoveEntry(entry)
+		})
+	})
+}
@@ -0,0 +1,116 @@
+package repositories
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+
+	"ome-app-back/models"
+)
+
+// RecipeDAO 处理食谱数据访问
+type RecipeDAO struct {
+	db *gorm.DB
+}
+
+// NewRecipeDAO 创建食谱DAO实例
+func NewRecipeDAO(db *gorm.DB) *RecipeDAO {
+	return &RecipeDAO{db: db}
+}
+
+// Create 在事务中创建食谱及其食材
+func (d *RecipeDAO) Create(recipe *models.Recipe) error {
+	return d.db.Transaction(func(tx *gorm.DB) error {
+		return tx.Create(recipe).Error
+	})
+}
+
+// Update 在事务中更新食谱，并用新的食材列表替换原有食材
+func (d *RecipeDAO) Update(recipe *models.Recipe) error {
+	return d.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("recipe_id = ?", recipe.ID).Delete(&models.RecipeIngredient{}).Error; err != nil {
+			return err
+		}
+		for i := range recipe.Ingredients {
+			recipe.Ingredients[i].ID = 0
+			recipe.Ingredients[i].RecipeID = recipe.ID
+		}
+		if len(recipe.Ingredients) > 0 {
+			if err := tx.Create(&recipe.Ingredients).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Omit("Ingredients").Save(recipe).Error
+	})
+}
+
+// GetByID 获取用户可见的食谱（自己的或公开的），包含食材
+func (d *RecipeDAO) GetByID(userID, recipeID int64) (*models.Recipe, error) {
+	var recipe models.Recipe
+	err := d.db.Preload("Ingredients").
+		Where("id = ? AND (user_id = ? OR is_public = ?)", recipeID, userID, true).
+		First(&recipe).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("食谱不存在")
+		}
+		return nil, err
+	}
+	return &recipe, nil
+}
+
+// GetOwnedByID 获取用户自己的食谱，包含食材
+func (d *RecipeDAO) GetOwnedByID(userID, recipeID int64) (*models.Recipe, error) {
+	var recipe models.Recipe
+	err := d.db.Preload("Ingredients").
+		Where("id = ? AND user_id = ?", recipeID, userID).
+		First(&recipe).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("食谱不存在或无权限操作")
+		}
+		return nil, err
+	}
+	return &recipe, nil
+}
+
+// ListByUser 获取用户自己的食谱列表
+func (d *RecipeDAO) ListByUser(userID int64) ([]models.Recipe, error) {
+	var recipes []models.Recipe
+	err := d.db.Preload("Ingredients").
+		Where("user_id = ?", userID).
+		Order("updated_at DESC").
+		Find(&recipes).Error
+	if err != nil {
+		return nil, err
+	}
+	return recipes, nil
+}
+
+// ListPublic 搜索公开分享的食谱
+func (d *RecipeDAO) ListPublic(keyword string, limit int) ([]models.Recipe, error) {
+	var recipes []models.Recipe
+	query := d.db.Preload("Ingredients").Where("is_public = ?", true)
+	if keyword != "" {
+		query = query.Where("name LIKE ?", "%"+keyword+"%")
+	}
+	err := query.Order("updated_at DESC").Limit(limit).Find(&recipes).Error
+	if err != nil {
+		return nil, err
+	}
+	return recipes, nil
+}
+
+// Delete 在事务中删除用户的食谱及其食材
+func (d *RecipeDAO) Delete(userID, recipeID int64) error {
+	return d.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("id = ? AND user_id = ?", recipeID, userID).Delete(&models.Recipe{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("记录不存在或无权限删除")
+		}
+		return tx.Where("recipe_id = ?", recipeID).Delete(&models.RecipeIngredient{}).Error
+	})
+}
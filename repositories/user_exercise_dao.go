@@ -54,9 +54,9 @@ func (d *UserExerciseDAO) GetHistory(userID int64, startDate, endDate time.Time,
 	return exercises, nil
 }
 
-// GetTodayExercises 获取今日运动记录
-func (d *UserExerciseDAO) GetTodayExercises(userID int64) ([]models.UserExercise, error) {
-	today := time.Now()
+// GetTodayExercises 获取今日运动记录，按 now 所在时区划分当天
+func (d *UserExerciseDAO) GetTodayExercises(userID int64, now time.Time) ([]models.UserExercise, error) {
+	today := now
 	startOfDay := time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, today.Location())
 	endOfDay := startOfDay.Add(24 * time.Hour)
 
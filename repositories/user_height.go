@@ -82,13 +82,20 @@ func (d *UserHeightDAO) Update(height *models.UserHeight) error {
 	return d.db.Save(height).Error
 }
 
-// Delete 删除身高记录
-func (d *UserHeightDAO) Delete(id int64) error {
-	return d.db.Delete(&models.UserHeight{}, id).Error
+// Delete 删除用户的身高记录，记录不存在或不属于该用户时返回 gorm.ErrRecordNotFound
+func (d *UserHeightDAO) Delete(userID, id int64) error {
+	result := d.db.Where("id = ? AND user_id = ?", id, userID).Delete(&models.UserHeight{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
 }
 
-// GetHeightStatistics 获取身高统计数据
-func (d *UserHeightDAO) GetHeightStatistics(userID int64, days int) (map[string]interface{}, error) {
+// GetHeightStatistics 获取 startDate 以来的身高统计数据
+func (d *UserHeightDAO) GetHeightStatistics(userID int64, startDate time.Time) (map[string]interface{}, error) {
 	var result struct {
 		CurrentHeight float64 `json:"current_height"`
 		MinHeight     float64 `json:"min_height"`
@@ -107,9 +114,6 @@ func (d *UserHeightDAO) GetHeightStatistics(userID int64, days int) (map[string]
 	}
 	result.CurrentHeight = currentHeight.HeightCM
 
-	// 获取指定天数内的统计数据
-	startDate := time.Now().AddDate(0, 0, -days)
-
 	// 获取最早的身高记录
 	var firstHeight models.UserHeight
 	if err := d.db.Where("user_id = ? AND record_date >= ?", userID, startDate).
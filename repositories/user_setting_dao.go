@@ -0,0 +1,31 @@
+package repositories
+
+import (
+	"gorm.io/gorm"
+
+	"ome-app-back/models"
+)
+
+// UserSettingDAO 用户设置数据访问对象
+type UserSettingDAO struct {
+	db *gorm.DB
+}
+
+// NewUserSettingDAO 创建用户设置DAO
+func NewUserSettingDAO(db *gorm.DB) *UserSettingDAO {
+	return &UserSettingDAO{db: db}
+}
+
+// GetByUserID 获取用户设置，不存在时返回 gorm.ErrRecordNotFound
+func (d *UserSettingDAO) GetByUserID(userID int64) (*models.UserSetting, error) {
+	var setting models.UserSetting
+	if err := d.db.Where("user_id = ?", userID).First(&setting).Error; err != nil {
+		return nil, err
+	}
+	return &setting, nil
+}
+
+// Save 保存用户设置，ID为0时新建
+func (d *UserSettingDAO) Save(setting *models.UserSetting) error {
+	return d.db.Save(setting).Error
+}
@@ -19,12 +19,12 @@ func NewUserWeightDAO(db *gorm.DB) *UserWeightDAO {
 	return &UserWeightDAO{db: db}
 }
 
-// Create 记录用户体重
-func (d *UserWeightDAO) Create(userID int64, weightKG float64) error {
+// Create 记录用户体重，recordDate 为用户时区下的日历日期
+func (d *UserWeightDAO) Create(userID int64, weightKG float64, recordDate time.Time) error {
 	weight := models.UserWeight{
 		UserID:     userID,
 		WeightKG:   weightKG,
-		RecordDate: time.Now(),
+		RecordDate: recordDate,
 	}
 	return d.db.Create(&weight).Error
 }
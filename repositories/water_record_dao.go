@@ -0,0 +1,80 @@
+package repositories
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+
+	"ome-app-back/models"
+)
+
+// WaterRecordDAO 处理饮水记录数据访问
+type WaterRecordDAO struct {
+	db *gorm.DB
+}
+
+// NewWaterRecordDAO 创建饮水记录DAO实例
+func NewWaterRecordDAO(db *gorm.DB) *WaterRecordDAO {
+	return &WaterRecordDAO{db: db}
+}
+
+// WaterDailyTotal 每日饮水汇总
+type WaterDailyTotal struct {
+	Date    string `json:"date"`
+	TotalML int    `json:"total_ml"`
+}
+
+// Create 创建饮水记录
+func (d *WaterRecordDAO) Create(record *models.WaterRecord) error {
+	return d.db.Create(record).Error
+}
+
+// GetByDateRange 获取用户指定时间范围内的饮水记录
+func (d *WaterRecordDAO) GetByDateRange(userID int64, startTime, endTime time.Time) ([]models.WaterRecord, error) {
+	var records []models.WaterRecord
+	err := d.db.Where("user_id = ? AND record_time >= ? AND record_time < ?", userID, startTime, endTime).
+		Order("record_time DESC").
+		Find(&records).Error
+	if err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// GetDailyTotals 按天汇总用户指定时间范围内的饮水量，按 startTime 所在时区划分日期
+func (d *WaterRecordDAO) GetDailyTotals(userID int64, startTime, endTime time.Time) ([]WaterDailyTotal, error) {
+	records, err := d.GetByDateRange(userID, startTime, endTime)
+	if err != nil {
+		return nil, err
+	}
+
+	// 在应用层按日期汇总，避免不同数据库日期函数差异
+	totals := make(map[string]int)
+	dates := make([]string, 0)
+	for i := len(records) - 1; i >= 0; i-- {
+		dateKey := records[i].RecordTime.In(startTime.Location()).Format("2006-01-02")
+		if _, exists := totals[dateKey]; !exists {
+			dates = append(dates, dateKey)
+		}
+		totals[dateKey] += records[i].AmountML
+	}
+
+	result := make([]WaterDailyTotal, 0, len(dates))
+	for _, date := range dates {
+		result = append(result, WaterDailyTotal{Date: date, TotalML: totals[date]})
+	}
+	return result, nil
+}
+
+// Delete 删除饮水记录
+func (d *WaterRecordDAO) Delete(userID, recordID int64) error {
+	result := d.db.Where("id = ? AND user_id = ?", recordID, userID).Delete(&models.WaterRecord{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("记录不存在或无权限删除")
+	}
+	return nil
+}
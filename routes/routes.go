@@ -1,6 +1,8 @@
 package routes
 
 import (
+	"expvar"
+
 	v1 "ome-app-back/handlers/v1"
 	"ome-app-back/middleware"
 
@@ -23,6 +25,7 @@ func SetupRoutes(engine *gin.Engine, handlers *v1.Handlers) {
 	// 需要认证的接口
 	auth := apiV1.Group("")
 	auth.Use(middleware.JWT())
+	auth.Use(handlers.UserSetting.SyncTimezone) // 根据请求头自动同步用户时区
 	setupAuthRoutes(auth, handlers)
 }
 
@@ -53,6 +56,8 @@ func setupAuthRoutes(router *gin.RouterGroup, handlers *v1.Handlers) {
 	router.PUT("/user/profile", handlers.User.UpdateProfile)
 	router.PUT("/user/goal", handlers.User.UpdateGoal)
 	router.GET("/user/goal", handlers.User.GetGoal)
+	router.GET("/user/settings", handlers.UserSetting.GetSettings)
+	router.PUT("/user/settings", handlers.UserSetting.UpdateSettings)
 
 	// 文件访问（需要验证权限的用户文件）
 	router.GET("/user/files/*filepath", handlers.File.GetUserFile)
@@ -66,6 +71,47 @@ func setupAuthRoutes(router *gin.RouterGroup, handlers *v1.Handlers) {
 	router.PUT("/nutrition/today", handlers.Nutrition.UpdateTodayNutrition)
 	router.GET("/nutrition/history", handlers.Nutrition.GetNutritionHistory)
 	router.GET("/nutrition/weekly-summary", handlers.Nutrition.GetWeekSummary)
+	router.GET("/nutrition/report", handlers.NutritionReport.GetNutritionReport)
+	router.GET("/nutrition/score", handlers.DietScore.GetDailyScore)
+	router.GET("/nutrition/score/history", handlers.DietScore.GetScoreHistory)
+	router.GET("/nutrition/micronutrients", handlers.Nutrition.GetMicronutrientOptions)
+	router.GET("/nutrition/date/:date", handlers.Nutrition.GetNutritionByDate)
+	router.PUT("/nutrition/date/:date", handlers.Nutrition.UpdateNutritionByDate)
+	router.GET("/nutrition/date/:date/entries", handlers.Nutrition.GetNutritionEntries)
+	router.POST("/nutrition/date/:date/entries", handlers.Nutrition.AddNutritionEntry)
+	router.DELETE("/nutrition/entries/:id", handlers.Nutrition.DeleteNutritionEntry)
+	router.POST("/nutrition/copy-meal", handlers.Nutrition.CopyMeal)
+	router.GET("/nutrition/favorites", handlers.FavoriteFood.GetFavorites)
+	router.POST("/nutrition/favorites", handlers.FavoriteFood.SaveFavorite)
+	router.DELETE("/nutrition/favorites/:id", handlers.FavoriteFood.DeleteFavorite)
+	router.POST("/nutrition/favorites/:id/log", handlers.FavoriteFood.LogFavorite)
+	router.GET("/nutrition/frequent-foods", handlers.FavoriteFood.GetFrequentFoods)
+
+	// 食物库
+	router.GET("/foods", handlers.Food.SearchFoods)
+	router.POST("/foods", handlers.Food.CreateFood)
+	router.GET("/foods/:id", handlers.Food.GetFood)
+	router.DELETE("/foods/:id", handlers.Food.DeleteFood)
+
+	// 食谱
+	router.POST("/recipes", handlers.Recipe.CreateRecipe)
+	router.GET("/recipes", handlers.Recipe.GetMyRecipes)
+	router.GET("/recipes/public", handlers.Recipe.GetPublicRecipes)
+	router.GET("/recipes/:id", handlers.Recipe.GetRecipe)
+	router.PUT("/recipes/:id", handlers.Recipe.UpdateRecipe)
+	router.DELETE("/recipes/:id", handlers.Recipe.DeleteRecipe)
+	router.POST("/recipes/:id/log", handlers.Recipe.LogRecipe)
+
+	// 饮食计划
+	router.POST("/meal-plans", handlers.MealPlan.GenerateMealPlan)
+	router.GET("/meal-plans", handlers.MealPlan.GetMealPlans)
+	router.GET("/meal-plans/latest", handlers.MealPlan.GetLatestMealPlan)
+	router.GET("/meal-plans/:id", handlers.MealPlan.GetMealPlan)
+	router.POST("/meal-plans/items/:item_id/regenerate", handlers.MealPlan.RegenerateMeal)
+	router.POST("/meal-plans/items/:item_id/log", handlers.MealPlan.LogPlannedMeal)
+
+	// 今日推荐
+	router.GET("/recommendations/today", handlers.Recommendation.GetTodayRecommendations)
 
 	// 聊天会话管理
 	router.POST("/chat/sessions", handlers.Chat.CreateSession)
@@ -77,9 +123,16 @@ func setupAuthRoutes(router *gin.RouterGroup, handlers *v1.Handlers) {
 
 	// 食物识别
 	router.POST("/food/recognize", handlers.FoodRecognition.RecognizeFood)
+	router.POST("/food/recognize/text", handlers.FoodRecognition.RecognizeText)
 	router.GET("/food/recognition/:id", handlers.FoodRecognition.GetRecognitionByID)
 	router.GET("/food/recognition/today", handlers.FoodRecognition.GetTodayRecognitions)
+	router.PUT("/food/recognition/:id/items", handlers.FoodRecognition.UpdateRecognitionItems)
+	router.POST("/food/recognition/:id/items/confirm", handlers.FoodRecognition.ConfirmRecognitionItems)
+	router.POST("/food/recognition/:id/feedback", handlers.FoodRecognition.SubmitRecognitionFeedback)
 	router.POST("/food/recognition/:id/save", handlers.FoodRecognition.SaveRecognitionToNutrition)
+	router.POST("/food/recognition/:id/unadopt", handlers.FoodRecognition.UnadoptRecognition)
+	router.POST("/food/recognition/:id/retry", handlers.FoodRecognition.RetryRecognition)
+	router.GET("/food/recognition/:id/events", handlers.FoodRecognition.StreamRecognition)
 	router.GET("/food/recognition/adopted", handlers.FoodRecognition.GetAdoptedRecognitions)
 
 	// 运动记录
@@ -114,4 +167,22 @@ func setupAuthRoutes(router *gin.RouterGroup, handlers *v1.Handlers) {
 	router.GET("/user/height/current", handlers.Height.GetCurrentHeight)
 	router.DELETE("/user/height/:id", handlers.Height.DeleteHeight)
 	router.GET("/user/height/statistics", handlers.Height.GetHeightStatistics)
+
+	// 饮水记录
+	router.POST("/water", handlers.Water.CreateWater)
+	router.DELETE("/water/:id", handlers.Water.DeleteWater)
+	router.GET("/water/today", handlers.Water.GetTodayWater)
+	router.GET("/water/history", handlers.Water.GetWaterHistory)
+	router.GET("/water/statistics", handlers.Water.GetWaterStatistics)
+	router.GET("/water/options", handlers.Water.GetWaterOptions)
+
+	// 首页概览
+	router.GET("/dashboard/today", handlers.Dashboard.GetToday)
+
+	// 管理接口，仅配置中的管理员用户可访问
+	admin := router.Group("/admin")
+	admin.Use(handlers.Admin.RequireAdmin)
+	admin.GET("/recognition/eval-dataset", handlers.Admin.ExportRecognitionDataset)
+	// 运行指标（识别缓存命中率等），包含进程命令行与内存统计，JSON格式
+	admin.GET("/debug/vars", gin.WrapH(expvar.Handler()))
 }
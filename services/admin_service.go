@@ -0,0 +1,142 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"ome-app-back/config"
+	"ome-app-back/models"
+	"ome-app-back/models/constant"
+	"ome-app-back/repositories"
+)
+
+// ErrInvalidExportDate 导出日期格式无效
+var ErrInvalidExportDate = errors.New("日期格式无效，应为YYYY-MM-DD")
+
+// evalExportBatchSize 导出评测数据集时每批读取的识别记录数
+const evalExportBatchSize = 200
+
+// AdminService 处理管理接口相关服务
+type AdminService struct {
+	recognitionDAO *repositories.FoodRecognitionDAO
+	settingService *UserSettingService
+	adminUserIDs   map[int64]bool
+}
+
+// NewAdminService 创建管理服务实例
+func NewAdminService(recognitionDAO *repositories.FoodRecognitionDAO, settingService *UserSettingService, cfg *config.AdminConfig) *AdminService {
+	adminUserIDs := make(map[int64]bool, len(cfg.UserIDs))
+	for _, id := range cfg.UserIDs {
+		adminUserIDs[id] = true
+	}
+	return &AdminService{
+		recognitionDAO: recognitionDAO,
+		settingService: settingService,
+		adminUserIDs:   adminUserIDs,
+	}
+}
+
+// IsAdmin 判断用户是否可访问管理接口
+func (s *AdminService) IsAdmin(userID int64) bool {
+	return s.adminUserIDs[userID]
+}
+
+// ExportDatasetFileName 评测数据集的下载文件名，日期按管理员时区
+func (s *AdminService) ExportDatasetFileName(userID int64) string {
+	return fmt.Sprintf("recognition_eval_%s.jsonl", s.settingService.Now(userID).Format("20060102"))
+}
+
+// ExportRecognitionDataset 将用户提交过准确度反馈的识别记录导出为评测数据集（JSON Lines，每行一条样本）
+// startDate/endDate 按管理员 userID 的时区筛选反馈时间，格式 YYYY-MM-DD，为空时不限制；日期无效时在写入任何内容前返回错误
+func (s *AdminService) ExportRecognitionDataset(w io.Writer, userID int64, startDate, endDate string) (int, error) {
+	var start time.Time
+	end := time.Now().Add(time.Minute)
+	if startDate != "" {
+		parsed, err := s.settingService.ParseDate(userID, startDate)
+		if err != nil {
+			return 0, ErrInvalidExportDate
+		}
+		start = parsed
+	}
+	if endDate != "" {
+		parsed, err := s.settingService.ParseDate(userID, endDate)
+		if err != nil {
+			return 0, ErrInvalidExportDate
+		}
+		end = parsed.AddDate(0, 0, 1)
+	}
+	log.Printf("[评测数据集] 开始导出, 反馈时间范围: %s - %s", start.Format(time.RFC3339), end.Format(time.RFC3339))
+
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	count := 0
+	var lastID int64
+	for {
+		records, err := s.recognitionDAO.GetFeedbackRecognitions(lastID, start, end, evalExportBatchSize)
+		if err != nil {
+			log.Printf("[评测数据集] 错误: 查询识别记录失败: %v", err)
+			return count, err
+		}
+		for i := range records {
+			sample := newRecognitionEvalSample(&records[i])
+			// 反馈时间按提交反馈的用户时区输出
+			sample.FeedbackAt = sample.FeedbackAt.In(s.settingService.Location(records[i].UserID))
+			if err := encoder.Encode(sample); err != nil {
+				return count, err
+			}
+			count++
+		}
+		if len(records) < evalExportBatchSize {
+			break
+		}
+		lastID = records[len(records)-1].ID
+	}
+
+	log.Printf("[评测数据集] 导出完成, 共%d条样本", count)
+	return count, nil
+}
+
+// newRecognitionEvalSample 由识别记录生成评测样本，AI输出取用户修改前的原始结果
+func newRecognitionEvalSample(recognition *models.FoodRecognition) models.RecognitionEvalSample {
+	aiOutput := models.RecognitionGroundTruth{
+		Foods: recognition.CurrentFoods(),
+		Nutrition: models.FoodRecognitionNutrition{
+			CaloriesIntake: recognition.CaloriesIntake,
+			ProteinIntakeG: recognition.ProteinIntakeG,
+			CarbIntakeG:    recognition.CarbIntakeG,
+			FatIntakeG:     recognition.FatIntakeG,
+			Micronutrients: recognition.Micronutrients,
+		},
+	}
+	if recognition.OriginalNutrition != nil {
+		aiOutput = models.RecognitionGroundTruth{
+			Foods:     recognition.OriginalFoods(),
+			Nutrition: *recognition.OriginalNutrition,
+		}
+	}
+
+	sample := models.RecognitionEvalSample{
+		RecognitionID: recognition.ID,
+		Source:        recognition.Source,
+		InputText:     recognition.InputText,
+		AIResponse:    recognition.AIResponse,
+		AIOutput:      aiOutput,
+		Rating:        recognition.FeedbackRating,
+		Comment:       recognition.FeedbackComment,
+	}
+	if recognition.Source != constant.RecognitionSourceText {
+		sample.ImagePaths = recognition.AllImageURLs()
+		sample.OriginalImagePaths = recognition.AllOriginalImageURLs()
+	}
+	if recognition.GroundTruth != nil {
+		sample.Corrected = *recognition.GroundTruth
+	}
+	if recognition.FeedbackAt != nil {
+		sample.FeedbackAt = *recognition.FeedbackAt
+	}
+	return sample
+}
@@ -18,6 +18,7 @@ import (
 
 	"ome-app-back/config"
 	"ome-app-back/models"
+	"ome-app-back/models/constant"
 )
 
 // AIService 处理AI相关服务
@@ -29,10 +30,12 @@ type AIService struct {
 	defaultModel string
 	client       *http.Client
 	testMode     bool // 是否为测试模式
+
+	micronutrients []config.MicronutrientConfig // 食物识别需要估算的微量营养素
 }
 
 // NewAIService 创建AI服务实例
-func NewAIService(cfg *config.AIConfig) *AIService {
+func NewAIService(cfg *config.AIConfig, nutritionCfg *config.NutritionConfig) *AIService {
 	// 创建具有适当超时设置的HTTP客户端
 	transport := &http.Transport{
 		Proxy: func(req *http.Request) (*url.URL, error) {
@@ -69,6 +72,8 @@ func NewAIService(cfg *config.AIConfig) *AIService {
 		defaultModel: cfg.Model,
 		client:       client,
 		testMode:     cfg.TestMode, // 从配置读取测试模式
+
+		micronutrients: nutritionCfg.GetMicronutrients(),
 	}
 
 	if service.testMode {
@@ -87,6 +92,8 @@ type ChatRequest struct {
 	MaxTokens   int                      `json:"max_tokens,omitempty"`
 	Temperature float64                  `json:"temperature"`
 	Stream      bool                     `json:"stream,omitempty"`
+
+	ResponseFormat map[string]string `json:"response_format,omitempty"` // 结构化输出格式，如 {"type": "json_object"}
 }
 
 // ChatResponse AI响应结构
@@ -273,19 +280,53 @@ const testChatResponse = `有氧运动主要以提高心肺功能为主，但也
 // 简化版食物识别测试响应
 const testFoodRecognitionResponse = `{
   "foods": [
-    {"name": "鸡胸肉", "quantity": "约200克", "calories": 330},
-    {"name": "糙米", "quantity": "约150克", "calories": 240},
-    {"name": "西兰花", "quantity": "约100克", "calories": 55}
+    {"name": "鸡胸肉", "quantity": "约200克", "weight_g": 200, "calories": 330, "protein_g": 40, "carb_g": 0, "fat_g": 7, "confidence": 0.92, "allergens": []},
+    {"name": "糙米", "quantity": "约150克", "weight_g": 150, "calories": 240, "protein_g": 3.5, "carb_g": 52, "fat_g": 4, "confidence": 0.85, "allergens": []},
+    {"name": "西兰花", "quantity": "约100克", "weight_g": 100, "calories": 55, "protein_g": 1.5, "carb_g": 8, "fat_g": 4, "confidence": 0.55, "allergens": []}
   ],
   "nutrition": {
     "calories_intake": 625,
     "protein_intake_g": 45,
     "carb_intake_g": 60,
-    "fat_intake_g": 15
+    "fat_intake_g": 15,
+    "micronutrients": {
+      "fiber_g": 6.5,
+      "sugar_g": 3,
+      "sodium_mg": 520,
+      "cholesterol_mg": 145,
+      "vitamin_a_ug": 65,
+      "vitamin_c_mg": 92,
+      "vitamin_d_ug": 0.2
+    }
   },
   "analysis": "这是一顿营养均衡的健康餐，蛋白质含量丰富，适合健身增肌人群。碳水化合物以复合碳水为主，提供持久能量。添加更多蔬菜可增加纤维和微量元素摄入。"
 }`
 
+// 文字记录饮食测试响应
+const testFoodTextResponse = `{
+  "foods": [
+    {"name": "鸡蛋", "quantity": "2个", "weight_g": 100, "calories": 144, "protein_g": 13.3, "carb_g": 2.8, "fat_g": 8.8, "confidence": 0.95, "allergens": ["egg"]},
+    {"name": "白粥", "quantity": "1碗", "weight_g": 250, "calories": 115, "protein_g": 2.8, "carb_g": 24.5, "fat_g": 0.8, "confidence": 0.9, "allergens": []},
+    {"name": "油条", "quantity": "1根", "weight_g": 60, "calories": 232, "protein_g": 4.1, "carb_g": 30.5, "fat_g": 10.6, "confidence": 0.9, "allergens": ["gluten"]}
+  ],
+  "nutrition": {
+    "calories_intake": 491,
+    "protein_intake_g": 20.2,
+    "carb_intake_g": 57.8,
+    "fat_intake_g": 20.2,
+    "micronutrients": {
+      "fiber_g": 1.5,
+      "sugar_g": 1.2,
+      "sodium_mg": 720,
+      "cholesterol_mg": 372,
+      "vitamin_a_ug": 140,
+      "vitamin_c_mg": 0,
+      "vitamin_d_ug": 1.1
+    }
+  },
+  "analysis": "典型的中式早餐，鸡蛋提供优质蛋白；油条为油炸食品，脂肪和钠含量较高，建议减少食用频率，搭配蔬菜或水果补充膳食纤维和维生素C。"
+}`
+
 // ChatWithAI 发送聊天请求到AI
 func (s *AIService) ChatWithAI(messages []models.OpenAIMessage) (string, error) {
 	logPrefix := "[AI聊天]"
@@ -367,6 +408,105 @@ func (s *AIService) ChatWithAI(messages []models.OpenAIMessage) (string, error)
 	return processedContent, nil
 }
 
+// GenerateJSON 发送请求并要求AI以JSON对象返回，返回未经处理的原始JSON文本
+// testResponse 为测试模式下直接返回的内容
+func (s *AIService) GenerateJSON(messages []models.OpenAIMessage, testResponse string) (string, error) {
+	logPrefix := "[AI结构化输出]"
+
+	// 测试模式直接返回调用方提供的预定义响应
+	if s.testMode {
+		log.Printf("%s 测试模式，返回预定义响应", logPrefix)
+		return testResponse, nil
+	}
+
+	if s.apiKey == "" {
+		log.Println(logPrefix + " 错误: API密钥未配置")
+		return "", errors.New("AI API密钥未配置")
+	}
+
+	apiMessages := make([]map[string]interface{}, len(messages))
+	for i, msg := range messages {
+		apiMessages[i] = map[string]interface{}{
+			"role":    msg.Role,
+			"content": msg.Content,
+		}
+	}
+
+	requestBody := ChatRequest{
+		Model:          s.defaultModel,
+		Messages:       apiMessages,
+		MaxTokens:      s.maxTokens,
+		Temperature:    s.temperature,
+		ResponseFormat: map[string]string{"type": "json_object"},
+	}
+
+	log.Printf("%s 准备请求: 模型=%s, 消息数=%d", logPrefix, requestBody.Model, len(requestBody.Messages))
+
+	jsonData, err := json.Marshal(requestBody)
+	if err != nil {
+		log.Printf("%s 错误: 请求序列化失败: %v", logPrefix, err)
+		return "", fmt.Errorf("请求序列化失败: %v", err)
+	}
+
+	responseBody, err := s.makeAPIRequest(jsonData, logPrefix)
+	if err != nil {
+		return "", err
+	}
+
+	var responseData ChatResponse
+	if err := json.Unmarshal(responseBody, &responseData); err != nil {
+		log.Printf("%s 错误: 解析响应失败: %v", logPrefix, err)
+		return "", fmt.Errorf("解析响应失败: %v", err)
+	}
+
+	if len(responseData.Choices) == 0 {
+		log.Printf("%s 错误: API返回的选择项为空", logPrefix)
+		return "", errors.New("API返回的选择项为空")
+	}
+
+	if responseData.Usage.TotalTokens > 0 {
+		log.Printf("%s 响应统计: 总令牌=%d", logPrefix, responseData.Usage.TotalTokens)
+	}
+
+	// 去掉可能包裹在外层的markdown代码块标记和说明文字
+	content := strings.TrimSpace(responseData.Choices[0].Message.Content)
+	if extracted, err := extractJSONObject(content); err == nil {
+		return extracted, nil
+	}
+	return content, nil
+}
+
+// RepairFoodRecognitionJSON 将无法使用的食物识别输出连同问题描述发回AI，请求按相同格式修正
+func (s *AIService) RepairFoodRecognitionJSON(raw string, problems []string) (string, error) {
+	messages := []models.OpenAIMessage{
+		s.GetSystemMessageForFoodRecognition(),
+		{Role: "assistant", Content: raw},
+		{Role: "user", Content: "上面的结果存在以下问题，请修正后按相同JSON格式完整返回：\n" + strings.Join(problems, "\n")},
+	}
+	return s.GenerateJSON(messages, testFoodRecognitionResponse)
+}
+
+// ParseFoodText 将用户用文字描述的饮食（如"两个鸡蛋一碗白粥"）解析为与食物识别相同格式的JSON
+func (s *AIService) ParseFoodText(text string) (string, error) {
+	log.Printf("[AI文字记录] 开始解析: %s", text)
+	messages := []models.OpenAIMessage{
+		s.GetSystemMessageForFoodText(),
+		{Role: "user", Content: text},
+	}
+	return s.GenerateJSON(messages, testFoodTextResponse)
+}
+
+// RepairFoodTextJSON 将无法使用的文字饮食解析输出连同问题描述发回AI，请求按相同格式修正
+func (s *AIService) RepairFoodTextJSON(text, raw string, problems []string) (string, error) {
+	messages := []models.OpenAIMessage{
+		s.GetSystemMessageForFoodText(),
+		{Role: "user", Content: text},
+		{Role: "assistant", Content: raw},
+		{Role: "user", Content: "上面的结果存在以下问题，请修正后按相同JSON格式完整返回：\n" + strings.Join(problems, "\n")},
+	}
+	return s.GenerateJSON(messages, testFoodTextResponse)
+}
+
 // ChatWithAIStream 发送聊天请求到AI并以流式返回
 func (s *AIService) ChatWithAIStream(messages []models.OpenAIMessage, out chan<- string) error {
 	logPrefix := "[AI聊天-流式]"
@@ -503,9 +643,14 @@ func (s *AIService) ChatWithAIStream(messages []models.OpenAIMessage, out chan<-
 func (s *AIService) ConvertToMessages(chatMessages []models.ChatMessage) []models.OpenAIMessage {
 	messages := make([]models.OpenAIMessage, len(chatMessages))
 	for i, msg := range chatMessages {
+		content := msg.Content
+		if len(msg.Attachments) > 0 {
+			// 图片由识别结果以系统消息提供，这里只标注用户发送了照片
+			content = strings.TrimSpace(fmt.Sprintf("[发送了%d张餐食照片]\n%s", len(msg.Attachments), msg.Content))
+		}
 		messages[i] = models.OpenAIMessage{
 			Role:    string(msg.Role),
-			Content: msg.Content,
+			Content: content,
 		}
 	}
 	return messages
@@ -535,44 +680,113 @@ func (s *AIService) GetSystemMessageForFoodRecognition() models.OpenAIMessage {
 		Role: "system",
 		Content: `你是一个专业的食物识别和营养分析AI。你的任务是:
 1. 识别图片中的食物
-2. 估算每种食物的大致数量
-3. 计算总热量(千卡)和主要营养素含量(蛋白质、碳水化合物、脂肪，单位为克)
-4. 简要分析这顿饭的营养价值和健康性
+2. 估算每种食物的大致数量和重量(克)
+3. 计算每种食物的热量(千卡)和主要营养素含量(蛋白质、碳水化合物、脂肪，单位为克)，以及这顿饭的总量
+4. 估算微量营养素含量(` + s.micronutrientPromptNames() + `)
+5. 简要分析这顿饭的营养价值和健康性
+6. 标记每种食物可能含有的常见过敏原，只能使用以下代码: ` + allergenPromptCodes() + `；不含时返回空数组
+7. 给出每种食物的识别置信度(0-1之间的小数)，食物种类或份量难以从图片判断时给较低的值
+
+请以JSON格式输出结果:
+{
+  "foods": [
+    {"name": "食物名称", "quantity": "份量描述", "weight_g": 重量克数, "calories": 热量, "protein_g": 蛋白质克数, "carb_g": 碳水克数, "fat_g": 脂肪克数, "confidence": 识别置信度, "allergens": ["过敏原代码"]}
+  ],
+  "nutrition": {
+    "calories_intake": 总热量,
+    "protein_intake_g": 蛋白质克数,
+    "carb_intake_g": 碳水克数,
+    "fat_intake_g": 脂肪克数,
+    "micronutrients": {
+` + s.micronutrientPromptSchema() + `
+    }
+  },
+  "analysis": "对这顿饭的简短营养分析"
+}
+
+各食物的热量与宏量营养素之和必须等于总量。只返回JSON内容，不要添加其他文字说明。`,
+	}
+}
+
+// GetSystemMessageForFoodText 获取文字记录饮食的系统消息
+func (s *AIService) GetSystemMessageForFoodText() models.OpenAIMessage {
+	return models.OpenAIMessage{
+		Role: "system",
+		Content: `你是一个专业的营养分析AI。用户会用一段文字描述自己吃了什么，例如"两个鸡蛋一碗白粥一根油条"。你的任务是:
+1. 把描述拆分为独立的食物，使用常见的标准食物名称(如"鸡蛋"、"白粥"，不要带数量词)
+2. 按描述中的数量估算每种食物的份量和重量(克)；未说明数量时按一人份估算
+3. 计算每种食物的热量(千卡)和蛋白质、碳水化合物、脂肪(克)
+4. 计算总热量、宏量营养素合计，以及微量营养素(` + s.micronutrientPromptNames() + `)
+5. 简要分析这顿饭的营养价值和健康性
+6. 标记每种食物可能含有的常见过敏原，只能使用以下代码: ` + allergenPromptCodes() + `；不含时返回空数组
+7. 给出每种食物的解析置信度(0-1之间的小数)，描述含糊、无法确定是什么食物或份量时给较低的值
 
 请以JSON格式输出结果:
 {
   "foods": [
-    {"name": "食物名称", "quantity": "份量描述", "calories": 估计热量}
+    {"name": "食物名称", "quantity": "份量描述", "weight_g": 重量克数, "calories": 热量, "protein_g": 蛋白质克数, "carb_g": 碳水克数, "fat_g": 脂肪克数, "confidence": 解析置信度, "allergens": ["过敏原代码"]}
   ],
   "nutrition": {
     "calories_intake": 总热量,
     "protein_intake_g": 蛋白质克数,
     "carb_intake_g": 碳水克数,
-    "fat_intake_g": 脂肪克数
+    "fat_intake_g": 脂肪克数,
+    "micronutrients": {
+` + s.micronutrientPromptSchema() + `
+    }
   },
   "analysis": "对这顿饭的简短营养分析"
 }
 
-只返回JSON内容，不要添加其他文字说明。`,
+各食物的热量与宏量营养素之和必须等于总量。描述中没有食物时foods返回空数组。只返回JSON内容，不要添加其他文字说明。`,
 	}
 }
 
-// AnalyzeImageWithAI 分析图片内容（使用base64编码）
-func (s *AIService) AnalyzeImageWithAI(base64Image string, prompt string) (string, error) {
+// allergenPromptCodes 生成提示词中的过敏原代码列表
+func allergenPromptCodes() string {
+	codes := make([]string, 0, len(constant.Allergens))
+	for _, allergen := range constant.Allergens {
+		codes = append(codes, fmt.Sprintf("%s(%s)", allergen.Key, allergen.Name))
+	}
+	return strings.Join(codes, "、")
+}
+
+// micronutrientPromptNames 生成提示词中的微量营养素名称列表
+func (s *AIService) micronutrientPromptNames() string {
+	names := make([]string, 0, len(s.micronutrients))
+	for _, m := range s.micronutrients {
+		names = append(names, fmt.Sprintf("%s，单位为%s", m.Name, m.Unit))
+	}
+	return strings.Join(names, "；")
+}
+
+// micronutrientPromptSchema 生成提示词中micronutrients字段的JSON结构
+func (s *AIService) micronutrientPromptSchema() string {
+	lines := make([]string, 0, len(s.micronutrients))
+	for _, m := range s.micronutrients {
+		lines = append(lines, fmt.Sprintf(`      "%s": %s(%s)`, m.Key, m.Name, m.Unit))
+	}
+	return strings.Join(lines, ",\n")
+}
+
+// AIImageInput 发送给AI的图片
+type AIImageInput struct {
+	Base64   string // base64编码的图片内容
+	MIMEType string // 图片的真实MIME类型
+}
+
+// AnalyzeImageWithAI 分析图片内容（使用base64编码），多张图片在同一次请求中发送，按同一餐合并分析
+func (s *AIService) AnalyzeImageWithAI(images []AIImageInput, prompt string) (string, error) {
 	logPrefix := "[AI图像分析]"
 
 	// 测试模式直接返回预定义响应
 	if s.testMode {
 		log.Printf("%s 测试模式，返回预定义响应", logPrefix)
-		log.Printf("%s 测试模式原始完整回复内容: %s", logPrefix, testFoodRecognitionResponse)
-		// 将换行符替换为\n字符串，以便前端正确处理markdown
-		processedResponse := strings.ReplaceAll(testFoodRecognitionResponse, "\n", "\\n")
-		log.Printf("%s 测试模式处理后发送给前端的内容: %s", logPrefix, processedResponse)
-		return processedResponse, nil
+		return testFoodRecognitionResponse, nil
 	}
 
 	// 记录请求开始
-	log.Printf("%s 开始请求, 提示词: %s", logPrefix, prompt)
+	log.Printf("%s 开始请求, 图片数=%d, 提示词: %s", logPrefix, len(images), prompt)
 
 	// 创建带有图像的消息内容
 	systemMessage := map[string]interface{}{
@@ -580,21 +794,30 @@ func (s *AIService) AnalyzeImageWithAI(base64Image string, prompt string) (strin
 		"content": s.GetSystemMessageForFoodRecognition().Content,
 	}
 
-	userMessage := map[string]interface{}{
-		"role": "user",
-		"content": []interface{}{
-			map[string]string{
-				"type": "text",
-				"text": "图片中的食物是什么？请分析营养成分。",
-			},
-			map[string]interface{}{
-				"type": "image_url",
-				"image_url": map[string]string{
-					"url": "data:image/jpeg;base64," + base64Image,
-				},
-			},
+	text := "图片中的食物是什么？请分析营养成分。"
+	if len(images) > 1 {
+		text = fmt.Sprintf("以下%d张图片属于同一餐，可能是同一份食物的不同角度，也可能是同一桌上的不同餐盘。"+
+			"请合并识别：同一份食物在多张图片中出现时只列出一次、只计算一次热量，不同的餐盘分别列出。请分析这一餐的营养成分。", len(images))
+	}
+	parts := []interface{}{
+		map[string]string{
+			"type": "text",
+			"text": text,
 		},
 	}
+	for _, image := range images {
+		parts = append(parts, map[string]interface{}{
+			"type": "image_url",
+			"image_url": map[string]string{
+				"url": "data:" + image.MIMEType + ";base64," + image.Base64,
+			},
+		})
+	}
+
+	userMessage := map[string]interface{}{
+		"role":    "user",
+		"content": parts,
+	}
 
 	messages := []map[string]interface{}{systemMessage, userMessage}
 
@@ -639,12 +862,8 @@ func (s *AIService) AnalyzeImageWithAI(base64Image string, prompt string) (strin
 		log.Printf("%s 响应统计: 总令牌=%d", logPrefix, responseData.Usage.TotalTokens)
 	}
 
-	// 返回AI回复内容
-	originalContent := responseData.Choices[0].Message.Content
-	log.Printf("%s 成功获取原始回复, 完整内容: %s", logPrefix, originalContent)
-
-	// 将换行符替换为\n字符串，以便前端正确处理markdown
-	processedContent := strings.ReplaceAll(originalContent, "\n", "\\n")
-	log.Printf("%s 处理后发送给前端的内容: %s", logPrefix, processedContent)
-	return processedContent, nil
+	// 返回未经处理的AI回复内容，由调用方提取并校验其中的JSON
+	content := responseData.Choices[0].Message.Content
+	log.Printf("%s 成功获取原始回复, 完整内容: %s", logPrefix, content)
+	return content, nil
 }
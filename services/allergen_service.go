@@ -0,0 +1,155 @@
+package services
+
+import (
+	"log"
+	"strings"
+
+	"ome-app-back/models"
+	"ome-app-back/models/constant"
+	"ome-app-back/repositories"
+)
+
+// AllergenService 过敏原与食物不耐受检查服务
+type AllergenService struct {
+	userGoalDAO *repositories.UserGoalDAO
+}
+
+// NewAllergenService 创建过敏原检查服务实例
+func NewAllergenService(userGoalDAO *repositories.UserGoalDAO) *AllergenService {
+	return &AllergenService{
+		userGoalDAO: userGoalDAO,
+	}
+}
+
+// AllergenCheckFood 待检查的食物
+type AllergenCheckFood struct {
+	Name      string   // 食物名称
+	Allergens []string // AI标记的过敏原代码
+}
+
+// intoleranceRule 用户不耐受项解析后的匹配规则
+type intoleranceRule struct {
+	intolerance string
+	allergen    *constant.Allergen // 为空表示映射表中没有的自定义不耐受项
+}
+
+// CheckFoods 按用户填写的食物不耐受检查食物，返回过敏原警告
+// 检查失败或用户未设置目标时不返回警告，不影响识别与记录流程
+func (s *AllergenService) CheckFoods(userID int64, foods []AllergenCheckFood) []models.AllergenWarning {
+	goal, err := s.userGoalDAO.GetByUserID(userID)
+	if err != nil {
+		log.Printf("[过敏原检查] 用户(ID:%d)获取健康目标失败，跳过检查: %v", userID, err)
+		return nil
+	}
+
+	warnings := DetectAllergenWarnings(goal.FoodIntolerances, foods)
+	if len(warnings) > 0 {
+		log.Printf("[过敏原检查] 用户(ID:%d)命中%d条过敏原警告", userID, len(warnings))
+	}
+	return warnings
+}
+
+// CheckNames 按食物名称检查过敏原
+func (s *AllergenService) CheckNames(userID int64, names ...string) []models.AllergenWarning {
+	foods := make([]AllergenCheckFood, 0, len(names))
+	for _, name := range names {
+		if strings.TrimSpace(name) != "" {
+			foods = append(foods, AllergenCheckFood{Name: name})
+		}
+	}
+	if len(foods) == 0 {
+		return nil
+	}
+	return s.CheckFoods(userID, foods)
+}
+
+// DetectAllergenWarnings 根据不耐受项与过敏原映射表生成警告，同一食物同一不耐受项只警告一次
+func DetectAllergenWarnings(intolerances []string, foods []AllergenCheckFood) []models.AllergenWarning {
+	rules := parseIntolerances(intolerances)
+	if len(rules) == 0 {
+		return nil
+	}
+
+	var warnings []models.AllergenWarning
+	seen := make(map[string]bool)
+	for _, food := range foods {
+		for _, rule := range rules {
+			source := ""
+			if rule.allergen != nil && containsString(food.Allergens, rule.allergen.Key) {
+				source = constant.AllergenSourceAI
+			} else if rule.matches(food.Name) {
+				source = constant.AllergenSourceKeyword
+			}
+			if source == "" {
+				continue
+			}
+
+			key := food.Name + "|" + rule.intolerance
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+
+			warning := models.AllergenWarning{
+				FoodName:     food.Name,
+				Intolerance:  rule.intolerance,
+				AllergenName: rule.intolerance,
+				Source:       source,
+			}
+			if rule.allergen != nil {
+				warning.Allergen = rule.allergen.Key
+				warning.AllergenName = rule.allergen.Name
+			}
+			warnings = append(warnings, warning)
+		}
+	}
+	return warnings
+}
+
+// matchIntolerances 返回文本命中的用户不耐受项
+func matchIntolerances(text string, intolerances []string) []string {
+	var matched []string
+	for _, rule := range parseIntolerances(intolerances) {
+		if rule.matches(text) {
+			matched = append(matched, rule.intolerance)
+		}
+	}
+	return matched
+}
+
+// parseIntolerances 将用户填写的不耐受项映射到过敏原类别
+func parseIntolerances(intolerances []string) []intoleranceRule {
+	rules := make([]intoleranceRule, 0, len(intolerances))
+	for _, intolerance := range intolerances {
+		value := strings.TrimSpace(intolerance)
+		lower := strings.ToLower(value)
+		if value == "" || containsString(constant.NoIntoleranceValues, lower) {
+			continue
+		}
+
+		rule := intoleranceRule{intolerance: value}
+		for i := range constant.Allergens {
+			allergen := &constant.Allergens[i]
+			if lower == allergen.Key || containsString(allergen.Aliases, lower) {
+				rule.allergen = allergen
+				break
+			}
+		}
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+// matches 判断文本是否含有该不耐受项对应的食物
+func (r intoleranceRule) matches(text string) bool {
+	text = strings.ToLower(text)
+	if r.allergen == nil {
+		return strings.Contains(text, strings.ToLower(r.intolerance))
+	}
+	for _, keyword := range r.allergen.Keywords {
+		if strings.Contains(text, keyword) {
+			return true
+		}
+	}
+	return false
+}
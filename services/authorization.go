@@ -0,0 +1,49 @@
+package services
+
+import (
+	"errors"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// 用户资源的访问控制约定：
+//   - 按ID访问的用户记录一律按所有者查询（DAO 以 id 与 user_id 共同查询），不属于当前用户的记录与不存在的记录一样
+//     返回 gorm.ErrRecordNotFound，接口返回404，不暴露其他用户的记录是否存在；
+//   - 上传文件按所在目录 uploads/user_{id}/ 判断归属，只能由所有者通过需要认证的文件接口访问，
+//     公共文件接口不提供用户目录下的文件。
+
+// ErrForbidden 无权访问该文件
+var ErrForbidden = errors.New("无权访问该文件")
+
+// uploadDirPrefix 上传文件访问路径的目录前缀
+const uploadDirPrefix = "uploads/"
+
+// userUploadDirPrefix 用户上传目录名前缀，目录名为 user_{用户ID}
+const userUploadDirPrefix = "user_"
+
+// cleanUploadPath 规范化上传文件的访问路径（去掉前导斜杠），路径包含 ".." 或不在上传目录下时返回 ErrForbidden
+func cleanUploadPath(filePath string) (string, error) {
+	if strings.Contains(filePath, "..") {
+		return "", ErrForbidden
+	}
+	cleaned := strings.TrimPrefix(path.Clean("/"+filePath), "/")
+	if !strings.HasPrefix(cleaned, uploadDirPrefix) {
+		return "", ErrForbidden
+	}
+	return cleaned, nil
+}
+
+// uploadOwner 返回上传文件所属的用户ID，第二个返回值表示文件是否位于用户目录下
+// 用户目录名无法解析出用户ID时返回0，任何用户都无法访问
+func uploadOwner(cleanPath string) (int64, bool) {
+	dir := strings.SplitN(strings.TrimPrefix(cleanPath, uploadDirPrefix), "/", 2)[0]
+	if !strings.HasPrefix(dir, userUploadDirPrefix) {
+		return 0, false
+	}
+	userID, err := strconv.ParseInt(strings.TrimPrefix(dir, userUploadDirPrefix), 10, 64)
+	if err != nil || userID <= 0 {
+		return 0, true
+	}
+	return userID, true
+}
@@ -1,7 +1,10 @@
 package services
 
 import (
+	"errors"
+	"fmt"
 	"log"
+	"mime/multipart"
 	"strings"
 	"sync"
 	"time"
@@ -12,15 +15,17 @@ import (
 
 // ChatService 处理聊天相关服务
 type ChatService struct {
-	chatDAO   *repositories.ChatDAO
-	aiService *AIService
+	chatDAO            *repositories.ChatDAO
+	aiService          *AIService
+	recognitionService *FoodRecognitionService
 }
 
 // NewChatService 创建聊天服务实例
-func NewChatService(chatDAO *repositories.ChatDAO, aiService *AIService) *ChatService {
+func NewChatService(chatDAO *repositories.ChatDAO, aiService *AIService, recognitionService *FoodRecognitionService) *ChatService {
 	return &ChatService{
-		chatDAO:   chatDAO,
-		aiService: aiService,
+		chatDAO:            chatDAO,
+		aiService:          aiService,
+		recognitionService: recognitionService,
 	}
 }
 
@@ -29,9 +34,14 @@ func (s *ChatService) CreateSession(userID int64, title string) (*models.ChatSes
 	return s.chatDAO.CreateSession(userID, title)
 }
 
-// GetSession 获取会话信息
-func (s *ChatService) GetSession(sessionID string) (*models.ChatSession, error) {
-	return s.chatDAO.GetSession(sessionID)
+// GetSession 获取用户的会话信息，会话不属于该用户时与不存在一样返回 gorm.ErrRecordNotFound
+func (s *ChatService) GetSession(userID int64, sessionID string) (*models.ChatSession, error) {
+	session, err := s.chatDAO.GetUserSession(sessionID, userID)
+	if err != nil {
+		log.Printf("[聊天] 错误: 用户(ID:%d)获取会话(ID:%s)失败: %v", userID, sessionID, err)
+		return nil, err
+	}
+	return session, nil
 }
 
 // ListUserSessions 获取用户的所有会话列表
@@ -39,21 +49,48 @@ func (s *ChatService) ListUserSessions(userID int64) ([]models.ChatSession, erro
 	return s.chatDAO.ListUserSessions(userID)
 }
 
-// UpdateSessionTitle 更新会话标题
-func (s *ChatService) UpdateSessionTitle(sessionID string, title string) error {
-	return s.chatDAO.UpdateSessionTitle(sessionID, title)
+// UpdateSessionTitle 更新用户会话的标题
+func (s *ChatService) UpdateSessionTitle(userID int64, sessionID string, title string) error {
+	if _, err := s.GetSession(userID, sessionID); err != nil {
+		return err
+	}
+	return s.chatDAO.UpdateSessionTitle(sessionID, userID, title)
 }
 
-// DeleteSession 删除会话及其消息
-func (s *ChatService) DeleteSession(sessionID string) error {
-	return s.chatDAO.DeleteSession(sessionID)
+// DeleteSession 删除用户的会话及其消息
+func (s *ChatService) DeleteSession(userID int64, sessionID string) error {
+	return s.chatDAO.DeleteSession(sessionID, userID)
+}
+
+// ErrEmptyChatMessage 消息内容与图片均为空
+var ErrEmptyChatMessage = errors.New("消息内容和图片不能同时为空")
+
+const (
+	chatHistoryLimit            = 10 // 发送给AI的最近历史消息条数
+	chatRecognitionContextLimit = 3  // 作为上下文提供给AI的会话内最近识别记录数
+)
+
+// ChatStreamEvent 聊天流式输出事件，Event 为SSE事件名
+// message 事件的 Data 为AI回复片段，recognition 事件的 Data 为图片识别结果
+type ChatStreamEvent struct {
+	Event string
+	Data  interface{}
 }
 
 // SendMessage 发送消息并获取AI回复
-func (s *ChatService) SendMessage(userID int64, sessionID string, content string) (*models.ChatMessage, <-chan string, error) {
-	log.Printf("[聊天] 用户(ID:%d)在会话(ID:%s)中发送新消息", userID, sessionID)
+// 附带餐食图片时先上传图片并作为附件保存在用户消息上，随后在后台完成识别，
+// 通过 recognition 事件推送识别结果，再由AI结合识别数据流式回复
+func (s *ChatService) SendMessage(userID int64, sessionID string, content string, files []*multipart.FileHeader) (*models.ChatMessage, <-chan ChatStreamEvent, error) {
+	log.Printf("[聊天] 用户(ID:%d)在会话(ID:%s)中发送新消息, 图片数: %d", userID, sessionID, len(files))
 	start := time.Now()
 
+	if strings.TrimSpace(content) == "" && len(files) == 0 {
+		return nil, nil, ErrEmptyChatMessage
+	}
+	if _, err := s.GetSession(userID, sessionID); err != nil {
+		return nil, nil, err
+	}
+
 	// 创建用户消息
 	userMessage := &models.ChatMessage{
 		SessionID: sessionID,
@@ -62,6 +99,26 @@ func (s *ChatService) SendMessage(userID int64, sessionID string, content string
 		Content:   content,
 	}
 
+	// 上传图片并创建待识别记录，图片作为附件保存在用户消息上
+	var recognition *models.FoodRecognition
+	if len(files) > 0 {
+		var err error
+		recognition, err = s.recognitionService.PrepareRecognition(userID, sessionID, files)
+		if err != nil {
+			log.Printf("[聊天] 错误: 上传餐食图片失败: %v", err)
+			return nil, nil, err
+		}
+		originalURLs := recognition.AllOriginalImageURLs()
+		for i, url := range recognition.AllImageURLs() {
+			attachment := models.ChatAttachment{Type: models.ChatAttachmentImage, URL: url}
+			if i < len(originalURLs) {
+				attachment.OriginalURL = originalURLs[i]
+			}
+			userMessage.Attachments = append(userMessage.Attachments, attachment)
+		}
+		userMessage.RecognitionID = &recognition.ID
+	}
+
 	// 保存用户消息
 	if err := s.chatDAO.AddMessage(userMessage); err != nil {
 		log.Printf("[聊天] 错误: 保存用户消息失败: %v", err)
@@ -70,15 +127,28 @@ func (s *ChatService) SendMessage(userID int64, sessionID string, content string
 	log.Printf("[聊天] 用户消息已保存(ID:%d)", userMessage.ID)
 
 	// 创建一个通道，用于将AI的响应流式传输给调用者
-	responseChan := make(chan string, 10)
+	responseChan := make(chan ChatStreamEvent, 10)
 
 	// 启动一个goroutine来处理AI交互和数据库保存
 	go func() {
 		defer close(responseChan)
 
+		// 先完成图片识别并推送识别结果，识别失败时直接回复失败原因
+		if recognition != nil {
+			result, err := s.recognitionService.AwaitRecognition(recognition)
+			if err != nil {
+				log.Printf("[聊天] 错误: 识别餐食图片失败(识别记录ID:%d): %v", recognition.ID, err)
+				reply := "抱歉，这餐的照片没能识别成功，可以稍后在识别记录中重试，或换一张更清晰的照片再发给我。"
+				responseChan <- ChatStreamEvent{Event: "message", Data: reply}
+				s.saveAssistantMessage(userID, sessionID, reply, userMessage.RecognitionID)
+				return
+			}
+			responseChan <- ChatStreamEvent{Event: "recognition", Data: result}
+		}
+
 		// 获取会话历史消息
 		log.Printf("[聊天] 正在获取会话历史消息...")
-		messages, err := s.chatDAO.GetLastNMessages(sessionID, 10) // 获取最近10条消息
+		messages, err := s.chatDAO.GetLastNMessages(sessionID, chatHistoryLimit)
 		if err != nil {
 			log.Printf("[聊天] 错误: 获取会话历史消息失败: %v", err)
 			return
@@ -88,9 +158,12 @@ func (s *ChatService) SendMessage(userID int64, sessionID string, content string
 		// 转换为AI服务格式的消息
 		aiMessages := s.aiService.ConvertToMessages(messages)
 
-		// 添加系统消息
-		systemMessage := s.aiService.GetSystemMessageForChat()
-		aiMessages = append([]models.OpenAIMessage{systemMessage}, aiMessages...)
+		// 添加系统消息，会话中识别过餐食时附上识别数据，便于回答后续追问
+		systemMessages := []models.OpenAIMessage{s.aiService.GetSystemMessageForChat()}
+		if recognitionMessage, ok := s.recognitionContextMessage(userID, sessionID); ok {
+			systemMessages = append(systemMessages, recognitionMessage)
+		}
+		aiMessages = append(systemMessages, aiMessages...)
 		log.Printf("[聊天] 准备向AI发送%d条消息(含系统消息)", len(aiMessages))
 
 		// 用于从AI服务接收流式响应的内部通道
@@ -103,7 +176,7 @@ func (s *ChatService) SendMessage(userID int64, sessionID string, content string
 			defer wg.Done()
 			for chunk := range aiStreamChan {
 				fullResponse.WriteString(chunk)
-				responseChan <- chunk
+				responseChan <- ChatStreamEvent{Event: "message", Data: chunk}
 			}
 		}()
 
@@ -119,18 +192,7 @@ func (s *ChatService) SendMessage(userID int64, sessionID string, content string
 
 		// 创建并保存AI回复消息
 		if fullResponse.Len() > 0 {
-			assistantMessage := &models.ChatMessage{
-				SessionID: sessionID,
-				UserID:    userID,
-				Role:      models.RoleAssistant,
-				Content:   fullResponse.String(),
-			}
-
-			if err := s.chatDAO.AddMessage(assistantMessage); err != nil {
-				log.Printf("[聊天] 错误: 保存AI回复失败: %v", err)
-			} else {
-				log.Printf("[聊天] AI回复已保存(ID:%d)", assistantMessage.ID)
-			}
+			s.saveAssistantMessage(userID, sessionID, fullResponse.String(), userMessage.RecognitionID)
 		}
 
 		duration := time.Since(start)
@@ -140,7 +202,69 @@ func (s *ChatService) SendMessage(userID int64, sessionID string, content string
 	return userMessage, responseChan, nil
 }
 
-// GetMessages 获取会话的消息列表
-func (s *ChatService) GetMessages(sessionID string) ([]models.ChatMessage, error) {
+// saveAssistantMessage 保存AI回复消息，回复针对图片识别时关联识别记录
+func (s *ChatService) saveAssistantMessage(userID int64, sessionID, content string, recognitionID *int64) {
+	assistantMessage := &models.ChatMessage{
+		SessionID:     sessionID,
+		UserID:        userID,
+		Role:          models.RoleAssistant,
+		Content:       content,
+		RecognitionID: recognitionID,
+	}
+
+	if err := s.chatDAO.AddMessage(assistantMessage); err != nil {
+		log.Printf("[聊天] 错误: 保存AI回复失败: %v", err)
+	} else {
+		log.Printf("[聊天] AI回复已保存(ID:%d)", assistantMessage.ID)
+	}
+}
+
+// recognitionContextMessage 将会话中最近的餐食识别结果整理为系统消息，会话中没有识别记录时返回 false
+// 使用识别记录的当前数据，用户在识别记录中修正过的食物也会同步到对话中
+func (s *ChatService) recognitionContextMessage(userID int64, sessionID string) (models.OpenAIMessage, bool) {
+	results, err := s.recognitionService.GetSessionRecognitions(userID, sessionID, chatRecognitionContextLimit)
+	if err != nil {
+		log.Printf("[聊天] 警告: 获取会话识别记录失败: %v", err)
+		return models.OpenAIMessage{}, false
+	}
+	if len(results) == 0 {
+		return models.OpenAIMessage{}, false
+	}
+
+	var b strings.Builder
+	b.WriteString("以下是用户在本次对话中发送的餐食照片的识别结果（可能经用户修正），回答与这些餐食相关的问题时请以此为准。")
+	b.WriteString("如果用户刚发送了照片，请先简要总结识别出的食物和营养，再给出饮食建议；对标记为待确认的食物，可提醒用户核对。\n")
+	// 识别记录按时间倒序返回，按发送顺序列出
+	for i := len(results) - 1; i >= 0; i-- {
+		result := results[i]
+		status := "未记入饮食记录"
+		if result.IsAdopted {
+			status = "已记入饮食记录"
+		}
+		fmt.Fprintf(&b, "\n餐食识别记录#%d（%s，%s）：\n", result.ID, result.RecordDate, status)
+		for _, food := range result.RecognizedFoods {
+			fmt.Fprintf(&b, "- %s %s 约%.0f克：热量%.0f千卡，蛋白质%.1f克，碳水%.1f克，脂肪%.1f克",
+				food.Name, food.Quantity, food.WeightG, food.Calories, food.ProteinG, food.CarbG, food.FatG)
+			if food.LowConfidence {
+				b.WriteString("（识别置信度低，待确认）")
+			}
+			b.WriteString("\n")
+		}
+		n := result.NutritionSummary
+		fmt.Fprintf(&b, "合计：热量%.0f千卡，蛋白质%.1f克，碳水%.1f克，脂肪%.1f克\n",
+			n.CaloriesIntake, n.ProteinIntakeG, n.CarbIntakeG, n.FatIntakeG)
+	}
+
+	return models.OpenAIMessage{
+		Role:    string(models.RoleSystem),
+		Content: b.String(),
+	}, true
+}
+
+// GetMessages 获取用户会话的消息列表
+func (s *ChatService) GetMessages(userID int64, sessionID string) ([]models.ChatMessage, error) {
+	if _, err := s.GetSession(userID, sessionID); err != nil {
+		return nil, err
+	}
 	return s.chatDAO.GetMessages(sessionID)
 }
@@ -0,0 +1,62 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"gorm.io/gorm"
+
+	"ome-app-back/models"
+)
+
+func TestChatServiceRejectsOtherUsersSession(t *testing.T) {
+	svc, repos := newTestServices(t)
+	const ownerID, otherID = int64(1), int64(2)
+
+	session, err := svc.ChatService.CreateSession(ownerID, "午餐")
+	if err != nil {
+		t.Fatalf("创建会话失败: %v", err)
+	}
+	err = repos.ChatDAO.AddMessage(&models.ChatMessage{SessionID: session.ID, UserID: ownerID, Role: models.RoleUser, Content: "今天吃了什么"})
+	if err != nil {
+		t.Fatalf("保存消息失败: %v", err)
+	}
+
+	calls := map[string]func() error{
+		"GetMessages": func() error {
+			_, err := svc.ChatService.GetMessages(otherID, session.ID)
+			return err
+		},
+		"UpdateSessionTitle": func() error {
+			return svc.ChatService.UpdateSessionTitle(otherID, session.ID, "被修改")
+		},
+		"DeleteSession": func() error {
+			return svc.ChatService.DeleteSession(otherID, session.ID)
+		},
+		"SendMessage": func() error {
+			_, _, err := svc.ChatService.SendMessage(otherID, session.ID, "这餐热量多少", nil)
+			return err
+		},
+	}
+	for name, call := range calls {
+		if err := call(); !errors.Is(err, gorm.ErrRecordNotFound) {
+			t.Errorf("%s: 其他用户访问会话应返回 gorm.ErrRecordNotFound，实际为 %v", name, err)
+		}
+	}
+
+	// 会话与消息保持不变
+	got, err := svc.ChatService.GetSession(ownerID, session.ID)
+	if err != nil {
+		t.Fatalf("所有者获取会话失败: %v", err)
+	}
+	if got.Title != "午餐" {
+		t.Errorf("会话标题被修改为 %q", got.Title)
+	}
+	messages, err := svc.ChatService.GetMessages(ownerID, session.ID)
+	if err != nil {
+		t.Fatalf("所有者获取消息失败: %v", err)
+	}
+	if len(messages) != 1 {
+		t.Errorf("会话消息数应为1，实际为%d", len(messages))
+	}
+}
@@ -0,0 +1,91 @@
+package services
+
+import (
+	"errors"
+	"log"
+
+	"ome-app-back/models"
+)
+
+// 首页推荐卡片展示的推荐数量
+const dashboardRecommendationLimit = 3
+
+// DashboardService 首页每日概览服务
+type DashboardService struct {
+	nutritionService *NutritionService
+	waterService     *WaterService
+	exerciseService  *ExerciseService
+
+	recommendationService *RecommendationService
+}
+
+// NewDashboardService 创建首页概览服务实例
+func NewDashboardService(nutritionService *NutritionService, waterService *WaterService, exerciseService *ExerciseService, recommendationService *RecommendationService) *DashboardService {
+	return &DashboardService{
+		nutritionService: nutritionService,
+		waterService:     waterService,
+		exerciseService:  exerciseService,
+
+		recommendationService: recommendationService,
+	}
+}
+
+// DashboardExerciseSummary 今日运动汇总
+type DashboardExerciseSummary struct {
+	Count          int     `json:"count"`
+	DurationMin    float64 `json:"duration_min"`
+	CaloriesBurned float64 `json:"calories_burned"`
+}
+
+// DashboardTodayResponse 今日概览响应
+type DashboardTodayResponse struct {
+	Nutrition     *models.DailyNutrition   `json:"nutrition"`
+	NeedsAnalysis bool                     `json:"needs_analysis"` // 用户尚未生成健康分析，营养数据为空
+	Water         *WaterTodayResponse      `json:"water"`
+	Exercise      DashboardExerciseSummary `json:"exercise"`
+
+	Recommendations []Recommendation `json:"recommendations"` // 今日推荐卡片（规则排序的前几项）
+}
+
+// GetToday 获取今日概览：营养、饮水与运动
+func (s *DashboardService) GetToday(userID int64) (*DashboardTodayResponse, error) {
+	resp := &DashboardTodayResponse{}
+
+	nutrition, err := s.nutritionService.GetTodayNutrition(userID)
+	if err != nil {
+		if !errors.Is(err, ErrNoHealthAnalysis) {
+			return nil, err
+		}
+		resp.NeedsAnalysis = true
+	} else {
+		resp.Nutrition = nutrition
+	}
+
+	water, err := s.waterService.GetTodayWater(userID)
+	if err != nil {
+		return nil, err
+	}
+	resp.Water = water
+
+	exercises, err := s.exerciseService.GetTodayExercises(userID)
+	if err != nil {
+		return nil, err
+	}
+	resp.Exercise.Count = len(exercises)
+	for _, exercise := range exercises {
+		resp.Exercise.DurationMin += exercise.DurationMin
+		resp.Exercise.CaloriesBurned += exercise.CaloriesBurned
+	}
+
+	// 今日推荐卡片，获取失败不影响概览
+	if !resp.NeedsAnalysis {
+		recommendations, err := s.recommendationService.GetTodayRecommendations(userID, &RecommendationRequest{Limit: dashboardRecommendationLimit})
+		if err != nil {
+			log.Printf("[首页概览] 用户(ID:%d)获取今日推荐失败: %v", userID, err)
+		} else {
+			resp.Recommendations = recommendations.Suggestions
+		}
+	}
+
+	return resp, nil
+}
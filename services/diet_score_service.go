@@ -0,0 +1,405 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"time"
+
+	"ome-app-back/config"
+	"ome-app-back/models"
+	"ome-app-back/repositories"
+)
+
+// 饮食评分维度
+const (
+	DietScoreCalories       = "calories"       // 热量达标
+	DietScoreMacroBalance   = "macro_balance"  // 宏量营养素供能比
+	DietScoreProtein        = "protein"        // 蛋白质充足
+	DietScoreVariety        = "variety"        // 食物多样性
+	DietScoreMicronutrients = "micronutrients" // 微量营养素限值
+)
+
+// 饮食评分参数
+const (
+	dietScoreMaxRangeDays = 366
+	dietScoreDefaultDays  = 30
+
+	calorieFullScoreDeviation = 0.10 // 热量偏差在±10%以内得满分
+	calorieZeroScoreDeviation = 0.50 // 热量偏差达到±50%时得0分
+	macroZeroScoreDeviation   = 15.0 // 供能比平均偏差达到15个百分点时得0分
+	varietyFullScoreFoods     = 8    // 一天吃到8种不同食物得满分
+	micronutrientMaxOverLimit = 1.0  // 限量营养素超出限值100%时得0分
+)
+
+// 各评分维度的权重，某维度无数据时按其余维度的权重重新归一
+var dietScoreWeights = []struct {
+	Key    string
+	Name   string
+	Weight float64
+}{
+	{DietScoreCalories, "热量达标", 30},
+	{DietScoreMacroBalance, "营养素均衡", 20},
+	{DietScoreProtein, "蛋白质充足", 20},
+	{DietScoreVariety, "食物多样性", 15},
+	{DietScoreMicronutrients, "微量营养素", 15},
+}
+
+// 自定义错误，表示评分查询的日期范围无效
+var ErrInvalidScoreRange = errors.New("评分日期范围无效")
+
+// DietScoreComponent 饮食评分的单个维度
+type DietScoreComponent struct {
+	Key       string  `json:"key"`
+	Name      string  `json:"name"`
+	Score     float64 `json:"score"`     // 维度得分(0-100)
+	Weight    float64 `json:"weight"`    // 归一后的权重(%)，无数据时为0
+	Available bool    `json:"available"` // 是否有足够数据参与评分
+	Detail    string  `json:"detail"`    // 评分说明
+}
+
+// DailyDietScore 单日饮食评分
+type DailyDietScore struct {
+	Date       string               `json:"date"`
+	Logged     bool                 `json:"logged"` // 当天是否有饮食记录，未记录时不评分
+	Score      float64              `json:"score"`  // 综合得分(0-100)
+	Grade      string               `json:"grade"`  // 等级 A-E
+	Components []DietScoreComponent `json:"components,omitempty"`
+}
+
+// DietScoreHistory 饮食评分历史
+type DietScoreHistory struct {
+	StartDate    string           `json:"start_date"`
+	EndDate      string           `json:"end_date"`
+	LoggedDays   int              `json:"logged_days"`
+	AverageScore float64          `json:"average_score"` // 有记录日期的平均得分
+	AverageGrade string           `json:"average_grade"`
+	Days         []DailyDietScore `json:"days"` // 按日期升序
+}
+
+// DietScoreHistoryRequest 饮食评分历史查询请求
+type DietScoreHistoryRequest struct {
+	StartDate string `form:"start_date"` // 格式: 2023-04-01
+	EndDate   string `form:"end_date"`   // 格式: 2023-04-30，不传日期时默认最近30天
+}
+
+// DietScoreService 饮食质量评分服务，按热量、营养素均衡、蛋白质、多样性和微量营养素为每天打分
+type DietScoreService struct {
+	nutritionDAO       *repositories.DailyNutritionDAO
+	entryDAO           *repositories.NutritionEntryDAO
+	foodRecognitionDAO *repositories.FoodRecognitionDAO
+	settingService     *UserSettingService
+	nutritionCfg       *config.NutritionConfig
+}
+
+// NewDietScoreService 创建饮食评分服务实例
+func NewDietScoreService(
+	nutritionDAO *repositories.DailyNutritionDAO,
+	entryDAO *repositories.NutritionEntryDAO,
+	foodRecognitionDAO *repositories.FoodRecognitionDAO,
+	settingService *UserSettingService,
+	nutritionCfg *config.NutritionConfig,
+) *DietScoreService {
+	return &DietScoreService{
+		nutritionDAO:       nutritionDAO,
+		entryDAO:           entryDAO,
+		foodRecognitionDAO: foodRecognitionDAO,
+		settingService:     settingService,
+		nutritionCfg:       nutritionCfg,
+	}
+}
+
+// GetDailyScore 获取指定日期的饮食评分，dateStr 为空时为用户时区的今天
+func (s *DietScoreService) GetDailyScore(userID int64, dateStr string) (*DailyDietScore, error) {
+	date := s.settingService.Today(userID)
+	if dateStr != "" {
+		var err error
+		if date, err = time.Parse(reportDateLayout, dateStr); err != nil {
+			return nil, fmt.Errorf("%w: 日期格式错误，请使用 YYYY-MM-DD 格式", ErrInvalidScoreRange)
+		}
+	}
+
+	history, err := s.scoreRange(userID, date, date)
+	if err != nil {
+		return nil, err
+	}
+	return &history.Days[0], nil
+}
+
+// GetScoreHistory 获取日期范围内每天的饮食评分
+func (s *DietScoreService) GetScoreHistory(userID int64, req *DietScoreHistoryRequest) (*DietScoreHistory, error) {
+	var startDate, endDate time.Time
+	if req.StartDate == "" && req.EndDate == "" {
+		endDate = s.settingService.Today(userID)
+		startDate = endDate.AddDate(0, 0, -(dietScoreDefaultDays - 1))
+	} else {
+		var err error
+		if startDate, err = time.Parse(reportDateLayout, req.StartDate); err != nil {
+			return nil, fmt.Errorf("%w: 开始日期格式错误", ErrInvalidScoreRange)
+		}
+		if endDate, err = time.Parse(reportDateLayout, req.EndDate); err != nil {
+			return nil, fmt.Errorf("%w: 结束日期格式错误", ErrInvalidScoreRange)
+		}
+	}
+
+	if endDate.Before(startDate) {
+		return nil, fmt.Errorf("%w: 结束日期不能早于开始日期", ErrInvalidScoreRange)
+	}
+	if int(endDate.Sub(startDate).Hours()/24)+1 > dietScoreMaxRangeDays {
+		return nil, fmt.Errorf("%w: 日期范围不能超过%d天", ErrInvalidScoreRange, dietScoreMaxRangeDays)
+	}
+
+	return s.scoreRange(userID, startDate, endDate)
+}
+
+// scoreRange 计算日期范围内每天的饮食评分
+func (s *DietScoreService) scoreRange(userID int64, startDate, endDate time.Time) (*DietScoreHistory, error) {
+	records, err := s.nutritionDAO.GetHistory(userID, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+	recordsByDate := make(map[string]*models.DailyNutrition, len(records))
+	for i := range records {
+		recordsByDate[records[i].Date.Format(reportDateLayout)] = &records[i]
+	}
+
+	foods, err := s.distinctFoodsByDate(userID, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+
+	history := &DietScoreHistory{
+		StartDate: startDate.Format(reportDateLayout),
+		EndDate:   endDate.Format(reportDateLayout),
+		Days:      make([]DailyDietScore, 0, int(endDate.Sub(startDate).Hours()/24)+1),
+	}
+	var total float64
+	for date := startDate; !date.After(endDate); date = date.AddDate(0, 0, 1) {
+		key := date.Format(reportDateLayout)
+		day := DailyDietScore{Date: key}
+		if record, ok := recordsByDate[key]; ok && isLogged(record) {
+			day = s.scoreDay(record, len(foods[key]))
+			day.Date = key
+			history.LoggedDays++
+			total += day.Score
+		}
+		history.Days = append(history.Days, day)
+	}
+
+	if history.LoggedDays > 0 {
+		history.AverageScore = roundTo(total/float64(history.LoggedDays), 1)
+		history.AverageGrade = dietScoreGrade(history.AverageScore)
+	}
+	return history, nil
+}
+
+// distinctFoodsByDate 按日期统计饮食记录和已采用的识别记录中出现的不同食物
+func (s *DietScoreService) distinctFoodsByDate(userID int64, startDate, endDate time.Time) (map[string]map[string]bool, error) {
+	foods := make(map[string]map[string]bool)
+	add := func(date, name string) {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			return
+		}
+		if foods[date] == nil {
+			foods[date] = make(map[string]bool)
+		}
+		foods[date][name] = true
+	}
+
+	entries, err := s.entryDAO.ListByDateRange(userID, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		add(entry.Date.Format(reportDateLayout), entry.FoodName)
+	}
+
+	recognitions, err := s.foodRecognitionDAO.GetUserAdoptedRecognitionsByDateRange(userID, startDate, endDate)
+	if err != nil {
+		log.Printf("[饮食评分] 用户(ID:%d)获取识别记录失败，多样性仅按饮食记录计算: %v", userID, err)
+		return foods, nil
+	}
+	for date, dayRecognitions := range recognitions {
+		for i := range dayRecognitions {
+			for _, food := range splitRecognitionFoods(&dayRecognitions[i]) {
+				add(date, food.FoodName)
+			}
+		}
+	}
+	return foods, nil
+}
+
+// scoreDay 计算单日饮食评分，foodCount 为当天吃到的不同食物数量
+func (s *DietScoreService) scoreDay(record *models.DailyNutrition, foodCount int) DailyDietScore {
+	components := map[string]DietScoreComponent{
+		DietScoreCalories:       scoreCalories(record),
+		DietScoreMacroBalance:   scoreMacroBalance(record),
+		DietScoreProtein:        scoreProtein(record),
+		DietScoreVariety:        scoreVariety(foodCount),
+		DietScoreMicronutrients: scoreMicronutrients(record, s.nutritionCfg.GetMicronutrients()),
+	}
+
+	var totalWeight float64
+	for _, w := range dietScoreWeights {
+		if components[w.Key].Available {
+			totalWeight += w.Weight
+		}
+	}
+
+	day := DailyDietScore{Logged: true, Components: make([]DietScoreComponent, 0, len(dietScoreWeights))}
+	for _, w := range dietScoreWeights {
+		component := components[w.Key]
+		component.Key, component.Name = w.Key, w.Name
+		component.Score = roundTo(component.Score, 1)
+		if component.Available && totalWeight > 0 {
+			component.Weight = roundTo(w.Weight/totalWeight*100, 1)
+			day.Score += component.Score * w.Weight / totalWeight
+		}
+		day.Components = append(day.Components, component)
+	}
+	day.Score = roundTo(day.Score, 1)
+	day.Grade = dietScoreGrade(day.Score)
+	return day
+}
+
+// scoreCalories 热量达标：偏差在±10%内满分，偏差越大得分越低
+func scoreCalories(record *models.DailyNutrition) DietScoreComponent {
+	if record.TargetCalories <= 0 {
+		return DietScoreComponent{Detail: "缺少热量目标"}
+	}
+	deviation := math.Abs(record.CaloriesIntake-record.TargetCalories) / record.TargetCalories
+	return DietScoreComponent{
+		Available: true,
+		Score:     linearScore(deviation, calorieFullScoreDeviation, calorieZeroScoreDeviation),
+		Detail:    fmt.Sprintf("摄入%.0f千卡，目标%.0f千卡，偏差%.0f%%", record.CaloriesIntake, record.TargetCalories, deviation*100),
+	}
+}
+
+// scoreMacroBalance 营养素均衡：比较蛋白质、碳水、脂肪的实际供能比与目标供能比
+func scoreMacroBalance(record *models.DailyNutrition) DietScoreComponent {
+	actual := macroEnergyShares(record.ProteinIntakeG, record.CarbIntakeG, record.FatIntakeG)
+	target := macroEnergyShares(record.TargetProteinG, record.TargetCarbG, record.TargetFatG)
+	if actual == nil || target == nil {
+		return DietScoreComponent{Detail: "缺少宏量营养素数据"}
+	}
+
+	var deviation float64
+	for i := range actual {
+		deviation += math.Abs(actual[i]-target[i]) * 100
+	}
+	deviation /= float64(len(actual))
+	return DietScoreComponent{
+		Available: true,
+		Score:     linearScore(deviation, 0, macroZeroScoreDeviation),
+		Detail: fmt.Sprintf("供能比 蛋白质%.0f%%/碳水%.0f%%/脂肪%.0f%%，目标 %.0f%%/%.0f%%/%.0f%%",
+			actual[0]*100, actual[1]*100, actual[2]*100, target[0]*100, target[1]*100, target[2]*100),
+	}
+}
+
+// scoreProtein 蛋白质充足：达到目标即满分，不足时按完成比例计分
+func scoreProtein(record *models.DailyNutrition) DietScoreComponent {
+	if record.TargetProteinG <= 0 {
+		return DietScoreComponent{Detail: "缺少蛋白质目标"}
+	}
+	ratio := math.Min(record.ProteinIntakeG/record.TargetProteinG, 1)
+	return DietScoreComponent{
+		Available: true,
+		Score:     ratio * 100,
+		Detail:    fmt.Sprintf("摄入%.0f克，目标%.0f克", record.ProteinIntakeG, record.TargetProteinG),
+	}
+}
+
+// scoreVariety 食物多样性：按当天吃到的不同食物数量计分
+func scoreVariety(foodCount int) DietScoreComponent {
+	if foodCount == 0 {
+		return DietScoreComponent{Detail: "没有食物明细记录"}
+	}
+	return DietScoreComponent{
+		Available: true,
+		Score:     math.Min(float64(foodCount)/varietyFullScoreFoods, 1) * 100,
+		Detail:    fmt.Sprintf("吃到%d种不同食物，%d种及以上满分", foodCount, varietyFullScoreFoods),
+	}
+}
+
+// scoreMicronutrients 微量营养素：min 类按达到目标的比例计分，max 类不超过限值满分、超出越多得分越低
+// 只统计当天有摄入数据且有目标值的营养素
+func scoreMicronutrients(record *models.DailyNutrition, micronutrients []config.MicronutrientConfig) DietScoreComponent {
+	var total float64
+	var counted int
+	var exceeded []string
+	for _, m := range micronutrients {
+		target := record.MicronutrientTargets[m.Key]
+		intake, ok := record.MicronutrientIntake[m.Key]
+		if !ok || target <= 0 {
+			continue
+		}
+
+		counted++
+		if m.LimitType == "max" {
+			over := (intake - target) / target
+			total += linearScore(over, 0, micronutrientMaxOverLimit)
+			if over > 0 {
+				exceeded = append(exceeded, m.Name)
+			}
+		} else {
+			total += math.Min(intake/target, 1) * 100
+		}
+	}
+	if counted == 0 {
+		return DietScoreComponent{Detail: "没有微量营养素数据"}
+	}
+
+	detail := fmt.Sprintf("统计%d项微量营养素", counted)
+	if len(exceeded) > 0 {
+		detail += "，超出限值：" + strings.Join(exceeded, "、")
+	}
+	return DietScoreComponent{
+		Available: true,
+		Score:     total / float64(counted),
+		Detail:    detail,
+	}
+}
+
+// macroEnergyShares 计算蛋白质、碳水、脂肪的供能比例，总热量为0时返回nil
+func macroEnergyShares(proteinG, carbG, fatG float64) []float64 {
+	energy := []float64{proteinG * 4, carbG * 4, fatG * 9}
+	total := energy[0] + energy[1] + energy[2]
+	if total <= 0 {
+		return nil
+	}
+	for i := range energy {
+		energy[i] /= total
+	}
+	return energy
+}
+
+// linearScore 偏差不超过 full 时得100分，达到 zero 时得0分，其间线性递减
+func linearScore(deviation, full, zero float64) float64 {
+	switch {
+	case deviation <= full:
+		return 100
+	case deviation >= zero:
+		return 0
+	default:
+		return (zero - deviation) / (zero - full) * 100
+	}
+}
+
+// dietScoreGrade 将得分换算为等级：A≥85，B≥70，C≥55，D≥40，其余为E
+func dietScoreGrade(score float64) string {
+	switch {
+	case score >= 85:
+		return "A"
+	case score >= 70:
+		return "B"
+	case score >= 55:
+		return "C"
+	case score >= 40:
+		return "D"
+	default:
+		return "E"
+	}
+}
@@ -11,13 +11,15 @@ import (
 
 // ExerciseService 运动服务
 type ExerciseService struct {
-	exerciseDAO *repositories.UserExerciseDAO
+	exerciseDAO    *repositories.UserExerciseDAO
+	settingService *UserSettingService
 }
 
 // NewExerciseService 创建运动服务实例
-func NewExerciseService(exerciseDAO *repositories.UserExerciseDAO) *ExerciseService {
+func NewExerciseService(exerciseDAO *repositories.UserExerciseDAO, settingService *UserSettingService) *ExerciseService {
 	return &ExerciseService{
-		exerciseDAO: exerciseDAO,
+		exerciseDAO:    exerciseDAO,
+		settingService: settingService,
 	}
 }
 
@@ -88,17 +90,17 @@ func (s *ExerciseService) GetExercise(userID, exerciseID int64) (*models.UserExe
 // GetExerciseHistory 获取运动历史记录
 func (s *ExerciseService) GetExerciseHistory(userID int64, req *ExerciseHistoryRequest) ([]models.UserExercise, error) {
 	// 解析日期
-	startDate, err := time.Parse("2006-01-02", req.StartDate)
+	startDate, err := s.settingService.ParseDate(userID, req.StartDate)
 	if err != nil {
 		return nil, errors.New("开始日期格式错误，请使用 YYYY-MM-DD 格式")
 	}
 
-	endDate, err := time.Parse("2006-01-02", req.EndDate)
+	endDate, err := s.settingService.ParseDate(userID, req.EndDate)
 	if err != nil {
 		return nil, errors.New("结束日期格式错误，请使用 YYYY-MM-DD 格式")
 	}
 
-	// 将结束日期设置为当天的最后一秒
+	// 按用户时区将结束日期设置为当天的最后一秒
 	endDate = endDate.Add(23*time.Hour + 59*time.Minute + 59*time.Second)
 
 	return s.exerciseDAO.GetHistory(userID, startDate, endDate, req.Limit)
@@ -106,7 +108,7 @@ func (s *ExerciseService) GetExerciseHistory(userID int64, req *ExerciseHistoryR
 
 // GetTodayExercises 获取今日运动记录
 func (s *ExerciseService) GetTodayExercises(userID int64) ([]models.UserExercise, error) {
-	return s.exerciseDAO.GetTodayExercises(userID)
+	return s.exerciseDAO.GetTodayExercises(userID, s.settingService.Now(userID))
 }
 
 // UpdateExercise 更新运动记录
@@ -158,17 +160,17 @@ func (s *ExerciseService) DeleteExercise(userID, exerciseID int64) error {
 // GetExerciseStatistics 获取运动统计数据
 func (s *ExerciseService) GetExerciseStatistics(userID int64, startDate, endDate string) (map[string]interface{}, error) {
 	// 解析日期
-	start, err := time.Parse("2006-01-02", startDate)
+	start, err := s.settingService.ParseDate(userID, startDate)
 	if err != nil {
 		return nil, errors.New("开始日期格式错误，请使用 YYYY-MM-DD 格式")
 	}
 
-	end, err := time.Parse("2006-01-02", endDate)
+	end, err := s.settingService.ParseDate(userID, endDate)
 	if err != nil {
 		return nil, errors.New("结束日期格式错误，请使用 YYYY-MM-DD 格式")
 	}
 
-	// 将结束日期设置为当天的最后一秒
+	// 按用户时区将结束日期设置为当天的最后一秒
 	end = end.Add(23*time.Hour + 59*time.Minute + 59*time.Second)
 
 	return s.exerciseDAO.GetStatistics(userID, start, end)
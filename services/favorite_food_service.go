@@ -0,0 +1,316 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"ome-app-back/models"
+	"ome-app-back/models/constant"
+	"ome-app-back/repositories"
+)
+
+// 常吃食物统计参数
+const (
+	frequentFoodLookbackDays = 90 // 统计最近多少天的记录
+	frequentFoodHalfLifeDays = 14 // 记录权重减半所需天数，越近的记录权重越高
+	frequentFoodDefaultLimit = 20
+	frequentFoodMaxLimit     = 50
+)
+
+// FavoriteFoodService 收藏食物与常吃食物服务，用于快捷记录饮食
+type FavoriteFoodService struct {
+	favoriteDAO        *repositories.FavoriteFoodDAO
+	entryDAO           *repositories.NutritionEntryDAO
+	foodRecognitionDAO *repositories.FoodRecognitionDAO
+	nutritionService   *NutritionService
+	settingService     *UserSettingService
+}
+
+// NewFavoriteFoodService 创建收藏食物服务实例
+func NewFavoriteFoodService(
+	favoriteDAO *repositories.FavoriteFoodDAO,
+	entryDAO *repositories.NutritionEntryDAO,
+	foodRecognitionDAO *repositories.FoodRecognitionDAO,
+	nutritionService *NutritionService,
+	settingService *UserSettingService,
+) *FavoriteFoodService {
+	return &FavoriteFoodService{
+		favoriteDAO:        favoriteDAO,
+		entryDAO:           entryDAO,
+		foodRecognitionDAO: foodRecognitionDAO,
+		nutritionService:   nutritionService,
+		settingService:     settingService,
+	}
+}
+
+// SaveFavoriteFoodRequest 收藏食物请求，传 entry_id 时从已有饮食记录收藏，否则使用请求中的营养数值
+type SaveFavoriteFoodRequest struct {
+	EntryID        int64                 `json:"entry_id"`
+	FoodName       string                `json:"food_name" binding:"required_without=EntryID,max=100"`
+	Quantity       string                `json:"quantity" binding:"max=50"`
+	MealType       string                `json:"meal_type" binding:"omitempty,oneof=breakfast lunch dinner snack"`
+	CaloriesIntake float64               `json:"calories_intake" binding:"gte=0"`
+	ProteinIntakeG float64               `json:"protein_intake_g" binding:"gte=0"`
+	CarbIntakeG    float64               `json:"carb_intake_g" binding:"gte=0"`
+	FatIntakeG     float64               `json:"fat_intake_g" binding:"gte=0"`
+	Micronutrients models.Micronutrients `json:"micronutrients"`
+}
+
+// LogFavoriteFoodRequest 按收藏记录饮食请求
+type LogFavoriteFoodRequest struct {
+	Date     string  `json:"date"`                                                             // 格式: 2023-12-01，为空时为今天
+	MealType string  `json:"meal_type" binding:"omitempty,oneof=breakfast lunch dinner snack"` // 为空时使用收藏的默认餐次
+	Servings float64 `json:"servings" binding:"omitempty,gt=0"`                                // 份数，默认1份
+}
+
+// FrequentFoodRequest 常吃食物查询请求
+type FrequentFoodRequest struct {
+	MealType string `form:"meal_type" binding:"omitempty,oneof=breakfast lunch dinner snack"` // 只统计该餐次的记录
+	Limit    int    `form:"limit"`                                                            // 默认20，最多50
+}
+
+// SaveFavorite 收藏食物，同名同份量的收藏已存在时更新
+func (s *FavoriteFoodService) SaveFavorite(userID int64, req *SaveFavoriteFoodRequest) (*models.FavoriteFood, error) {
+	favorite := &models.FavoriteFood{
+		UserID:         userID,
+		FoodName:       strings.TrimSpace(req.FoodName),
+		Quantity:       strings.TrimSpace(req.Quantity),
+		MealType:       req.MealType,
+		CaloriesIntake: req.CaloriesIntake,
+		ProteinIntakeG: req.ProteinIntakeG,
+		CarbIntakeG:    req.CarbIntakeG,
+		FatIntakeG:     req.FatIntakeG,
+		Micronutrients: req.Micronutrients,
+	}
+
+	if req.EntryID > 0 {
+		entry, err := s.entryDAO.GetByID(userID, req.EntryID)
+		if err != nil {
+			return nil, err
+		}
+		favorite.FoodName = entry.FoodName
+		favorite.Quantity = entry.Quantity
+		favorite.CaloriesIntake = entry.CaloriesIntake
+		favorite.ProteinIntakeG = entry.ProteinIntakeG
+		favorite.CarbIntakeG = entry.CarbIntakeG
+		favorite.FatIntakeG = entry.FatIntakeG
+		favorite.Micronutrients = entry.Micronutrients
+		if favorite.MealType == "" {
+			favorite.MealType = entry.MealType
+		}
+	}
+	if favorite.FoodName == "" {
+		return nil, errors.New("食物名称不能为空")
+	}
+
+	if err := s.favoriteDAO.Save(favorite); err != nil {
+		log.Printf("[收藏食物] 用户(ID:%d)收藏%s失败: %v", userID, favorite.FoodName, err)
+		return nil, err
+	}
+	return favorite, nil
+}
+
+// GetFavorites 获取用户的收藏食物列表
+func (s *FavoriteFoodService) GetFavorites(userID int64) ([]models.FavoriteFood, error) {
+	return s.favoriteDAO.ListByUser(userID)
+}
+
+// DeleteFavorite 取消收藏
+func (s *FavoriteFoodService) DeleteFavorite(userID, favoriteID int64) error {
+	return s.favoriteDAO.Delete(userID, favoriteID)
+}
+
+// LogFavorite 按份数将收藏食物记录到指定日期的饮食记录
+func (s *FavoriteFoodService) LogFavorite(userID, favoriteID int64, req *LogFavoriteFoodRequest) (*NutritionEntryResponse, error) {
+	favorite, err := s.favoriteDAO.GetByID(userID, favoriteID)
+	if err != nil {
+		return nil, err
+	}
+
+	mealType := req.MealType
+	if mealType == "" {
+		mealType = favorite.MealType
+	}
+	if mealType == "" {
+		return nil, errors.New("请指定餐次")
+	}
+
+	dateStr := req.Date
+	if dateStr == "" {
+		dateStr = s.settingService.Today(userID).Format("2006-01-02")
+	}
+
+	servings := req.Servings
+	if servings <= 0 {
+		servings = 1
+	}
+	quantity := favorite.Quantity
+	if servings != 1 {
+		quantity = strings.TrimSpace(quantity + " ×" + strconv.FormatFloat(servings, 'f', -1, 64))
+	}
+
+	entryReq := &AddNutritionEntryRequest{
+		MealType:       mealType,
+		FoodName:       favorite.FoodName,
+		Quantity:       quantity,
+		CaloriesIntake: favorite.CaloriesIntake * servings,
+		ProteinIntakeG: favorite.ProteinIntakeG * servings,
+		CarbIntakeG:    favorite.CarbIntakeG * servings,
+		FatIntakeG:     favorite.FatIntakeG * servings,
+		Micronutrients: favorite.Micronutrients.Scale(servings),
+	}
+	return s.nutritionService.AddSourcedEntry(userID, dateStr, entryReq, constant.EntrySourceFavorite, favorite.ID)
+}
+
+// frequentFoodStat 常吃食物统计中间结果
+type frequentFoodStat struct {
+	food        models.FrequentFood
+	lastDate    int64 // 最近一次记录的日期与创建时间，用于选取最新的份量与营养数值
+	lastCreated int64
+	mealCounts  map[string]int
+}
+
+// GetFrequentFoods 根据最近的饮食记录和已采用的食物识别记录统计常吃食物，按频率与新近程度综合排序
+func (s *FavoriteFoodService) GetFrequentFoods(userID int64, req *FrequentFoodRequest) ([]models.FrequentFood, error) {
+	mealType, limit := req.MealType, req.Limit
+	if limit <= 0 {
+		limit = frequentFoodDefaultLimit
+	}
+	if limit > frequentFoodMaxLimit {
+		limit = frequentFoodMaxLimit
+	}
+
+	loc := s.settingService.Location(userID)
+	today := s.settingService.Today(userID)
+	startDate := today.AddDate(0, 0, -frequentFoodLookbackDays)
+
+	stats := make(map[string]*frequentFoodStat)
+	record := func(food models.FrequentFood, meal string, date, createdAt int64) {
+		if mealType != "" && meal != mealType {
+			return
+		}
+		key := strings.ToLower(strings.TrimSpace(food.FoodName))
+		if key == "" {
+			return
+		}
+
+		stat, ok := stats[key]
+		if !ok {
+			stat = &frequentFoodStat{mealCounts: make(map[string]int)}
+			stats[key] = stat
+		}
+
+		daysAgo := float64(today.Unix()-date) / 86400
+		stat.food.Score += math.Pow(0.5, daysAgo/frequentFoodHalfLifeDays)
+		stat.food.Count++
+		stat.mealCounts[meal]++
+
+		if !ok || date > stat.lastDate || (date == stat.lastDate && createdAt > stat.lastCreated) {
+			score, count := stat.food.Score, stat.food.Count
+			stat.food = food
+			stat.food.Score, stat.food.Count = score, count
+			stat.lastDate, stat.lastCreated = date, createdAt
+		}
+	}
+
+	entries, err := s.entryDAO.ListByDateRange(userID, startDate, today)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		// 采用识别结果产生的条目由识别记录统计，避免重复计数
+		if entry.Source == constant.EntrySourceRecognition {
+			continue
+		}
+		record(models.FrequentFood{
+			FoodName:       entry.FoodName,
+			Quantity:       entry.Quantity,
+			CaloriesIntake: entry.CaloriesIntake,
+			ProteinIntakeG: entry.ProteinIntakeG,
+			CarbIntakeG:    entry.CarbIntakeG,
+			FatIntakeG:     entry.FatIntakeG,
+			Micronutrients: entry.Micronutrients,
+			LastLoggedDate: entry.Date.Format("2006-01-02"),
+		}, entry.MealType, entry.Date.Unix(), entry.CreatedAt.Unix())
+	}
+
+	recognitions, err := s.foodRecognitionDAO.GetUserAdoptedRecognitionsByDateRange(userID, startDate, today)
+	if err != nil {
+		return nil, err
+	}
+	for _, dayRecognitions := range recognitions {
+		for _, recognition := range dayRecognitions {
+			// 识别记录没有餐次，按识别时间推断
+			meal := currentMealSlot(recognition.CreatedAt.In(loc))
+			for _, food := range splitRecognitionFoods(&recognition) {
+				record(food, meal, recognition.RecordDate.Unix(), recognition.CreatedAt.Unix())
+			}
+		}
+	}
+
+	favorites, err := s.favoriteDAO.ListByUser(userID)
+	if err != nil {
+		log.Printf("[收藏食物] 用户(ID:%d)获取收藏列表失败: %v", userID, err)
+	}
+	favoriteNames := make(map[string]bool, len(favorites))
+	for _, favorite := range favorites {
+		favoriteNames[strings.ToLower(strings.TrimSpace(favorite.FoodName))] = true
+	}
+
+	result := make([]models.FrequentFood, 0, len(stats))
+	for key, stat := range stats {
+		food := stat.food
+		food.MealType = mostFrequentMeal(stat.mealCounts)
+		food.Score = roundTo(food.Score, 3)
+		food.IsFavorite = favoriteNames[key]
+		result = append(result, food)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Score != result[j].Score {
+			return result[i].Score > result[j].Score
+		}
+		return result[i].LastLoggedDate > result[j].LastLoggedDate
+	})
+	if len(result) > limit {
+		result = result[:limit]
+	}
+	return result, nil
+}
+
+// splitRecognitionFoods 将识别记录拆分为单个食物，食物缺少的营养数值按各食物热量占比分摊总量
+func splitRecognitionFoods(recognition *models.FoodRecognition) []models.FrequentFood {
+	items := recognition.CurrentFoods()
+	if len(items) == 0 {
+		return nil
+	}
+
+	foods := make([]models.FrequentFood, 0, len(items))
+	for _, item := range resolveRecognitionItems(recognition, items) {
+		foods = append(foods, models.FrequentFood{
+			FoodName:       item.Name,
+			Quantity:       item.Quantity,
+			CaloriesIntake: item.Calories,
+			ProteinIntakeG: item.ProteinG,
+			CarbIntakeG:    item.CarbG,
+			FatIntakeG:     item.FatG,
+			Micronutrients: item.Micronutrients,
+			LastLoggedDate: recognition.RecordDate.Format("2006-01-02"),
+		})
+	}
+	return foods
+}
+
+// mostFrequentMeal 返回记录次数最多的餐次，次数相同时按餐次顺序取靠前的
+func mostFrequentMeal(mealCounts map[string]int) string {
+	best, bestCount := "", 0
+	for _, mealType := range constant.MealTypes {
+		if mealCounts[mealType] > bestCount {
+			best, bestCount = mealType, mealCounts[mealType]
+		}
+	}
+	return best
+}
@@ -18,6 +18,7 @@ import (
 type FileService struct {
 	uploadDir string
 	maxSize   int64
+	imageCfg  *config.ImageConfig
 }
 
 // NewFileService 创建文件服务实例
@@ -33,49 +34,90 @@ func NewFileService(cfg *config.UploadConfig) *FileService {
 	return &FileService{
 		uploadDir: uploadDir,
 		maxSize:   cfg.MaxSize,
+		imageCfg:  &cfg.Image,
 	}
 }
 
-// UploadImage 上传图片文件
-func (s *FileService) UploadImage(file *multipart.FileHeader, userID int64) (string, error) {
+// UploadImage 上传图片文件并预处理：按内容识别真实格式，原图清除GPS信息后保留，
+// 另存一份摆正方向、缩放并压缩后的JPEG用于识别和展示
+func (s *FileService) UploadImage(file *multipart.FileHeader, userID int64) (*ProcessedImage, error) {
 	// 检查文件大小
 	if file.Size > s.maxSize {
-		return "", fmt.Errorf("文件过大：%d 字节，最大允许 %d 字节", file.Size, s.maxSize)
+		return nil, fmt.Errorf("文件过大：%d 字节，最大允许 %d 字节", file.Size, s.maxSize)
 	}
 
-	// 创建用户目录
-	userDir := filepath.Join(s.uploadDir, fmt.Sprintf("user_%d", userID))
-	if err := os.MkdirAll(userDir, 0755); err != nil {
-		return "", fmt.Errorf("创建用户目录失败: %v", err)
-	}
-
-	// 生成唯一文件名
-	ext := filepath.Ext(file.Filename)
-	filename := fmt.Sprintf("%d_%s%s", time.Now().UnixNano(), randomString(8), ext)
-	fullPath := filepath.Join(userDir, filename)
-
-	// 打开上传的文件
+	// 读取上传的文件
 	src, err := file.Open()
 	if err != nil {
-		return "", fmt.Errorf("打开上传文件失败: %v", err)
+		return nil, fmt.Errorf("打开上传文件失败: %v", err)
 	}
 	defer src.Close()
 
-	// 创建目标文件
-	dst, err := os.Create(fullPath)
+	data, err := io.ReadAll(io.LimitReader(src, s.maxSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("读取上传文件失败: %v", err)
+	}
+	if int64(len(data)) > s.maxSize {
+		return nil, fmt.Errorf("文件过大：最大允许 %d 字节", s.maxSize)
+	}
+
+	// 按文件内容识别格式，不信任客户端提供的扩展名
+	mimeType := detectImageMIME(data)
+	if mimeType == "" {
+		return nil, ErrInvalidImage
+	}
+
+	img, err := s.decodeImage(data, mimeType)
+	if err != nil {
+		return nil, err
+	}
+	processed, width, height, err := s.resizeAndEncode(img)
 	if err != nil {
-		return "", fmt.Errorf("创建目标文件失败: %v", err)
+		return nil, err
 	}
-	defer dst.Close()
 
-	// 复制文件内容
-	if _, err = io.Copy(dst, src); err != nil {
-		return "", fmt.Errorf("复制文件内容失败: %v", err)
+	// 创建用户目录及原图目录
+	userDirName := fmt.Sprintf("user_%d", userID)
+	originalDir := filepath.Join(s.uploadDir, userDirName, "original")
+	if err := os.MkdirAll(originalDir, 0755); err != nil {
+		return nil, fmt.Errorf("创建用户目录失败: %v", err)
+	}
+
+	// 原图清除定位等元数据后保存；无法原样清除的格式（如HEIC）改为保存不含元数据的全尺寸JPEG
+	originalMIME := mimeType
+	original, ok := stripImageMetadata(data, mimeType)
+	if !ok {
+		b := img.Bounds()
+		original, err = encodeJPEG(img, b.Dx(), b.Dy(), originalJPEGQuality)
+		if err != nil {
+			return nil, err
+		}
+		originalMIME = mimeJPEG
+	}
+
+	// 生成唯一文件名，原图使用保存内容的真实扩展名
+	baseName := fmt.Sprintf("%d_%s", time.Now().UnixNano(), randomString(8))
+	originalName := baseName + imageExtensions[originalMIME]
+	processedName := baseName + ".jpg"
+
+	if err := os.WriteFile(filepath.Join(originalDir, originalName), original, 0644); err != nil {
+		return nil, fmt.Errorf("保存原图失败: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(s.uploadDir, userDirName, processedName), processed, 0644); err != nil {
+		os.Remove(filepath.Join(originalDir, originalName))
+		return nil, fmt.Errorf("保存处理后图片失败: %v", err)
 	}
 
 	// 返回相对路径
-	relativePath := filepath.Join("uploads", fmt.Sprintf("user_%d", userID), filename)
-	return relativePath, nil
+	return &ProcessedImage{
+		Path:         filepath.Join("uploads", userDirName, processedName),
+		OriginalPath: filepath.Join("uploads", userDirName, "original", originalName),
+		OriginalMIME: originalMIME,
+		MIMEType:     mimeJPEG,
+		Width:        width,
+		Height:       height,
+		Hash:         perceptualHash(img),
+	}, nil
 }
 
 // GetImageBase64 读取图片并转换为Base64编码
@@ -158,6 +200,30 @@ func (s *FileService) GetFile(filePath string) ([]byte, string, error) {
 	return data, mimeType, nil
 }
 
+// GetPublicFile 获取公共文件，用户上传目录下的文件不公开，返回 ErrForbidden
+func (s *FileService) GetPublicFile(filePath string) ([]byte, string, error) {
+	cleaned, err := cleanUploadPath(filePath)
+	if err != nil {
+		return nil, "", err
+	}
+	if _, private := uploadOwner(cleaned); private {
+		return nil, "", ErrForbidden
+	}
+	return s.GetFile(cleaned)
+}
+
+// GetUserFile 获取用户自己上传的文件，文件不在该用户的上传目录下时返回 ErrForbidden
+func (s *FileService) GetUserFile(userID int64, filePath string) ([]byte, string, error) {
+	cleaned, err := cleanUploadPath(filePath)
+	if err != nil {
+		return nil, "", err
+	}
+	if ownerID, _ := uploadOwner(cleaned); ownerID != userID {
+		return nil, "", ErrForbidden
+	}
+	return s.GetFile(cleaned)
+}
+
 // getMimeType 根据文件扩展名确定MIME类型
 func getMimeType(ext string) string {
 	switch ext {
@@ -171,6 +237,8 @@ func getMimeType(ext string) string {
 		return "image/bmp"
 	case ".webp":
 		return "image/webp"
+	case ".heic":
+		return "image/heic"
 	case ".svg":
 		return "image/svg+xml"
 	case ".pdf":
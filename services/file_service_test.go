@@ -0,0 +1,77 @@
+package services
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"ome-app-back/config"
+)
+
+// writeTestUploads 在当前工作目录下创建测试用的上传文件
+func writeTestUploads(t *testing.T, paths ...string) {
+	t.Helper()
+	for _, p := range paths {
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatalf("创建目录失败: %v", err)
+		}
+		if err := os.WriteFile(p, []byte("data"), 0644); err != nil {
+			t.Fatalf("写入文件失败: %v", err)
+		}
+	}
+}
+
+func TestFileServiceGetUserFile(t *testing.T) {
+	chdirTemp(t)
+	writeTestUploads(t, "uploads/user_1/a.jpg", "uploads/user_12/b.jpg", "uploads/foods/c.png")
+	s := NewFileService(&config.UploadConfig{Dir: "uploads"})
+
+	tests := []struct {
+		path    string
+		wantErr error
+	}{
+		{"uploads/user_1/a.jpg", nil},
+		{"/uploads/user_1/a.jpg", nil},
+		{"uploads/user_12/b.jpg", ErrForbidden},
+		{"/uploads/user_12/b.jpg", ErrForbidden},
+		{"uploads/user_1/../user_12/b.jpg", ErrForbidden},
+		{"uploads/foods/c.png", ErrForbidden},
+		{"config/config.yaml", ErrForbidden},
+	}
+	for _, tt := range tests {
+		_, _, err := s.GetUserFile(1, tt.path)
+		if tt.wantErr == nil && err != nil {
+			t.Errorf("GetUserFile(1, %q) 返回错误: %v", tt.path, err)
+		}
+		if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+			t.Errorf("GetUserFile(1, %q) 应返回 %v，实际为 %v", tt.path, tt.wantErr, err)
+		}
+	}
+}
+
+func TestFileServiceGetPublicFile(t *testing.T) {
+	chdirTemp(t)
+	writeTestUploads(t, "uploads/user_1/a.jpg", "uploads/foods/c.png")
+	s := NewFileService(&config.UploadConfig{Dir: "uploads"})
+
+	tests := []struct {
+		path    string
+		wantErr error
+	}{
+		{"uploads/foods/c.png", nil},
+		{"uploads/user_1/a.jpg", ErrForbidden},
+		{"uploads/user_1/original/a.jpg", ErrForbidden},
+		{"uploads/foods/../user_1/a.jpg", ErrForbidden},
+		{"config/config.yaml", ErrForbidden},
+	}
+	for _, tt := range tests {
+		_, _, err := s.GetPublicFile(tt.path)
+		if tt.wantErr == nil && err != nil {
+			t.Errorf("GetPublicFile(%q) 返回错误: %v", tt.path, err)
+		}
+		if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+			t.Errorf("GetPublicFile(%q) 应返回 %v，实际为 %v", tt.path, tt.wantErr, err)
+		}
+	}
+}
@@ -0,0 +1,304 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+
+	"ome-app-back/models"
+)
+
+// AI结构化输出的错误类型，可通过 errors.Is 判断
+var (
+	ErrAIOutputNoJSON    = errors.New("AI响应中未找到JSON内容")
+	ErrAIOutputMalformed = errors.New("AI响应JSON格式错误")
+	ErrAIOutputInvalid   = errors.New("AI响应内容未通过校验")
+)
+
+// 识别结果校验参数
+const (
+	recognitionSumTolerance      = 0.15 // 各食物合计与总量允许的相对偏差
+	recognitionCalorieSlack      = 50.0 // 热量合计允许的最小绝对偏差(千卡)
+	recognitionMacroSlack        = 5.0  // 宏量营养素合计允许的最小绝对偏差(克)
+	maxRecognitionRepairAttempts = 1    // 解析失败后请求AI修复的次数
+)
+
+// codeFencePattern 匹配markdown代码块
+var codeFencePattern = regexp.MustCompile("(?s)```[a-zA-Z]*\\s*(.*?)```")
+
+// AIOutputError AI结构化输出解析或校验失败的详细错误
+type AIOutputError struct {
+	Kind     error    // ErrAIOutputNoJSON / ErrAIOutputMalformed / ErrAIOutputInvalid
+	Problems []string // 具体问题描述
+}
+
+func (e *AIOutputError) Error() string {
+	if len(e.Problems) == 0 {
+		return e.Kind.Error()
+	}
+	return fmt.Sprintf("%s: %s", e.Kind.Error(), strings.Join(e.Problems, "；"))
+}
+
+func (e *AIOutputError) Unwrap() error {
+	return e.Kind
+}
+
+// parseRecognitionOutput 从AI原始回复中提取JSON并解析、校验为识别结果
+// dedupe 为 true 时先合并重复的食物项（多图识别时同一份食物可能在不同图片中被重复列出）
+func parseRecognitionOutput(raw string, dedupe bool) (*AIAnalysisResult, error) {
+	content, err := extractJSONObject(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	var result AIAnalysisResult
+	if err := json.Unmarshal([]byte(content), &result); err != nil {
+		return nil, &AIOutputError{Kind: ErrAIOutputMalformed, Problems: []string{err.Error()}}
+	}
+	if dedupe {
+		var removed []models.RecognizedFoodItem
+		result.Foods, removed = dedupeRecognizedFoods(result.Foods)
+		if len(removed) > 0 {
+			recomputeDedupedNutrition(&result, removed)
+		}
+	}
+
+	if problems := validateRecognitionResult(&result); len(problems) > 0 {
+		return nil, &AIOutputError{Kind: ErrAIOutputInvalid, Problems: problems}
+	}
+	return &result, nil
+}
+
+// extractJSONObject 从文本中提取第一个完整的JSON对象
+// 支持markdown代码块包裹和前后附带说明文字，字符串内未转义的换行会被转义
+func extractJSONObject(text string) (string, error) {
+	text = strings.TrimSpace(text)
+	// 优先使用包含JSON对象的代码块
+	for _, match := range codeFencePattern.FindAllStringSubmatch(text, -1) {
+		if strings.Contains(match[1], "{") {
+			text = match[1]
+			break
+		}
+	}
+
+	start := strings.Index(text, "{")
+	if start < 0 {
+		return "", &AIOutputError{Kind: ErrAIOutputNoJSON}
+	}
+
+	var b strings.Builder
+	depth := 0
+	inString := false
+	escaped := false
+	for _, r := range text[start:] {
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case r == '\\':
+				escaped = true
+			case r == '"':
+				inString = false
+			case r == '\n':
+				b.WriteString(`\n`)
+				continue
+			case r == '\r':
+				continue
+			case r == '\t':
+				b.WriteString(`\t`)
+				continue
+			}
+			b.WriteRune(r)
+			continue
+		}
+
+		b.WriteRune(r)
+		switch r {
+		case '"':
+			inString = true
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return b.String(), nil
+			}
+		}
+	}
+
+	return "", &AIOutputError{Kind: ErrAIOutputMalformed, Problems: []string{"JSON对象不完整"}}
+}
+
+// validateRecognitionResult 校验识别结果：数值不能为负，置信度在0-1之间，各食物合计需与总量大致相符
+func validateRecognitionResult(result *AIAnalysisResult) []string {
+	problems := make([]string, 0)
+	if len(result.Foods) == 0 {
+		problems = append(problems, "foods不能为空")
+	}
+
+	var sumCalories, sumProtein, sumCarb, sumFat float64
+	macrosComplete := len(result.Foods) > 0
+	for i, food := range result.Foods {
+		field := fmt.Sprintf("foods[%d]", i)
+		if strings.TrimSpace(food.Name) == "" {
+			problems = append(problems, field+".name不能为空")
+		}
+		problems = appendNegativeProblems(problems, field, map[string]float64{
+			"calories":  food.Calories,
+			"weight_g":  food.WeightG,
+			"protein_g": food.ProteinG,
+			"carb_g":    food.CarbG,
+			"fat_g":     food.FatG,
+		})
+		problems = appendNegativeProblems(problems, field+".micronutrients", food.Micronutrients)
+		if food.Confidence < 0 || food.Confidence > 1 {
+			problems = append(problems, field+".confidence必须在0到1之间")
+		}
+
+		sumCalories += food.Calories
+		sumProtein += food.ProteinG
+		sumCarb += food.CarbG
+		sumFat += food.FatG
+		if food.ProteinG == 0 && food.CarbG == 0 && food.FatG == 0 {
+			macrosComplete = false
+		}
+	}
+
+	n := result.Nutrition
+	problems = appendNegativeProblems(problems, "nutrition", map[string]float64{
+		"calories_intake":  n.CaloriesIntake,
+		"protein_intake_g": n.ProteinIntakeG,
+		"carb_intake_g":    n.CarbIntakeG,
+		"fat_intake_g":     n.FatIntakeG,
+	})
+	problems = appendNegativeProblems(problems, "nutrition.micronutrients", n.Micronutrients)
+
+	if len(result.Foods) > 0 && !roughlyEqual(sumCalories, n.CaloriesIntake, recognitionCalorieSlack) {
+		problems = append(problems, fmt.Sprintf("各食物热量合计%.0f与calories_intake %.0f不符", sumCalories, n.CaloriesIntake))
+	}
+	// 仅当每种食物都给出了宏量营养素时才校验合计
+	if macrosComplete {
+		pairs := []struct {
+			name       string
+			sum, total float64
+		}{
+			{"protein_intake_g", sumProtein, n.ProteinIntakeG},
+			{"carb_intake_g", sumCarb, n.CarbIntakeG},
+			{"fat_intake_g", sumFat, n.FatIntakeG},
+		}
+		for _, p := range pairs {
+			if !roughlyEqual(p.sum, p.total, recognitionMacroSlack) {
+				problems = append(problems, fmt.Sprintf("各食物%s合计%.1f与总量%.1f不符", p.name, p.sum, p.total))
+			}
+		}
+	}
+
+	return problems
+}
+
+// dedupeRecognizedFoods 合并名称和重量都相同的食物项，保留第一项并合并其过敏原标记，返回去重后的食物与被合并掉的重复项
+// 同一份食物在不同图片中的数量描述可能写法不同（如"2个"与"两个"），因此按规范化名称加重量（取整到克）判断
+// 未给出重量时改按规范化的数量描述判断，数量描述也为空的食物项无法确认是否重复，不参与合并
+func dedupeRecognizedFoods(foods []models.RecognizedFoodItem) ([]models.RecognizedFoodItem, []models.RecognizedFoodItem) {
+	result := make([]models.RecognizedFoodItem, 0, len(foods))
+	var removed []models.RecognizedFoodItem
+	index := make(map[string]int)
+	for _, food := range foods {
+		var key string
+		switch quantity := normalizeFoodKey(food.Quantity); {
+		case food.WeightG > 0:
+			key = fmt.Sprintf("%s|%.0f", normalizeFoodKey(food.Name), food.WeightG)
+		case quantity != "":
+			key = normalizeFoodKey(food.Name) + "|quantity:" + quantity
+		default:
+			result = append(result, food)
+			continue
+		}
+		i, exists := index[key]
+		if !exists {
+			index[key] = len(result)
+			result = append(result, food)
+			continue
+		}
+		for _, allergen := range food.Allergens {
+			if !containsString(result[i].Allergens, allergen) {
+				result[i].Allergens = append(result[i].Allergens, allergen)
+			}
+		}
+		removed = append(removed, food)
+	}
+	return result, removed
+}
+
+// recomputeDedupedNutrition 合并重复食物项后重新计算总量，避免总量仍包含被合并掉的重复项
+// 热量按去重后的食物合计；宏量营养素在每种食物都给出时按合计，否则与微量营养素一样从总量中扣除重复项
+func recomputeDedupedNutrition(result *AIAnalysisResult, removed []models.RecognizedFoodItem) {
+	n := &result.Nutrition
+	var sumCalories, sumProtein, sumCarb, sumFat float64
+	macrosComplete := true
+	for _, food := range result.Foods {
+		sumCalories += food.Calories
+		sumProtein += food.ProteinG
+		sumCarb += food.CarbG
+		sumFat += food.FatG
+		if food.ProteinG == 0 && food.CarbG == 0 && food.FatG == 0 {
+			macrosComplete = false
+		}
+	}
+
+	n.CaloriesIntake = roundTo(sumCalories, 2)
+	if macrosComplete {
+		n.ProteinIntakeG = roundTo(sumProtein, 2)
+		n.CarbIntakeG = roundTo(sumCarb, 2)
+		n.FatIntakeG = roundTo(sumFat, 2)
+	}
+	for _, food := range removed {
+		if !macrosComplete {
+			n.ProteinIntakeG = roundTo(math.Max(0, n.ProteinIntakeG-food.ProteinG), 2)
+			n.CarbIntakeG = roundTo(math.Max(0, n.CarbIntakeG-food.CarbG), 2)
+			n.FatIntakeG = roundTo(math.Max(0, n.FatIntakeG-food.FatG), 2)
+		}
+		n.Micronutrients = n.Micronutrients.Subtract(food.Micronutrients)
+	}
+}
+
+// normalizeFoodKey 规范化食物名称/份量用于比较，忽略大小写与空白
+func normalizeFoodKey(s string) string {
+	return strings.ToLower(strings.Join(strings.Fields(s), ""))
+}
+
+// appendNegativeProblems 记录为负数的字段，按字段名排序保证输出稳定
+func appendNegativeProblems(problems []string, prefix string, values map[string]float64) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if values[key] < 0 {
+			problems = append(problems, fmt.Sprintf("%s.%s不能为负数", prefix, key))
+		}
+	}
+	return problems
+}
+
+// roughlyEqual 判断合计值与总量是否在允许偏差内
+func roughlyEqual(sum, total, slack float64) bool {
+	return math.Abs(sum-total) <= math.Max(total*recognitionSumTolerance, slack)
+}
+
+// outputProblems 取出错误中的具体问题描述，用于反馈给AI修复
+func outputProblems(err error) []string {
+	var outputErr *AIOutputError
+	if errors.As(err, &outputErr) {
+		if len(outputErr.Problems) > 0 {
+			return outputErr.Problems
+		}
+		return []string{outputErr.Kind.Error()}
+	}
+	return []string{err.Error()}
+}
@@ -0,0 +1,57 @@
+package services
+
+import "testing"
+
+func TestParseRecognitionOutputDedupe(t *testing.T) {
+	tests := []struct {
+		name         string
+		raw          string
+		wantFoods    int
+		wantCalories float64
+	}{
+		{
+			name: "重量相同时合并",
+			raw: `{"foods":[{"name":"米饭","quantity":"1碗","weight_g":150,"calories":174},
+				{"name":"米饭 ","quantity":"一碗","weight_g":150,"calories":174}],
+				"nutrition":{"calories_intake":348},"analysis":""}`,
+			wantFoods:    1,
+			wantCalories: 174,
+		},
+		{
+			name: "未给出重量且数量不同时保留",
+			raw: `{"foods":[{"name":"米饭","quantity":"1碗","calories":174},
+				{"name":"米饭","quantity":"2碗","calories":348}],
+				"nutrition":{"calories_intake":522},"analysis":""}`,
+			wantFoods:    2,
+			wantCalories: 522,
+		},
+		{
+			name: "未给出重量时按数量描述合并",
+			raw: `{"foods":[{"name":"鸡蛋","quantity":"2个","weight_g":0,"calories":144},
+				{"name":"鸡蛋","quantity":"2 个","weight_g":0,"calories":144}],
+				"nutrition":{"calories_intake":288},"analysis":""}`,
+			wantFoods:    1,
+			wantCalories: 144,
+		},
+		{
+			name: "重量与数量都未给出时保留",
+			raw: `{"foods":[{"name":"苹果","calories":95},{"name":"苹果","calories":95}],
+				"nutrition":{"calories_intake":190},"analysis":""}`,
+			wantFoods:    2,
+			wantCalories: 190,
+		},
+	}
+	for _, tt := range tests {
+		result, err := parseRecognitionOutput(tt.raw, true)
+		if err != nil {
+			t.Errorf("%s: 解析失败: %v", tt.name, err)
+			continue
+		}
+		if len(result.Foods) != tt.wantFoods {
+			t.Errorf("%s: 食物项应为%d个，实际为%d个", tt.name, tt.wantFoods, len(result.Foods))
+		}
+		if result.Nutrition.CaloriesIntake != tt.wantCalories {
+			t.Errorf("%s: 总热量应为%.0f，实际为%.0f", tt.name, tt.wantCalories, result.Nutrition.CaloriesIntake)
+		}
+	}
+}
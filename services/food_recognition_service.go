@@ -1,42 +1,114 @@
 package services
 
 import (
-	"encoding/json"
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"log"
+	"math"
 	"mime/multipart"
+	"regexp"
+	"strconv"
+	"strings"
 	"time"
 
+	"ome-app-back/config"
 	"ome-app-back/repositories"
 	"ome-app-back/models"
+	"ome-app-back/models/constant"
 )
 
 // FoodRecognitionService 处理食物识别相关服务
 type FoodRecognitionService struct {
 	recognitionDAO    *repositories.FoodRecognitionDAO
 	nutritionDAO      *repositories.DailyNutritionDAO
+	entryDAO          *repositories.NutritionEntryDAO
 	healthAnalysisDAO *repositories.HealthAnalysisDAO
+	foodItemDAO       *repositories.FoodItemDAO
+	chatDAO           *repositories.ChatDAO
+	nutritionService  *NutritionService
 	fileService       *FileService
 	aiService         *AIService
+	allergenService   *AllergenService
+	settingService    *UserSettingService
+	workerPool        *recognitionWorkerPool
+	events            *recognitionEventHub
+	maxImages         int
+	cacheDistance     int
+	cacheDays         int
+	micronutrientKeys []string
 }
 
 func NewFoodRecognitionService(
 	recognitionDAO *repositories.FoodRecognitionDAO,
 	nutritionDAO *repositories.DailyNutritionDAO,
+	entryDAO *repositories.NutritionEntryDAO,
 	healthAnalysisDAO *repositories.HealthAnalysisDAO,
+	foodItemDAO *repositories.FoodItemDAO,
+	chatDAO *repositories.ChatDAO,
+	nutritionService *NutritionService,
 	fileService *FileService,
 	aiService *AIService,
+	allergenService *AllergenService,
+	settingService *UserSettingService,
+	aiCfg *config.AIConfig,
+	nutritionCfg *config.NutritionConfig,
 ) *FoodRecognitionService {
-	return &FoodRecognitionService{
+	s := &FoodRecognitionService{
 		recognitionDAO:    recognitionDAO,
 		nutritionDAO:      nutritionDAO,
+		entryDAO:          entryDAO,
 		healthAnalysisDAO: healthAnalysisDAO,
+		foodItemDAO:       foodItemDAO,
+		chatDAO:           chatDAO,
+		nutritionService:  nutritionService,
 		fileService:       fileService,
 		aiService:         aiService,
+		allergenService:   allergenService,
+		settingService:    settingService,
+		events:            newRecognitionEventHub(),
+		maxImages:         aiCfg.GetRecognitionMaxImages(),
+		cacheDistance:     aiCfg.GetRecognitionCacheDistance(),
+		cacheDays:         aiCfg.GetRecognitionCacheDays(),
+		micronutrientKeys: nutritionCfg.MicronutrientKeys(),
 	}
+
+	// 队列只保存在内存中，实例重启会丢失其中的任务；多实例部署时无法区分任务属于哪个实例，
+	// 因此按超时判断：长时间未更新的未完成任务视为中断并标记为失败，由用户重试
+	go s.failStaleRecognitions(time.Duration(aiCfg.GetRecognitionStaleMinutes()) * time.Minute)
+	s.workerPool = newRecognitionWorkerPool(aiCfg.GetRecognitionWorkers(), aiCfg.GetRecognitionQueueSize(), func(recognitionID int64) {
+		s.processRecognition(recognitionID)
+	})
+	return s
 }
 
+// failStaleRecognitions 启动时及之后每隔 timeout 将超过 timeout 未更新的未完成识别任务标记为失败
+func (s *FoodRecognitionService) failStaleRecognitions(timeout time.Duration) {
+	ticker := time.NewTicker(timeout)
+	defer ticker.Stop()
+	for {
+		count, err := s.recognitionDAO.FailInterruptedRecognitions(time.Now().Add(-timeout), "识别任务中断，请重试")
+		if err != nil {
+			log.Printf("[食物识别] 警告: 标记中断的识别任务失败: %v", err)
+		} else if count > 0 {
+			log.Printf("[食物识别] 已将%d个超过%s未完成的识别任务标记为失败", count, timeout)
+		}
+		<-ticker.C
+	}
+}
+
+// 识别任务相关错误
+var (
+	ErrRecognitionQueueFull    = errors.New("识别任务繁忙，请稍后重试")
+	ErrRecognitionNotRetryable = errors.New("只有识别失败的记录可以重试")
+	ErrRecognitionNotReady     = errors.New("识别尚未完成")
+	ErrInvalidImageCount       = errors.New("识别图片数量无效")
+	ErrInvalidFoodText         = errors.New("饮食描述不能为空")
+
+	// errRecognitionSuperseded 识别结果返回时记录已超时被标记为失败或已重新提交，结果被丢弃
+	errRecognitionSuperseded = errors.New("识别任务已超时或重新提交")
+)
+
 // AIAnalysisResult AI分析结果结构
 type AIAnalysisResult struct {
 	Foods     []models.RecognizedFoodItem     `json:"foods"`
@@ -44,6 +116,15 @@ type AIAnalysisResult struct {
 	Analysis  string                         `json:"analysis"`
 }
 
+// recognitionAwaitPollInterval 等待后台识别任务结束时重新读取状态的间隔
+const recognitionAwaitPollInterval = 2 * time.Second
+
+// recognitionCacheCandidates 查找可复用识别结果时最多比对的历史记录数
+const recognitionCacheCandidates = 50
+
+// foodItemMatchCandidates 文字记录匹配食物库时每种食物最多检索的条目数
+const foodItemMatchCandidates = 20
+
 // FoodRecognitionHistoryResult 食物识别历史结果
 type FoodRecognitionHistoryResult struct {
 	Total      int64                                    `json:"total"`       // 总记录数
@@ -51,119 +132,734 @@ type FoodRecognitionHistoryResult struct {
 	DateGroups map[string][]models.FoodRecognitionResult `json:"date_groups"` // 按日期分组的记录
 }
 
-// RecognizeFood 处理食物识别
-func (s *FoodRecognitionService) RecognizeFood(userID int64, sessionID string, file *multipart.FileHeader) (*models.FoodRecognitionResult, error) {
-	log.Printf("[食物识别] 开始处理用户(ID:%d)的识别请求", userID)
+// RecognizeFood 同步处理食物识别：上传图片后立即调用AI，返回完整的识别结果
+// 多张图片视为同一餐，合并为一条识别记录；识别失败时记录会被标记为失败，可通过重试接口重新识别
+// 用户近期识别过相同或近似的图片时直接复用其结果，force 为 true 时强制重新识别
+func (s *FoodRecognitionService) RecognizeFood(userID int64, sessionID string, files []*multipart.FileHeader, force bool) (*models.FoodRecognitionResult, error) {
+	log.Printf("[食物识别] 开始处理用户(ID:%d)的识别请求, 图片数: %d", userID, len(files))
 	startTime := time.Now()
 
-	// 上传图片
-	log.Printf("[食物识别] 上传图片: %s", file.Filename)
-	imagePath, err := s.fileService.UploadImage(file, userID)
+	recognition, err := s.createRecognition(userID, sessionID, files)
 	if err != nil {
-		log.Printf("[食物识别] 错误: 图片上传失败: %v", err)
 		return nil, err
 	}
-	log.Printf("[食物识别] 图片已上传至: %s", imagePath)
 
-	imageBase64, err := s.fileService.GetImageBase64(imagePath)
+	result, err := s.RunRecognition(recognition, force)
 	if err != nil {
-		log.Printf("[食物识别] 错误: 图片转换失败: %v", err)
 		return nil, err
 	}
-	log.Printf("[食物识别] 图片Base64转换完成, 大小: %d字节", len(imageBase64))
 
-	// 调用AI分析
-	aiResponse, err := s.aiService.AnalyzeImageWithAI(imageBase64, "请分析这张食物图片的营养成分")
+	// 计算总处理时间
+	duration := time.Since(startTime)
+	log.Printf("[食物识别] 处理完成, 总耗时: %.2f秒", duration.Seconds())
+
+	return result, nil
+}
+
+// PrepareRecognition 上传同一餐的图片并创建待识别记录，不调用AI
+// 用于聊天等需要先保存图片附件、再在后台完成识别的场景，之后调用 AwaitRecognition 完成识别
+func (s *FoodRecognitionService) PrepareRecognition(userID int64, sessionID string, files []*multipart.FileHeader) (*models.FoodRecognition, error) {
+	return s.createRecognition(userID, sessionID, files)
+}
+
+// RunRecognition 同步完成待识别记录的识别，优先复用相似图片的历史识别结果
+func (s *FoodRecognitionService) RunRecognition(recognition *models.FoodRecognition, force bool) (*models.FoodRecognitionResult, error) {
+	if s.reuseCachedRecognition(recognition, force) {
+		return s.publishRecognition(recognition.ID)
+	}
+	return s.processRecognition(recognition.ID)
+}
+
+// AwaitRecognition 将待识别记录提交到后台识别队列并等待识别结束，优先复用相似图片的历史识别结果
+// 与异步识别共用有界的工作协程，避免聊天等场景绕过识别并发上限；识别失败时返回失败原因
+func (s *FoodRecognitionService) AwaitRecognition(recognition *models.FoodRecognition) (*models.FoodRecognitionResult, error) {
+	if s.reuseCachedRecognition(recognition, false) {
+		return s.publishRecognition(recognition.ID)
+	}
+
+	// 先订阅再提交，避免错过提交后立即发生的状态变化
+	updates, cancel := s.events.subscribe(recognition.ID)
+	defer cancel()
+	if err := s.enqueueRecognition(recognition.ID); err != nil {
+		return nil, err
+	}
+
+	// 订阅者处理不及时时状态推送会被丢弃，定期重新读取状态兜底
+	ticker := time.NewTicker(recognitionAwaitPollInterval)
+	defer ticker.Stop()
+	for {
+		var result *models.FoodRecognitionResult
+		select {
+		case result = <-updates:
+		case <-ticker.C:
+			current, err := s.recognitionDAO.GetRecognitionByID(recognition.ID)
+			if err != nil {
+				return nil, err
+			}
+			if result, err = s.recognitionResult(current); err != nil {
+				return nil, err
+			}
+		}
+		switch result.Status {
+		case constant.RecognitionStatusCompleted:
+			return result, nil
+		case constant.RecognitionStatusFailed:
+			return nil, errors.New(result.ErrorMessage)
+		}
+	}
+}
+
+// SubmitRecognition 异步提交食物识别：上传图片并创建待识别记录后立即返回，由后台工作协程完成AI识别
+// 命中识别结果缓存时不进入队列，直接返回已完成的记录
+func (s *FoodRecognitionService) SubmitRecognition(userID int64, sessionID string, files []*multipart.FileHeader, force bool) (*models.FoodRecognitionResult, error) {
+	log.Printf("[食物识别] 用户(ID:%d)提交异步识别任务, 图片数: %d", userID, len(files))
+
+	recognition, err := s.createRecognition(userID, sessionID, files)
 	if err != nil {
-		log.Printf("[食物识别] 错误: AI分析失败: %v", err)
+		return nil, err
+	}
+	if s.reuseCachedRecognition(recognition, force) {
+		return s.publishRecognition(recognition.ID)
+	}
+	if err := s.enqueueRecognition(recognition.ID); err != nil {
 		return nil, err
 	}
 
-	log.Printf("[食物识别] 解析AI响应...")
-	var analysisResult AIAnalysisResult
-	if err := json.Unmarshal([]byte(aiResponse), &analysisResult); err != nil {
-		log.Printf("[食物识别] 错误: 解析AI响应失败: %v, 原始响应: %s", err, truncateString(aiResponse, 100))
-		return nil, errors.New("解析AI响应失败: " + err.Error())
+	return s.recognitionDAO.ConvertToResult(recognition)
+}
+
+// RecognizeTextRequest 文字记录饮食请求
+type RecognizeTextRequest struct {
+	Text      string `json:"text" binding:"required,max=500"` // 饮食描述，如"两个鸡蛋一碗白粥一根油条"
+	SessionID string `json:"session_id"`                      // 关联的聊天会话ID
+	Async     bool   `json:"async"`                           // 为 true 时以异步任务方式解析
+}
+
+// RecognizeText 由AI解析用户的文字饮食描述，生成与拍照识别相同的识别记录，同样可以修改、采用和重试
+func (s *FoodRecognitionService) RecognizeText(userID int64, req *RecognizeTextRequest) (*models.FoodRecognitionResult, error) {
+	text := strings.TrimSpace(req.Text)
+	if text == "" {
+		return nil, ErrInvalidFoodText
+	}
+	log.Printf("[食物识别] 用户(ID:%d)提交文字记录: %s", userID, text)
+	if err := s.checkSessionOwner(userID, req.SessionID); err != nil {
+		return nil, err
+	}
+
+	recognition, err := s.recognitionDAO.CreateTextRecognition(userID, req.SessionID, text, s.settingService.Today(userID))
+	if err != nil {
+		log.Printf("[食物识别] 错误: 创建识别记录失败: %v", err)
+		return nil, err
+	}
+	log.Printf("[食物识别] 识别记录已创建(ID:%d)", recognition.ID)
+
+	if req.Async {
+		if err := s.enqueueRecognition(recognition.ID); err != nil {
+			return nil, err
+		}
+		return s.recognitionDAO.ConvertToResult(recognition)
+	}
+	return s.processRecognition(recognition.ID)
+}
+
+// RetryRecognition 重新识别失败的记录，以异步任务方式执行
+func (s *FoodRecognitionService) RetryRecognition(recognitionID int64, userID int64) (*models.FoodRecognitionResult, error) {
+	recognition, err := s.getOwnedRecognition(recognitionID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if recognition.Status != constant.RecognitionStatusFailed {
+		return nil, ErrRecognitionNotRetryable
+	}
+
+	retried, err := s.recognitionDAO.RetryRecognition(recognitionID)
+	if err != nil {
+		return nil, err
+	}
+	if !retried {
+		// 并发的重试请求已先一步提交
+		return nil, ErrRecognitionNotRetryable
+	}
+	log.Printf("[食物识别] 用户(ID:%d)重试识别记录(ID:%d)", userID, recognitionID)
+
+	if err := s.enqueueRecognition(recognitionID); err != nil {
+		return nil, err
+	}
+	return s.publishRecognition(recognitionID)
+}
+
+// WatchRecognition 订阅识别记录的状态变化，返回当前状态及后续状态的通道
+// 识别已结束时返回的通道为 nil；调用方使用完毕后必须调用返回的取消函数
+func (s *FoodRecognitionService) WatchRecognition(recognitionID int64, userID int64) (*models.FoodRecognitionResult, <-chan *models.FoodRecognitionResult, func(), error) {
+	// 先订阅再读取当前状态，避免错过两者之间发生的状态变化
+	updates, cancel := s.events.subscribe(recognitionID)
+
+	recognition, err := s.getOwnedRecognition(recognitionID, userID)
+	if err != nil {
+		cancel()
+		return nil, nil, func() {}, err
+	}
+	result, err := s.recognitionResult(recognition)
+	if err != nil {
+		cancel()
+		return nil, nil, func() {}, err
+	}
+	if IsRecognitionFinished(result.Status) {
+		cancel()
+		return result, nil, func() {}, nil
+	}
+	return result, updates, cancel, nil
+}
+
+// IsRecognitionFinished 判断识别状态是否已结束（完成或失败）
+func IsRecognitionFinished(status string) bool {
+	return status == constant.RecognitionStatusCompleted || status == constant.RecognitionStatusFailed
+}
+
+// checkSessionOwner 校验识别记录要关联的聊天会话属于该用户，未关联会话时不校验
+// 会话不存在或不属于该用户时返回 gorm.ErrRecordNotFound
+func (s *FoodRecognitionService) checkSessionOwner(userID int64, sessionID string) error {
+	if sessionID == "" {
+		return nil
+	}
+	if _, err := s.chatDAO.GetUserSession(sessionID, userID); err != nil {
+		log.Printf("[食物识别] 错误: 用户(ID:%d)获取会话(ID:%s)失败: %v", userID, sessionID, err)
+		return err
+	}
+	return nil
+}
+
+// createRecognition 上传并预处理同一餐的全部图片，创建待识别的记录
+func (s *FoodRecognitionService) createRecognition(userID int64, sessionID string, files []*multipart.FileHeader) (*models.FoodRecognition, error) {
+	if len(files) == 0 || len(files) > s.maxImages {
+		return nil, fmt.Errorf("%w: 每次需上传1-%d张图片", ErrInvalidImageCount, s.maxImages)
+	}
+	if err := s.checkSessionOwner(userID, sessionID); err != nil {
+		return nil, err
+	}
+
+	imageURLs := make([]string, 0, len(files))
+	originalImageURLs := make([]string, 0, len(files))
+	imageHashes := make([]string, 0, len(files))
+	for _, file := range files {
+		// 上传并预处理图片
+		log.Printf("[食物识别] 上传图片: %s", file.Filename)
+		image, err := s.fileService.UploadImage(file, userID)
+		if err != nil {
+			log.Printf("[食物识别] 错误: 图片上传失败: %v", err)
+			return nil, err
+		}
+		log.Printf("[食物识别] 图片已上传至: %s(原图: %s, 格式: %s, 处理后尺寸: %dx%d)",
+			image.Path, image.OriginalPath, image.OriginalMIME, image.Width, image.Height)
+		imageURLs = append(imageURLs, image.Path)
+		originalImageURLs = append(originalImageURLs, image.OriginalPath)
+		imageHashes = append(imageHashes, image.Hash)
 	}
 
-	// 创建识别记录
-	log.Printf("[食物识别] 创建数据库记录, 识别到%d种食物", len(analysisResult.Foods))
 	recognition, err := s.recognitionDAO.CreateRecognition(
 		userID,
 		sessionID,
-		imagePath,
-		analysisResult.Foods,
-		analysisResult.Nutrition,
-		analysisResult.Analysis,
+		imageURLs,
+		originalImageURLs,
+		imageHashes,
+		s.settingService.Today(userID),
 	)
 	if err != nil {
 		log.Printf("[食物识别] 错误: 创建识别记录失败: %v", err)
 		return nil, err
 	}
-	log.Printf("[食物识别] 识别记录已保存(ID:%d)", recognition.ID)
+	log.Printf("[食物识别] 识别记录已创建(ID:%d)", recognition.ID)
+	return recognition, nil
+}
 
-	// 转换为前端结果对象
-	result, err := s.recognitionDAO.ConvertToResult(recognition)
+// reuseCachedRecognition 查找用户近期经AI识别过的相同或近似图片，命中时复用其识别结果完成当前记录
+// 返回 false 表示未命中或强制重新识别，需要调用AI；查询缓存出错时按未命中处理
+func (s *FoodRecognitionService) reuseCachedRecognition(recognition *models.FoodRecognition, force bool) bool {
+	if force {
+		recognitionMetrics.Add("cache_bypass", 1)
+		return false
+	}
+
+	since := time.Now().AddDate(0, 0, -s.cacheDays)
+	candidates, err := s.recognitionDAO.GetCacheCandidates(recognition.UserID, since, recognitionCacheCandidates)
+	if err != nil {
+		log.Printf("[食物识别] 警告: 查询识别缓存失败: %v", err)
+		recognitionMetrics.Add("cache_miss", 1)
+		return false
+	}
+
+	for i := range candidates {
+		source := &candidates[i]
+		if !s.imagesMatch(recognition.ImageHashes, source.ImageHashes) {
+			continue
+		}
+		completed, err := s.recognitionDAO.CompleteFromCache(recognition.ID, source)
+		if err != nil {
+			log.Printf("[食物识别] 警告: 复用识别记录(ID:%d)的结果失败: %v", source.ID, err)
+			break
+		}
+		if !completed {
+			break
+		}
+		log.Printf("[食物识别] 识别记录(ID:%d)命中缓存，复用识别记录(ID:%d)的结果", recognition.ID, source.ID)
+		recognitionMetrics.Add("cache_hit", 1)
+		return true
+	}
+
+	recognitionMetrics.Add("cache_miss", 1)
+	return false
+}
+
+// imagesMatch 判断两组图片是否为同一餐：数量相同，且每张图片都能与另一组中不同的图片一一对应
+func (s *FoodRecognitionService) imagesMatch(hashes, candidate []string) bool {
+	if len(hashes) == 0 || len(hashes) != len(candidate) {
+		return false
+	}
+	used := make([]bool, len(candidate))
+	for _, hash := range hashes {
+		matched := false
+		for j, other := range candidate {
+			if used[j] {
+				continue
+			}
+			if distance := imageHashDistance(hash, other); distance >= 0 && distance <= s.cacheDistance {
+				used[j] = true
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// enqueueRecognition 将识别任务放入后台队列，队列已满时将记录标记为失败
+func (s *FoodRecognitionService) enqueueRecognition(recognitionID int64) error {
+	if s.workerPool.submit(recognitionID) {
+		return nil
+	}
+	log.Printf("[食物识别] 警告: 识别队列已满，识别记录(ID:%d)提交失败", recognitionID)
+	if err := s.recognitionDAO.FailRecognition(recognitionID, ErrRecognitionQueueFull.Error()); err != nil {
+		log.Printf("[食物识别] 错误: 标记识别记录(ID:%d)失败状态出错: %v", recognitionID, err)
+	}
+	s.publishRecognition(recognitionID)
+	return ErrRecognitionQueueFull
+}
+
+// processRecognition 对待识别的记录调用AI识别并保存结果，失败时记录失败原因；每次状态变化都会推送给订阅者
+func (s *FoodRecognitionService) processRecognition(recognitionID int64) (*models.FoodRecognitionResult, error) {
+	started, err := s.recognitionDAO.StartRecognition(recognitionID)
+	if err != nil {
+		log.Printf("[食物识别] 错误: 更新识别记录(ID:%d)状态失败: %v", recognitionID, err)
+		return nil, err
+	}
+	if !started {
+		log.Printf("[食物识别] 识别记录(ID:%d)已被其他任务处理，跳过", recognitionID)
+		return nil, ErrRecognitionNotReady
+	}
+	s.publishRecognition(recognitionID)
+
+	if err := s.analyzeRecognition(recognitionID); err != nil {
+		if errors.Is(err, errRecognitionSuperseded) {
+			// 记录已被标记为失败或已重新提交，保持其当前状态
+			log.Printf("[食物识别] 识别记录(ID:%d)已超时或重新提交，丢弃本次识别结果", recognitionID)
+			return nil, ErrRecognitionNotReady
+		}
+		if failErr := s.recognitionDAO.FailRecognition(recognitionID, err.Error()); failErr != nil {
+			log.Printf("[食物识别] 错误: 标记识别记录(ID:%d)失败状态出错: %v", recognitionID, failErr)
+		}
+		s.publishRecognition(recognitionID)
+		return nil, err
+	}
+
+	return s.publishRecognition(recognitionID)
+}
+
+// analyzeRecognition 按记录的输入来源调用AI识别图片或解析文字描述，并保存识别结果
+func (s *FoodRecognitionService) analyzeRecognition(recognitionID int64) error {
+	recognition, err := s.recognitionDAO.GetRecognitionByID(recognitionID)
+	if err != nil {
+		return err
+	}
+
+	var analysisResult *AIAnalysisResult
+	if recognition.Source == constant.RecognitionSourceText {
+		analysisResult, err = s.analyzeText(recognition)
+	} else {
+		analysisResult, err = s.analyzeImages(recognition)
+	}
+	if err != nil {
+		return err
+	}
+
+	// 保存识别结果
+	log.Printf("[食物识别] 保存识别结果(ID:%d), 识别到%d种食物", recognitionID, len(analysisResult.Foods))
+	completed, err := s.recognitionDAO.CompleteRecognition(recognitionID, recognition.Attempts, analysisResult.Foods, analysisResult.Nutrition, analysisResult.Analysis)
+	if err != nil {
+		log.Printf("[食物识别] 错误: 保存识别结果失败: %v", err)
+		return err
+	}
+	if !completed {
+		return errRecognitionSuperseded
+	}
+	return nil
+}
+
+// analyzeImages 调用AI识别记录中的图片
+func (s *FoodRecognitionService) analyzeImages(recognition *models.FoodRecognition) (*AIAnalysisResult, error) {
+	return analyzeMealImages(s.aiService, s.fileService, recognition.AllImageURLs())
+}
+
+// analyzeMealImages 读取同一餐的全部图片并调用AI识别，解析失败时请求AI修复
+func analyzeMealImages(aiService *AIService, fileService *FileService, imageURLs []string) (*AIAnalysisResult, error) {
+	images := make([]AIImageInput, 0, len(imageURLs))
+	for _, imageURL := range imageURLs {
+		imageData, mimeType, err := fileService.GetFile(imageURL)
+		if err != nil {
+			log.Printf("[食物识别] 错误: 读取图片失败: %v", err)
+			return nil, err
+		}
+		imageBase64 := base64.StdEncoding.EncodeToString(imageData)
+		log.Printf("[食物识别] 图片Base64转换完成, 大小: %d字节", len(imageBase64))
+		images = append(images, AIImageInput{Base64: imageBase64, MIMEType: mimeType})
+	}
+
+	// 调用AI分析，同一餐的多张图片在一次请求中发送
+	aiResponse, err := aiService.AnalyzeImageWithAI(images, "请分析这一餐的营养成分")
+	if err != nil {
+		log.Printf("[食物识别] 错误: AI分析失败: %v", err)
+		return nil, err
+	}
+
+	log.Printf("[食物识别] 解析AI响应...")
+	return parseRecognitionWithRepair(aiResponse, len(images) > 1, aiService.RepairFoodRecognitionJSON)
+}
+
+// analyzeText 调用AI解析记录中的文字描述，并用食物库数据校准能匹配上的食物
+func (s *FoodRecognitionService) analyzeText(recognition *models.FoodRecognition) (*AIAnalysisResult, error) {
+	result, err := analyzeMealText(s.aiService, recognition.InputText)
+	if err != nil {
+		return nil, err
+	}
+	s.matchFoodItems(recognition.UserID, result)
+	return result, nil
+}
+
+// analyzeMealText 调用AI将文字描述解析为食物列表，解析失败时请求AI修复
+func analyzeMealText(aiService *AIService, text string) (*AIAnalysisResult, error) {
+	aiResponse, err := aiService.ParseFoodText(text)
+	if err != nil {
+		log.Printf("[食物识别] 错误: AI解析文字描述失败: %v", err)
+		return nil, err
+	}
+
+	log.Printf("[食物识别] 解析AI响应...")
+	return parseRecognitionWithRepair(aiResponse, false, func(raw string, problems []string) (string, error) {
+		return aiService.RepairFoodTextJSON(text, raw, problems)
+	})
+}
+
+// matchFoodItems 将解析出的食物按名称与食物库精确匹配，匹配成功且重量已知时按食物库每100克数据重新计算该食物的营养，并同步调整总量
+func (s *FoodRecognitionService) matchFoodItems(userID int64, result *AIAnalysisResult) {
+	matched := 0
+	for i := range result.Foods {
+		food := &result.Foods[i]
+		weight := food.WeightG
+		if weight <= 0 {
+			weight = parseGrams(food.Quantity)
+		}
+		if weight <= 0 {
+			continue
+		}
+
+		item, err := s.findFoodItem(userID, food.Name)
+		if err != nil {
+			log.Printf("[食物识别] 警告: 匹配食物库失败(%s): %v", food.Name, err)
+			continue
+		}
+		if item == nil {
+			continue
+		}
+
+		factor := weight / 100
+		calibrated := *food
+		calibrated.FoodItemID = item.ID
+		calibrated.WeightG = weight
+		calibrated.Calories = roundTo(item.CaloriesPer100G*factor, 2)
+		calibrated.ProteinG = roundTo(item.ProteinPer100G*factor, 2)
+		calibrated.CarbG = roundTo(item.CarbPer100G*factor, 2)
+		calibrated.FatG = roundTo(item.FatPer100G*factor, 2)
+		calibrated.Micronutrients = item.Micronutrients.Pick(s.micronutrientKeys).Scale(factor)
+
+		n := &result.Nutrition
+		n.CaloriesIntake = roundTo(math.Max(0, n.CaloriesIntake-food.Calories+calibrated.Calories), 2)
+		n.ProteinIntakeG = roundTo(math.Max(0, n.ProteinIntakeG-food.ProteinG+calibrated.ProteinG), 2)
+		n.CarbIntakeG = roundTo(math.Max(0, n.CarbIntakeG-food.CarbG+calibrated.CarbG), 2)
+		n.FatIntakeG = roundTo(math.Max(0, n.FatIntakeG-food.FatG+calibrated.FatG), 2)
+		n.Micronutrients = n.Micronutrients.Subtract(food.Micronutrients).Add(calibrated.Micronutrients)
+
+		*food = calibrated
+		matched++
+	}
+	log.Printf("[食物识别] 文字记录共%d种食物，%d种匹配到食物库", len(result.Foods), matched)
+}
+
+// findFoodItem 在用户可见的食物库中查找与名称完全相同的条目（忽略大小写与空白），用户自建食物优先，未找到时返回 nil
+func (s *FoodRecognitionService) findFoodItem(userID int64, name string) (*models.FoodItem, error) {
+	key := normalizeFoodKey(name)
+	if key == "" {
+		return nil, nil
+	}
+	items, err := s.foodItemDAO.Search(userID, strings.TrimSpace(name), "", foodItemMatchCandidates)
+	if err != nil {
+		return nil, err
+	}
+	for i := range items {
+		if normalizeFoodKey(items[i].Name) == key {
+			return &items[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// publishRecognition 读取识别记录的最新状态并推送给订阅者
+func (s *FoodRecognitionService) publishRecognition(recognitionID int64) (*models.FoodRecognitionResult, error) {
+	recognition, err := s.recognitionDAO.GetRecognitionByID(recognitionID)
+	if err != nil {
+		log.Printf("[食物识别] 错误: 读取识别记录(ID:%d)失败: %v", recognitionID, err)
+		return nil, err
+	}
+	result, err := s.recognitionResult(recognition)
 	if err != nil {
 		log.Printf("[食物识别] 错误: 转换结果对象失败: %v", err)
 		return nil, err
 	}
+	s.events.publish(result)
+	return result, nil
+}
 
-	// 计算总处理时间
-	duration := time.Since(startTime)
-	log.Printf("[食物识别] 处理完成, 总耗时: %.2f秒", duration.Seconds())
+// recognitionResult 转换为前端结果对象，识别完成时按用户的食物不耐受检查识别出的食物
+func (s *FoodRecognitionService) recognitionResult(recognition *models.FoodRecognition) (*models.FoodRecognitionResult, error) {
+	result, err := s.recognitionDAO.ConvertToResult(recognition)
+	if err != nil {
+		return nil, err
+	}
+	if result.Status == constant.RecognitionStatusCompleted {
+		checkFoods := make([]AllergenCheckFood, 0, len(result.RecognizedFoods))
+		for _, food := range result.RecognizedFoods {
+			checkFoods = append(checkFoods, AllergenCheckFood{Name: food.Name, Allergens: food.Allergens})
+		}
+		result.AllergenWarnings = s.allergenService.CheckFoods(recognition.UserID, checkFoods)
+	}
+	return result, nil
+}
 
+// parseRecognitionWithRepair 解析并校验AI识别结果，失败时通过 repair 请求AI修复后重试
+// multiImage 为 true 时合并多张图片中重复出现的同一份食物
+func parseRecognitionWithRepair(aiResponse string, multiImage bool, repair func(raw string, problems []string) (string, error)) (*AIAnalysisResult, error) {
+	result, err := parseRecognitionOutput(aiResponse, multiImage)
+	for attempt := 1; err != nil && attempt <= maxRecognitionRepairAttempts; attempt++ {
+		log.Printf("[食物识别] 警告: AI响应不可用(第%d次修复): %v, 原始响应: %s", attempt, err, truncateString(aiResponse, 100))
+		repaired, repairErr := repair(aiResponse, outputProblems(err))
+		if repairErr != nil {
+			log.Printf("[食物识别] 错误: 请求AI修复失败: %v", repairErr)
+			break
+		}
+		aiResponse = repaired
+		result, err = parseRecognitionOutput(aiResponse, multiImage)
+	}
+	if err != nil {
+		log.Printf("[食物识别] 错误: 解析AI响应失败: %v", err)
+		return nil, err
+	}
 	return result, nil
 }
 
-// SaveRecognitionToNutrition 将食物识别结果保存到用户当日营养摄入
-func (s *FoodRecognitionService) SaveRecognitionToNutrition(recognitionID int64, userID int64) error {
+// AdoptRecognitionRequest 采用识别结果请求
+type AdoptRecognitionRequest struct {
+	Date     string `json:"date"`                                                             // 格式: 2023-12-01，为空时为今天
+	MealType string `json:"meal_type" binding:"omitempty,oneof=breakfast lunch dinner snack"` // 为空时按识别时间推断
+}
+
+// RecognitionAdoptionResponse 采用/取消采用识别结果响应
+type RecognitionAdoptionResponse struct {
+	IsAdopted      bool                   `json:"is_adopted"`                // 操作后的采用状态
+	Changed        bool                   `json:"changed"`                   // 本次请求是否改变了采用状态，重复请求时为 false
+	Entry          *models.NutritionEntry `json:"entry,omitempty"`           // 采用生成的饮食记录
+	DailyNutrition *models.DailyNutrition `json:"daily_nutrition,omitempty"` // 受影响日期的营养数据
+}
+
+// SaveRecognitionToNutrition 采用食物识别结果：生成一条饮食记录并累加到指定日期的营养摄入
+// 重复采用不会重复累加，采用状态与营养数据在同一事务中更新
+func (s *FoodRecognitionService) SaveRecognitionToNutrition(recognitionID int64, userID int64, req *AdoptRecognitionRequest) (*RecognitionAdoptionResponse, error) {
 	log.Printf("[食物识别-保存] 开始将识别结果(ID:%d)保存到用户(ID:%d)的营养摄入", recognitionID, userID)
 
-	// 获取识别记录
-	recognition, err := s.recognitionDAO.GetRecognitionByID(recognitionID)
+	recognition, err := s.getOwnedRecognition(recognitionID, userID)
 	if err != nil {
-		log.Printf("[食物识别-保存] 错误: 获取识别记录失败: %v", err)
-		return err
+		return nil, err
+	}
+	if recognition.Status != constant.RecognitionStatusCompleted {
+		return nil, ErrRecognitionNotReady
+	}
+	if recognition.IsAdopted {
+		log.Printf("[食物识别-保存] 识别记录(ID:%d)已采用，忽略重复请求", recognitionID)
+		return s.adoptionState(recognition, false)
 	}
 
-	// 验证识别记录属于当前用户
-	if recognition.UserID != userID {
-		log.Printf("[食物识别-保存] 错误: 用户(ID:%d)尝试保存不属于自己的识别记录(ID:%d)", userID, recognitionID)
-		return errors.New("无权操作此识别记录")
+	dateStr := req.Date
+	if dateStr == "" {
+		dateStr = s.settingService.Today(userID).Format("2006-01-02")
+	}
+	mealType := req.MealType
+	if mealType == "" {
+		mealType = currentMealSlot(recognition.CreatedAt.In(s.settingService.Location(userID)))
 	}
 
-	// 直接从识别记录中获取营养数据
-	nutrition := models.FoodRecognitionNutrition{
+	nutrition, err := s.nutritionService.getEditableNutrition(userID, dateStr)
+	if err != nil {
+		log.Printf("[食物识别-保存] 错误: 获取%s营养数据失败: %v", dateStr, err)
+		return nil, err
+	}
+
+	entry := &models.NutritionEntry{
+		UserID:         userID,
+		Date:           nutrition.Date,
+		MealType:       mealType,
+		FoodName:       recognitionEntryName(recognition),
 		CaloriesIntake: recognition.CaloriesIntake,
 		ProteinIntakeG: recognition.ProteinIntakeG,
 		CarbIntakeG:    recognition.CarbIntakeG,
 		FatIntakeG:     recognition.FatIntakeG,
+		Micronutrients: recognition.Micronutrients,
+		Source:         constant.EntrySourceRecognition,
+		SourceID:       recognition.ID,
 	}
 
-	// 更新用户当日营养摄入
-	log.Printf("[食物识别-保存] 更新用户营养摄入数据...")
-	if err := s.updateDailyNutrition(userID, nutrition); err != nil {
-		log.Printf("[食物识别-保存] 错误: 更新营养数据失败: %v", err)
-		return err
+	adopted, err := s.recognitionDAO.Adopt(recognition.ID, entry, nutrition)
+	if err != nil {
+		log.Printf("[食物识别-保存] 错误: 采用识别记录(ID:%d)失败: %v", recognitionID, err)
+		return nil, err
+	}
+	if !adopted {
+		// 并发请求已先一步采用
+		log.Printf("[食物识别-保存] 识别记录(ID:%d)已被其他请求采用", recognitionID)
+		recognition.IsAdopted = true
+		return s.adoptionState(recognition, false)
 	}
 
-	// 更新识别记录的采用状态
-	log.Printf("[食物识别-保存] 更新识别记录采用状态...")
-	if err := s.recognitionDAO.UpdateAdoptionStatus(recognitionID, true); err != nil {
-		log.Printf("[食物识别-保存] 错误: 更新采用状态失败: %v", err)
-		// 虽然状态更新失败，但营养数据已成功更新，所以仍然返回成功
-		log.Printf("[食物识别-保存] 注意: 营养数据已成功更新，但状态更新失败")
-		return nil
+	log.Printf("[食物识别-保存] 识别记录(ID:%d)已采用到%s的%s，热量:%.2f", recognitionID, dateStr, mealType, entry.CaloriesIntake)
+	return &RecognitionAdoptionResponse{
+		IsAdopted:      true,
+		Changed:        true,
+		Entry:          entry,
+		DailyNutrition: nutrition,
+	}, nil
+}
+
+// UnadoptRecognition 取消采用食物识别结果：删除对应的饮食记录并从当日营养摄入中扣除，未采用时不做修改
+func (s *FoodRecognitionService) UnadoptRecognition(recognitionID int64, userID int64) (*RecognitionAdoptionResponse, error) {
+	recognition, err := s.getOwnedRecognition(recognitionID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !recognition.IsAdopted {
+		return &RecognitionAdoptionResponse{}, nil
 	}
 
-	log.Printf("[食物识别-保存] 营养数据更新成功，记录状态已标记为已采用")
-	return nil
+	entry, err := s.entryDAO.GetBySource(userID, constant.EntrySourceRecognition, recognition.ID)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		// 早期采用时直接累加到识别日期的营养数据，没有生成饮食记录
+		entry = &models.NutritionEntry{
+			UserID:         userID,
+			Date:           recognition.RecordDate,
+			CaloriesIntake: recognition.CaloriesIntake,
+			ProteinIntakeG: recognition.ProteinIntakeG,
+			CarbIntakeG:    recognition.CarbIntakeG,
+			FatIntakeG:     recognition.FatIntakeG,
+			Micronutrients: recognition.Micronutrients,
+		}
+	}
+
+	nutrition, err := s.nutritionService.getEditableNutrition(userID, entry.Date.Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+
+	unadopted, err := s.recognitionDAO.Unadopt(recognition.ID, entry, nutrition)
+	if err != nil {
+		log.Printf("[食物识别-取消采用] 错误: 取消采用识别记录(ID:%d)失败: %v", recognitionID, err)
+		return nil, err
+	}
+	if !unadopted {
+		return &RecognitionAdoptionResponse{}, nil
+	}
+
+	log.Printf("[食物识别-取消采用] 识别记录(ID:%d)已取消采用，扣除热量:%.2f", recognitionID, entry.CaloriesIntake)
+	return &RecognitionAdoptionResponse{
+		Changed:        true,
+		DailyNutrition: nutrition,
+	}, nil
+}
+
+// getOwnedRecognition 按所有者获取识别记录，不属于该用户的记录与不存在一样返回 gorm.ErrRecordNotFound
+func (s *FoodRecognitionService) getOwnedRecognition(recognitionID, userID int64) (*models.FoodRecognition, error) {
+	recognition, err := s.recognitionDAO.GetUserRecognition(recognitionID, userID)
+	if err != nil {
+		log.Printf("[食物识别] 错误: 用户(ID:%d)获取识别记录(ID:%d)失败: %v", userID, recognitionID, err)
+		return nil, err
+	}
+	return recognition, nil
+}
+
+// adoptionState 返回已采用识别记录的当前状态，用于重复采用请求
+func (s *FoodRecognitionService) adoptionState(recognition *models.FoodRecognition, changed bool) (*RecognitionAdoptionResponse, error) {
+	resp := &RecognitionAdoptionResponse{IsAdopted: recognition.IsAdopted, Changed: changed}
+
+	entry, err := s.entryDAO.GetBySource(recognition.UserID, constant.EntrySourceRecognition, recognition.ID)
+	if err != nil {
+		return nil, err
+	}
+	date := recognition.RecordDate
+	if entry != nil {
+		resp.Entry = entry
+		date = entry.Date
+	}
+
+	if nutrition, err := s.nutritionDAO.GetByDate(recognition.UserID, date); err == nil {
+		resp.DailyNutrition = nutrition
+	}
+	return resp, nil
+}
+
+// recognitionEntryName 由识别出的食物名称生成饮食记录名称
+func recognitionEntryName(recognition *models.FoodRecognition) string {
+	items := recognition.CurrentFoods()
+	if len(items) == 0 {
+		if recognition.Source == constant.RecognitionSourceText {
+			return "文字记录餐食"
+		}
+		return "拍照识别餐食"
+	}
+
+	names := make([]string, 0, len(items))
+	for _, item := range items {
+		names = append(names, item.Name)
+	}
+	name := strings.Join(names, "、")
+	if runes := []rune(name); len(runes) > 100 {
+		name = string(runes[:97]) + "..."
+	}
+	return name
 }
 
-// GetRecognitionByID 获取识别记录详情
-func (s *FoodRecognitionService) GetRecognitionByID(id int64) (*models.FoodRecognitionResult, error) {
-	recognition, err := s.recognitionDAO.GetRecognitionByID(id)
+// GetRecognitionByID 获取用户的识别记录详情
+func (s *FoodRecognitionService) GetRecognitionByID(userID, id int64) (*models.FoodRecognitionResult, error) {
+	recognition, err := s.getOwnedRecognition(id, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -171,9 +867,27 @@ func (s *FoodRecognitionService) GetRecognitionByID(id int64) (*models.FoodRecog
 	return s.recognitionDAO.ConvertToResult(recognition)
 }
 
+// GetSessionRecognitions 获取用户在聊天会话中最近完成的识别记录，按时间倒序
+func (s *FoodRecognitionService) GetSessionRecognitions(userID int64, sessionID string, limit int) ([]models.FoodRecognitionResult, error) {
+	records, err := s.recognitionDAO.GetSessionRecognitions(userID, sessionID, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]models.FoodRecognitionResult, 0, len(records))
+	for _, record := range records {
+		result, err := s.recognitionDAO.ConvertToResult(&record)
+		if err != nil {
+			continue // 跳过有错误的记录
+		}
+		results = append(results, *result)
+	}
+	return results, nil
+}
+
 // GetUserTodayRecognitions 获取用户今日的食物识别记录
 func (s *FoodRecognitionService) GetUserTodayRecognitions(userID int64) ([]models.FoodRecognitionResult, error) {
-	records, err := s.recognitionDAO.GetUserTodayRecognitions(userID)
+	records, err := s.recognitionDAO.GetUserTodayRecognitions(userID, s.settingService.Today(userID))
 	if err != nil {
 		return nil, err
 	}
@@ -190,49 +904,312 @@ func (s *FoodRecognitionService) GetUserTodayRecognitions(userID int64) ([]model
 	return results, nil
 }
 
-// updateDailyNutrition 更新用户当日营养摄入数据
-func (s *FoodRecognitionService) updateDailyNutrition(userID int64, nutrition models.FoodRecognitionNutrition) error {
-	log.Printf("[食物识别-营养] 开始更新用户(ID:%d)营养摄入", userID)
+// 自定义错误，表示识别记录已采用，不能再修改
+var ErrRecognitionAdopted = errors.New("识别记录已采用，请先取消采用再修改")
+
+// 自定义错误，表示修改的食物项无效
+var ErrInvalidRecognitionItem = errors.New("食物项无效")
+
+// gramsPattern 从份量描述中提取克数，如 "约200克"、"150g"
+var gramsPattern = regexp.MustCompile(`(\d+(?:\.\d+)?)\s*(?:克|g|G)`)
+
+// RecognitionItemInput 修改识别结果时的单个食物项，未传的营养数值沿用原食物项（按重量变化等比缩放）
+type RecognitionItemInput struct {
+	SourceIndex *int     `json:"source_index" binding:"omitempty,gte=0"` // 对应当前食物列表中的下标，新增食物不传
+	Name        string   `json:"name" binding:"required,max=100"`
+	Quantity    string   `json:"quantity" binding:"max=50"`
+	WeightG     *float64 `json:"weight_g" binding:"omitempty,gt=0"`
+	Calories    *float64 `json:"calories" binding:"omitempty,gte=0"`
+	ProteinG    *float64 `json:"protein_g" binding:"omitempty,gte=0"`
+	CarbG       *float64 `json:"carb_g" binding:"omitempty,gte=0"`
+	FatG        *float64 `json:"fat_g" binding:"omitempty,gte=0"`
+}
+
+// UpdateRecognitionItemsRequest 修改识别结果请求，items 为修改后的完整食物列表
+type UpdateRecognitionItemsRequest struct {
+	Items []RecognitionItemInput `json:"items" binding:"required,min=1,dive"`
+}
+
+// buildRecognitionItems 按用户提交的食物列表生成修改后的食物项并汇总营养总量
+func buildRecognitionItems(current []models.RecognizedFoodItem, inputs []RecognitionItemInput) ([]models.RecognizedFoodItem, models.FoodRecognitionNutrition, error) {
+	items := make([]models.RecognizedFoodItem, 0, len(inputs))
+	var nutrition models.FoodRecognitionNutrition
+	for i, input := range inputs {
+		item, err := applyRecognitionItemInput(current, &input)
+		if err != nil {
+			return nil, nutrition, fmt.Errorf("%w: 第%d项%s", ErrInvalidRecognitionItem, i+1, err.Error())
+		}
+		items = append(items, item)
+
+		nutrition.CaloriesIntake += item.Calories
+		nutrition.ProteinIntakeG += item.ProteinG
+		nutrition.CarbIntakeG += item.CarbG
+		nutrition.FatIntakeG += item.FatG
+		nutrition.Micronutrients = nutrition.Micronutrients.Add(item.Micronutrients)
+	}
+	return items, nutrition, nil
+}
 
-	// 创建营养服务实例
-	nutritionService := NewNutritionService(s.nutritionDAO, s.healthAnalysisDAO)
+// UpdateRecognitionItems 修改识别结果的食物列表并重新计算营养总量，首次修改时保留AI原始结果
+func (s *FoodRecognitionService) UpdateRecognitionItems(userID, recognitionID int64, req *UpdateRecognitionItemsRequest) (*models.FoodRecognitionResult, error) {
+	recognition, err := s.getOwnedRecognition(recognitionID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if recognition.Status != constant.RecognitionStatusCompleted {
+		return nil, ErrRecognitionNotReady
+	}
+	if recognition.IsAdopted {
+		return nil, ErrRecognitionAdopted
+	}
 
-	// 通过服务层获取今日营养数据
-	dailyNutrition, err := nutritionService.GetTodayNutrition(userID)
+	current := resolveRecognitionItems(recognition, recognition.CurrentFoods())
+	items, nutrition, err := buildRecognitionItems(current, req.Items)
 	if err != nil {
-		if err.Error() == "用户尚未生成健康分析报告，请先生成健康分析" {
-			log.Printf("[食物识别-营养] 错误: 用户尚未生成健康分析报告")
-		} else {
-			log.Printf("[食物识别-营养] 错误: 获取今日营养数据失败: %v", err)
+		return nil, err
+	}
+
+	// 首次修改时保存AI原始结果
+	firstEdit := recognition.OriginalNutrition == nil
+	if firstEdit {
+		recognition.OriginalNutrition = &models.FoodRecognitionNutrition{
+			CaloriesIntake: recognition.CaloriesIntake,
+			ProteinIntakeG: recognition.ProteinIntakeG,
+			CarbIntakeG:    recognition.CarbIntakeG,
+			FatIntakeG:     recognition.FatIntakeG,
+			Micronutrients: recognition.Micronutrients,
 		}
-		return err
 	}
 
-	// 记录原始值
-	log.Printf("[食物识别-营养] 更新前: 热量=%.2f, 蛋白质=%.2f, 碳水=%.2f, 脂肪=%.2f",
-		dailyNutrition.CaloriesIntake, dailyNutrition.ProteinIntakeG,
-		dailyNutrition.CarbIntakeG, dailyNutrition.FatIntakeG)
+	now := time.Now()
+	recognition.CaloriesIntake = roundTo(nutrition.CaloriesIntake, 2)
+	recognition.ProteinIntakeG = roundTo(nutrition.ProteinIntakeG, 2)
+	recognition.CarbIntakeG = roundTo(nutrition.CarbIntakeG, 2)
+	recognition.FatIntakeG = roundTo(nutrition.FatIntakeG, 2)
+	recognition.Micronutrients = nutrition.Micronutrients
+	recognition.IsEdited = true
+	recognition.EditedAt = &now
 
-	// 累加营养数据
-	dailyNutrition.CaloriesIntake += nutrition.CaloriesIntake
-	dailyNutrition.ProteinIntakeG += nutrition.ProteinIntakeG
-	dailyNutrition.CarbIntakeG += nutrition.CarbIntakeG
-	dailyNutrition.FatIntakeG += nutrition.FatIntakeG
+	if err := s.recognitionDAO.ReplaceItems(recognition, items, firstEdit); err != nil {
+		log.Printf("[食物识别-修改] 错误: 保存识别记录(ID:%d)失败: %v", recognitionID, err)
+		return nil, err
+	}
+	log.Printf("[食物识别-修改] 用户(ID:%d)已修改识别记录(ID:%d)，食物%d项，热量:%.2f", userID, recognitionID, len(items), recognition.CaloriesIntake)
 
-	// 记录新值
-	log.Printf("[食物识别-营养] 更新后: 热量=%.2f, 蛋白质=%.2f, 碳水=%.2f, 脂肪=%.2f",
-		dailyNutrition.CaloriesIntake, dailyNutrition.ProteinIntakeG,
-		dailyNutrition.CarbIntakeG, dailyNutrition.FatIntakeG)
+	// 重新读取以获得保存后的食物行
+	updated, err := s.recognitionDAO.GetRecognitionByID(recognitionID)
+	if err != nil {
+		return nil, err
+	}
+	return s.recognitionResult(updated)
+}
 
-	// 保存更新
-	err = s.nutritionDAO.Update(dailyNutrition)
+// ConfirmRecognitionItemsRequest 确认识别结果中食物的请求
+type ConfirmRecognitionItemsRequest struct {
+	Indexes []int `json:"indexes" binding:"required,min=1,dive,gte=0"` // 要确认的食物在当前食物列表中的下标
+}
+
+// ConfirmRecognitionItems 将用户核对无误的食物标记为已确认，不改变识别结果，确认后不再提示低置信度
+func (s *FoodRecognitionService) ConfirmRecognitionItems(userID, recognitionID int64, req *ConfirmRecognitionItemsRequest) (*models.FoodRecognitionResult, error) {
+	recognition, err := s.getOwnedRecognition(recognitionID, userID)
 	if err != nil {
-		log.Printf("[食物识别-营养] 错误: 保存营养数据失败: %v", err)
-		return err
+		return nil, err
+	}
+	if recognition.Status != constant.RecognitionStatusCompleted {
+		return nil, ErrRecognitionNotReady
 	}
 
-	log.Printf("[食物识别-营养] 营养数据更新成功(ID:%d)", dailyNutrition.ID)
-	return nil
+	count := len(recognition.CurrentFoods())
+	for _, index := range req.Indexes {
+		if index >= count {
+			return nil, fmt.Errorf("%w: 下标%d超出食物列表范围", ErrInvalidRecognitionItem, index)
+		}
+	}
+	if _, err := s.recognitionDAO.ConfirmItems(recognitionID, req.Indexes); err != nil {
+		log.Printf("[食物识别-修改] 错误: 确认识别记录(ID:%d)的食物失败: %v", recognitionID, err)
+		return nil, err
+	}
+	log.Printf("[食物识别-修改] 用户(ID:%d)已确认识别记录(ID:%d)的食物: %v", userID, recognitionID, req.Indexes)
+
+	updated, err := s.recognitionDAO.GetRecognitionByID(recognitionID)
+	if err != nil {
+		return nil, err
+	}
+	return s.recognitionResult(updated)
+}
+
+// RecognitionFeedbackRequest 识别准确度反馈请求
+// items 为用户修正后的完整食物列表（格式同修改识别结果），不传时以识别记录的当前结果作为正确结果
+type RecognitionFeedbackRequest struct {
+	Rating  int                    `json:"rating" binding:"required,min=1,max=5"`
+	Comment string                 `json:"comment" binding:"max=500"`
+	Items   []RecognitionItemInput `json:"items" binding:"omitempty,dive"`
+}
+
+// SubmitRecognitionFeedback 保存用户对识别准确度的评分与修正结果，供评测数据集使用，不影响识别记录本身及已采用的饮食记录
+func (s *FoodRecognitionService) SubmitRecognitionFeedback(userID, recognitionID int64, req *RecognitionFeedbackRequest) (*models.FoodRecognitionResult, error) {
+	recognition, err := s.getOwnedRecognition(recognitionID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if recognition.Status != constant.RecognitionStatusCompleted {
+		return nil, ErrRecognitionNotReady
+	}
+
+	truth := &models.RecognitionGroundTruth{
+		Foods: recognition.CurrentFoods(),
+		Nutrition: models.FoodRecognitionNutrition{
+			CaloriesIntake: recognition.CaloriesIntake,
+			ProteinIntakeG: recognition.ProteinIntakeG,
+			CarbIntakeG:    recognition.CarbIntakeG,
+			FatIntakeG:     recognition.FatIntakeG,
+			Micronutrients: recognition.Micronutrients,
+		},
+	}
+	if len(req.Items) > 0 {
+		current := resolveRecognitionItems(recognition, recognition.CurrentFoods())
+		items, nutrition, err := buildRecognitionItems(current, req.Items)
+		if err != nil {
+			return nil, err
+		}
+		nutrition.CaloriesIntake = roundTo(nutrition.CaloriesIntake, 2)
+		nutrition.ProteinIntakeG = roundTo(nutrition.ProteinIntakeG, 2)
+		nutrition.CarbIntakeG = roundTo(nutrition.CarbIntakeG, 2)
+		nutrition.FatIntakeG = roundTo(nutrition.FatIntakeG, 2)
+		truth = &models.RecognitionGroundTruth{Foods: items, Nutrition: nutrition}
+	}
+
+	if err := s.recognitionDAO.SaveFeedback(recognitionID, req.Rating, req.Comment, truth); err != nil {
+		log.Printf("[食物识别-反馈] 错误: 保存识别记录(ID:%d)的反馈失败: %v", recognitionID, err)
+		return nil, err
+	}
+	log.Printf("[食物识别-反馈] 用户(ID:%d)对识别记录(ID:%d)评分%d, 修正食物%d项", userID, recognitionID, req.Rating, len(req.Items))
+
+	updated, err := s.recognitionDAO.GetRecognitionByID(recognitionID)
+	if err != nil {
+		return nil, err
+	}
+	return s.recognitionResult(updated)
+}
+
+// applyRecognitionItemInput 根据修改请求生成食物项：以原食物项为基础，重量变化时等比缩放营养数值，再用请求中的数值覆盖
+func applyRecognitionItemInput(current []models.RecognizedFoodItem, input *RecognitionItemInput) (models.RecognizedFoodItem, error) {
+	var item models.RecognizedFoodItem
+	if input.SourceIndex != nil {
+		if *input.SourceIndex >= len(current) {
+			return item, fmt.Errorf("source_index %d 超出食物列表范围", *input.SourceIndex)
+		}
+		item = current[*input.SourceIndex]
+		item.Micronutrients = item.Micronutrients.Scale(1)
+	} else if input.Calories == nil {
+		return item, errors.New("新增食物需要提供热量")
+	} else {
+		// 用户新增的食物无需再核对
+		item.Confidence = 1
+	}
+	// 用户提交的食物列表视为已核对
+	item.Confirmed = true
+	item.LowConfidence = false
+
+	item.Name = strings.TrimSpace(input.Name)
+	if input.Quantity != "" {
+		item.Quantity = input.Quantity
+	}
+
+	if input.WeightG != nil {
+		baseWeight := item.WeightG
+		if baseWeight <= 0 {
+			baseWeight = parseGrams(item.Quantity)
+		}
+		if baseWeight > 0 && input.SourceIndex != nil {
+			factor := *input.WeightG / baseWeight
+			item.Calories *= factor
+			item.ProteinG *= factor
+			item.CarbG *= factor
+			item.FatG *= factor
+			item.Micronutrients = item.Micronutrients.Scale(factor)
+		}
+		item.WeightG = *input.WeightG
+		if input.Quantity == "" {
+			item.Quantity = strconv.FormatFloat(*input.WeightG, 'f', -1, 64) + "克"
+		}
+	} else if item.WeightG <= 0 {
+		item.WeightG = parseGrams(item.Quantity)
+	}
+
+	if input.Calories != nil {
+		item.Calories = *input.Calories
+	}
+	if input.ProteinG != nil {
+		item.ProteinG = *input.ProteinG
+	}
+	if input.CarbG != nil {
+		item.CarbG = *input.CarbG
+	}
+	if input.FatG != nil {
+		item.FatG = *input.FatG
+	}
+
+	item.Calories = roundTo(item.Calories, 2)
+	item.ProteinG = roundTo(item.ProteinG, 2)
+	item.CarbG = roundTo(item.CarbG, 2)
+	item.FatG = roundTo(item.FatG, 2)
+	return item, nil
+}
+
+// resolveRecognitionItems 补全食物项缺少的营养数值：修改过的记录直接使用各项数值，
+// 未修改的记录中缺少的宏量（早期记录）和微量营养素按各食物热量占比分摊总量
+func resolveRecognitionItems(recognition *models.FoodRecognition, items []models.RecognizedFoodItem) []models.RecognizedFoodItem {
+	if recognition.IsEdited || len(items) == 0 {
+		return items
+	}
+	// 只有早期记录的食物缺少宏量营养素，新识别的食物自带各项数值，仅需分摊缺少的微量营养素
+	perItemMacros := false
+	for _, item := range items {
+		if item.ProteinG > 0 || item.CarbG > 0 || item.FatG > 0 {
+			perItemMacros = true
+			break
+		}
+	}
+
+	var totalCalories float64
+	for _, item := range items {
+		totalCalories += item.Calories
+	}
+
+	resolved := make([]models.RecognizedFoodItem, 0, len(items))
+	for _, item := range items {
+		share := 1 / float64(len(items))
+		if totalCalories > 0 {
+			share = item.Calories / totalCalories
+		}
+		if totalCalories <= 0 {
+			item.Calories = roundTo(recognition.CaloriesIntake*share, 2)
+		}
+		if !perItemMacros {
+			item.ProteinG = roundTo(recognition.ProteinIntakeG*share, 2)
+			item.CarbG = roundTo(recognition.CarbIntakeG*share, 2)
+			item.FatG = roundTo(recognition.FatIntakeG*share, 2)
+		}
+		if len(item.Micronutrients) == 0 {
+			item.Micronutrients = recognition.Micronutrients.Scale(share)
+		}
+		resolved = append(resolved, item)
+	}
+	return resolved
+}
+
+// parseGrams 从份量描述中提取克数，无法识别时返回0
+func parseGrams(quantity string) float64 {
+	match := gramsPattern.FindStringSubmatch(quantity)
+	if match == nil {
+		return 0
+	}
+	grams, err := strconv.ParseFloat(match[1], 64)
+	if err != nil {
+		return 0
+	}
+	return grams
 }
 
 // truncateString 截断字符串，用于日志输出
@@ -245,6 +1222,14 @@ func truncateString(s string, maxLen int) string {
 
 // GetAdoptedRecognitions 获取用户已采用的食物识别记录
 func (s *FoodRecognitionService) GetAdoptedRecognitions(userID int64, page, pageSize int, startDate, endDate string) (*FoodRecognitionHistoryResult, error) {
+	// 默认查询用户时区下最近30天
+	today := s.settingService.Today(userID)
+	if startDate == "" {
+		startDate = today.AddDate(0, 0, -30).Format("2006-01-02")
+	}
+	if endDate == "" {
+		endDate = today.Format("2006-01-02")
+	}
 	log.Printf("[食物识别-历史] 查询用户(ID:%d)已采用的食物识别记录, 日期范围: %s - %s", userID, startDate, endDate)
 
 	// 解析日期
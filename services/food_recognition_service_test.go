@@ -0,0 +1,139 @@
+package services
+
+import (
+	"errors"
+	"mime/multipart"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+
+	"ome-app-back/models"
+	"ome-app-back/models/constant"
+)
+
+func TestFoodRecognitionServiceRejectsOtherUsersRecognition(t *testing.T) {
+	svc, repos := newTestServices(t)
+	const ownerID, otherID = int64(1), int64(2)
+
+	recognition, err := repos.FoodRecognitionDAO.CreateTextRecognition(ownerID, "", "两个鸡蛋一碗白粥", time.Now())
+	if err != nil {
+		t.Fatalf("创建识别记录失败: %v", err)
+	}
+
+	if _, err := svc.FoodRecognitionService.GetRecognitionByID(otherID, recognition.ID); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("GetRecognitionByID: 其他用户访问应返回 gorm.ErrRecordNotFound，实际为 %v", err)
+	}
+	if _, err := svc.FoodRecognitionService.UnadoptRecognition(recognition.ID, otherID); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("UnadoptRecognition: 其他用户访问应返回 gorm.ErrRecordNotFound，实际为 %v", err)
+	}
+
+	result, err := svc.FoodRecognitionService.GetRecognitionByID(ownerID, recognition.ID)
+	if err != nil {
+		t.Fatalf("所有者获取识别记录失败: %v", err)
+	}
+	if result.ID != recognition.ID {
+		t.Errorf("识别记录ID应为%d，实际为%d", recognition.ID, result.ID)
+	}
+}
+
+func TestFoodRecognitionDiscardsLateResult(t *testing.T) {
+	_, repos := newTestServices(t)
+	dao := repos.FoodRecognitionDAO
+
+	recognition, err := dao.CreateTextRecognition(1, "", "一碗米饭", time.Now())
+	if err != nil {
+		t.Fatalf("创建识别记录失败: %v", err)
+	}
+	if started, err := dao.StartRecognition(recognition.ID); err != nil || !started {
+		t.Fatalf("开始识别失败: %v", err)
+	}
+	foods := []models.RecognizedFoodItem{{Name: "米饭", WeightG: 150, Calories: 174}}
+	nutrition := models.FoodRecognitionNutrition{CaloriesIntake: 174}
+
+	// 超时被标记为失败后，第一次尝试的结果不能再将记录改为完成
+	if _, err := dao.FailInterruptedRecognitions(time.Now().Add(time.Minute), "识别任务中断，请重试"); err != nil {
+		t.Fatalf("标记中断任务失败: %v", err)
+	}
+	if completed, err := dao.CompleteRecognition(recognition.ID, 1, foods, nutrition, ""); err != nil || completed {
+		t.Errorf("超时后保存结果应被丢弃，completed=%v err=%v", completed, err)
+	}
+
+	// 重试后第一次尝试的结果同样被丢弃，只接受本次尝试的结果
+	if retried, err := dao.RetryRecognition(recognition.ID); err != nil || !retried {
+		t.Fatalf("重试识别失败: %v", err)
+	}
+	if started, err := dao.StartRecognition(recognition.ID); err != nil || !started {
+		t.Fatalf("重新开始识别失败: %v", err)
+	}
+	if completed, err := dao.CompleteRecognition(recognition.ID, 1, foods, nutrition, ""); err != nil || completed {
+		t.Errorf("重试后旧结果应被丢弃，completed=%v err=%v", completed, err)
+	}
+	if completed, err := dao.CompleteRecognition(recognition.ID, 2, foods, nutrition, ""); err != nil || !completed {
+		t.Fatalf("保存本次识别结果失败，completed=%v err=%v", completed, err)
+	}
+
+	got, err := dao.GetRecognitionByID(recognition.ID)
+	if err != nil {
+		t.Fatalf("获取识别记录失败: %v", err)
+	}
+	if got.Status != constant.RecognitionStatusCompleted {
+		t.Errorf("识别状态应为%s，实际为%s", constant.RecognitionStatusCompleted, got.Status)
+	}
+	if len(got.Items) != 1 {
+		t.Errorf("食物行应为1条，实际为%d", len(got.Items))
+	}
+	if completed, err := dao.CompleteRecognition(recognition.ID, 2, foods, nutrition, ""); err != nil || completed {
+		t.Errorf("已完成的记录不应被重复保存，completed=%v err=%v", completed, err)
+	}
+}
+
+func TestFoodRecognitionServiceRejectsOtherUsersSession(t *testing.T) {
+	svc, repos := newTestServices(t)
+	const ownerID, otherID = int64(1), int64(2)
+
+	session, err := svc.ChatService.CreateSession(ownerID, "午餐")
+	if err != nil {
+		t.Fatalf("创建会话失败: %v", err)
+	}
+
+	// 会话校验先于图片上传，文件内容不会被读取
+	files := []*multipart.FileHeader{{Filename: "meal.jpg"}}
+	calls := map[string]func(sessionID string) error{
+		"RecognizeText": func(sessionID string) error {
+			_, err := svc.FoodRecognitionService.RecognizeText(otherID, &RecognizeTextRequest{Text: "一碗米饭", SessionID: sessionID, Async: true})
+			return err
+		},
+		"RecognizeFood": func(sessionID string) error {
+			_, err := svc.FoodRecognitionService.RecognizeFood(otherID, sessionID, files, false)
+			return err
+		},
+		"SubmitRecognition": func(sessionID string) error {
+			_, err := svc.FoodRecognitionService.SubmitRecognition(otherID, sessionID, files, false)
+			return err
+		},
+		"PrepareRecognition": func(sessionID string) error {
+			_, err := svc.FoodRecognitionService.PrepareRecognition(otherID, sessionID, files)
+			return err
+		},
+	}
+	for name, call := range calls {
+		for _, sessionID := range []string{session.ID, "not-exist"} {
+			if err := call(sessionID); !errors.Is(err, gorm.ErrRecordNotFound) {
+				t.Errorf("%s(%q): 关联其他用户或不存在的会话应返回 gorm.ErrRecordNotFound，实际为 %v", name, sessionID, err)
+			}
+		}
+	}
+
+	result, err := svc.FoodRecognitionService.RecognizeText(ownerID, &RecognizeTextRequest{Text: "一碗米饭", SessionID: session.ID, Async: true})
+	if err != nil {
+		t.Fatalf("所有者关联自己的会话失败: %v", err)
+	}
+	recognition, err := repos.FoodRecognitionDAO.GetRecognitionByID(result.ID)
+	if err != nil {
+		t.Fatalf("获取识别记录失败: %v", err)
+	}
+	if recognition.SessionID != session.ID {
+		t.Errorf("识别记录的会话ID应为%s，实际为%s", session.ID, recognition.SessionID)
+	}
+}
@@ -0,0 +1,76 @@
+package services
+
+import (
+	"ome-app-back/config"
+	"ome-app-back/models"
+	"ome-app-back/repositories"
+)
+
+// FoodService 食物库服务
+type FoodService struct {
+	foodItemDAO  *repositories.FoodItemDAO
+	nutritionCfg *config.NutritionConfig
+}
+
+// NewFoodService 创建食物库服务实例
+func NewFoodService(foodItemDAO *repositories.FoodItemDAO, nutritionCfg *config.NutritionConfig) *FoodService {
+	return &FoodService{
+		foodItemDAO:  foodItemDAO,
+		nutritionCfg: nutritionCfg,
+	}
+}
+
+// CreateFoodRequest 创建自定义食物请求，营养数值按每100克填写
+type CreateFoodRequest struct {
+	Name            string                `json:"name" binding:"required,max=100"`
+	Category        string                `json:"category" binding:"max=50"`
+	CaloriesPer100G float64               `json:"calories_per_100g" binding:"gte=0"`
+	ProteinPer100G  float64               `json:"protein_per_100g" binding:"gte=0,lte=100"`
+	CarbPer100G     float64               `json:"carb_per_100g" binding:"gte=0,lte=100"`
+	FatPer100G      float64               `json:"fat_per_100g" binding:"gte=0,lte=100"`
+	Micronutrients  models.Micronutrients `json:"micronutrients"`
+}
+
+// SearchFoodRequest 搜索食物请求
+type SearchFoodRequest struct {
+	Keyword  string `form:"keyword"`
+	Category string `form:"category"`
+	Limit    int    `form:"limit"`
+}
+
+// CreateFood 创建用户自定义食物（仅创建者可见）
+func (s *FoodService) CreateFood(userID int64, req *CreateFoodRequest) (*models.FoodItem, error) {
+	item := &models.FoodItem{
+		Name:            req.Name,
+		Category:        req.Category,
+		CaloriesPer100G: req.CaloriesPer100G,
+		ProteinPer100G:  req.ProteinPer100G,
+		CarbPer100G:     req.CarbPer100G,
+		FatPer100G:      req.FatPer100G,
+		Micronutrients:  req.Micronutrients.Pick(s.nutritionCfg.MicronutrientKeys()),
+		CreatedBy:       userID,
+	}
+	if err := s.foodItemDAO.Create(item); err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// GetFood 获取食物详情
+func (s *FoodService) GetFood(userID, itemID int64) (*models.FoodItem, error) {
+	return s.foodItemDAO.GetByID(userID, itemID)
+}
+
+// SearchFoods 搜索食物库
+func (s *FoodService) SearchFoods(userID int64, req *SearchFoodRequest) ([]models.FoodItem, error) {
+	limit := 20
+	if req.Limit > 0 && req.Limit <= 100 {
+		limit = req.Limit
+	}
+	return s.foodItemDAO.Search(userID, req.Keyword, req.Category, limit)
+}
+
+// DeleteFood 删除用户自定义食物
+func (s *FoodService) DeleteFood(userID, itemID int64) error {
+	return s.foodItemDAO.Delete(userID, itemID)
+}
@@ -6,6 +6,7 @@ import (
 	"math"
 	"time"
 
+	"ome-app-back/config"
 	"ome-app-back/models"
 	"ome-app-back/repositories"
 )
@@ -17,6 +18,9 @@ type HealthAnalysisService struct {
 	userHeightDAO     *repositories.UserHeightDAO
 	userGoalDAO       *repositories.UserGoalDAO
 	healthAnalysisDAO *repositories.HealthAnalysisDAO
+	nutritionDAO      *repositories.DailyNutritionDAO
+	settingService    *UserSettingService
+	nutritionCfg      *config.NutritionConfig
 }
 
 // NewHealthAnalysisService 创建健康分析服务实例
@@ -26,6 +30,9 @@ func NewHealthAnalysisService(
 	userHeightDAO *repositories.UserHeightDAO,
 	userGoalDAO *repositories.UserGoalDAO,
 	healthAnalysisDAO *repositories.HealthAnalysisDAO,
+	nutritionDAO *repositories.DailyNutritionDAO,
+	settingService *UserSettingService,
+	nutritionCfg *config.NutritionConfig,
 ) *HealthAnalysisService {
 	return &HealthAnalysisService{
 		userDAO:           userDAO,
@@ -33,6 +40,9 @@ func NewHealthAnalysisService(
 		userHeightDAO:     userHeightDAO,
 		userGoalDAO:       userGoalDAO,
 		healthAnalysisDAO: healthAnalysisDAO,
+		nutritionDAO:      nutritionDAO,
+		settingService:    settingService,
+		nutritionCfg:      nutritionCfg,
 	}
 }
 
@@ -57,6 +67,8 @@ type AnalysisResponse struct {
 	WeeklyChangeKG      float64 `json:"weekly_change_kg"`
 	TargetDate          string  `json:"target_date"`
 	DaysToTarget        int     `json:"days_to_target"`
+
+	MicronutrientTargets models.Micronutrients `json:"micronutrient_targets"` // 每日微量营养素目标
 }
 
 // GenerateAnalysis 生成健康分析报告
@@ -115,6 +127,9 @@ func (s *HealthAnalysisService) GenerateAnalysis(req AnalysisRequest) (*Analysis
 	// 计算营养素建议
 	proteinNeedG, carbNeedG, fatNeedG := calculateNutrientNeeds(recommendedCalories, weightRecord.WeightKG, goal.GoalType)
 
+	// 计算微量营养素目标
+	micronutrientTargets := calculateMicronutrientTargets(recommendedCalories, s.nutritionCfg.GetMicronutrients())
+
 	// 计算距离目标日期天数
 	daysToTarget := int(math.Ceil(time.Until(goal.TargetDate).Hours() / 24))
 	if daysToTarget < 0 {
@@ -127,6 +142,7 @@ func (s *HealthAnalysisService) GenerateAnalysis(req AnalysisRequest) (*Analysis
 		weightRecord.WeightKG, goal.TargetWeightKG, weeklyChangeKG,
 		daysToTarget, goal.GoalType, proteinNeedG, carbNeedG, fatNeedG,
 	)
+	analysisContent += generateMicronutrientContent(micronutrientTargets, s.nutritionCfg.GetMicronutrients())
 
 	// 保存分析结果到数据库
 	analysis := &models.HealthAnalysis{
@@ -139,10 +155,15 @@ func (s *HealthAnalysisService) GenerateAnalysis(req AnalysisRequest) (*Analysis
 		FatNeedG:            fatNeedG,
 		RecommendedCalories: recommendedCalories,
 		AnalysisContent:     analysisContent,
+
+		MicronutrientTargets: micronutrientTargets,
 	}
 	if err := s.healthAnalysisDAO.Create(analysis); err != nil {
 		// 保存失败不影响返回结果
 		fmt.Println("保存健康分析结果失败:", err)
+	} else {
+		// 新的目标对今天及之后已创建的营养记录生效，历史记录保留当时的目标
+		s.syncNutritionTargets(analysis)
 	}
 
 	// 组装返回结果
@@ -161,9 +182,27 @@ func (s *HealthAnalysisService) GenerateAnalysis(req AnalysisRequest) (*Analysis
 		WeeklyChangeKG:      weeklyChangeKG, // 使用调整后的值
 		TargetDate:          goal.TargetDate.Format("2006-01-02"),
 		DaysToTarget:        daysToTarget,
+
+		MicronutrientTargets: micronutrientTargets,
 	}, nil
 }
 
+// syncNutritionTargets 将健康分析的目标值同步到用户时区的今天及之后的营养记录，同步失败不影响分析结果
+func (s *HealthAnalysisService) syncNutritionTargets(analysis *models.HealthAnalysis) {
+	_, err := s.nutritionDAO.UpdateTargetsFromDate(analysis.UserID, s.settingService.Today(analysis.UserID), &repositories.CreateNutritionParams{
+		UserID:         analysis.UserID,
+		TargetCalories: analysis.RecommendedCalories,
+		TargetProteinG: analysis.ProteinNeedG,
+		TargetCarbG:    analysis.CarbNeedG,
+		TargetFatG:     analysis.FatNeedG,
+
+		TargetMicronutrients: analysis.MicronutrientTargets,
+	})
+	if err != nil {
+		fmt.Println("同步营养目标失败:", err)
+	}
+}
+
 // 计算BMI
 func calculateBMI(weightKg float64, heightCm float64) float64 {
 	heightM := heightCm / 100.0
@@ -262,6 +301,37 @@ func calculateNutrientNeeds(calories float64, weightKg float64, goalType string)
 	return protein, carb, fat
 }
 
+// 计算微量营养素目标
+func calculateMicronutrientTargets(calories float64, micronutrients []config.MicronutrientConfig) models.Micronutrients {
+	targets := make(models.Micronutrients, len(micronutrients))
+	for _, m := range micronutrients {
+		target := m.DefaultTarget
+		// 与热量相关的营养素（如膳食纤维、添加糖）按推荐热量折算
+		if m.Per1000Kcal > 0 && calories > 0 {
+			target = m.Per1000Kcal * calories / 1000
+		}
+		targets[m.Key] = math.Round(target)
+	}
+	return targets
+}
+
+// 生成微量营养素建议文本
+func generateMicronutrientContent(targets models.Micronutrients, micronutrients []config.MicronutrientConfig) string {
+	if len(micronutrients) == 0 {
+		return ""
+	}
+
+	content := "\n\n微量营养素建议："
+	for _, m := range micronutrients {
+		limitWord := "不少于"
+		if m.LimitType == "max" {
+			limitWord = "不超过"
+		}
+		content += fmt.Sprintf("\n- %s：%s%.0f%s", m.Name, limitWord, targets[m.Key], m.Unit)
+	}
+	return content
+}
+
 // 生成分析文本内容
 func generateAnalysisContent(
 	bmi float64, bmiCategory string, bmr float64, tdee float64, recommendedCalories float64,
@@ -10,13 +10,15 @@ import (
 
 // HeightService 处理用户身高相关业务逻辑
 type HeightService struct {
-	heightDAO *repositories.UserHeightDAO
+	heightDAO      *repositories.UserHeightDAO
+	settingService *UserSettingService
 }
 
 // NewHeightService 创建身高服务实例
-func NewHeightService(heightDAO *repositories.UserHeightDAO) *HeightService {
+func NewHeightService(heightDAO *repositories.UserHeightDAO, settingService *UserSettingService) *HeightService {
 	return &HeightService{
-		heightDAO: heightDAO,
+		heightDAO:      heightDAO,
+		settingService: settingService,
 	}
 }
 
@@ -32,8 +34,8 @@ func (s *HeightService) CreateHeight(userID int64, req CreateHeightRequest) erro
 		return errors.New("身高必须在50-300厘米之间")
 	}
 
-	// 检查今天是否已有身高记录
-	today := time.Now()
+	// 检查用户时区下的今天是否已有身高记录
+	today := s.settingService.Today(userID)
 	existingHeight, err := s.heightDAO.GetHeightByDate(userID, today)
 	if err != nil {
 		return err
@@ -110,7 +112,7 @@ func (s *HeightService) GetCurrentHeight(userID int64) (*GetCurrentHeightRespons
 		return nil, errors.New("没有身高记录")
 	}
 
-	daysAgo := int(time.Since(height.RecordDate).Hours() / 24)
+	daysAgo := int(s.settingService.Today(userID).Sub(height.RecordDate).Hours() / 24)
 
 	return &GetCurrentHeightResponse{
 		HeightCM:   height.HeightCM,
@@ -119,19 +121,9 @@ func (s *HeightService) GetCurrentHeight(userID int64) (*GetCurrentHeightRespons
 	}, nil
 }
 
-// DeleteHeight 删除身高记录
+// DeleteHeight 删除身高记录，记录不存在或不属于该用户时返回 gorm.ErrRecordNotFound
 func (s *HeightService) DeleteHeight(userID int64, heightID int64) error {
-	// 验证记录是否属于当前用户
-	height, err := s.heightDAO.GetByID(heightID)
-	if err != nil {
-		return err
-	}
-
-	if height.UserID != userID {
-		return errors.New("只能删除自己的身高记录")
-	}
-
-	return s.heightDAO.Delete(heightID)
+	return s.heightDAO.Delete(userID, heightID)
 }
 
 // GetHeightStatisticsRequest 获取身高统计请求
@@ -155,7 +147,9 @@ func (s *HeightService) GetHeightStatistics(userID int64, req GetHeightStatistic
 		req.Days = 30 // 默认30天
 	}
 
-	stats, err := s.heightDAO.GetHeightStatistics(userID, req.Days)
+	// 按用户时区的日期计算统计起始日
+	startDate := s.settingService.Today(userID).AddDate(0, 0, -req.Days)
+	stats, err := s.heightDAO.GetHeightStatistics(userID, startDate)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,36 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+func TestHeightServiceRejectsOtherUsersHeight(t *testing.T) {
+	svc, repos := newTestServices(t)
+	const ownerID, otherID = int64(1), int64(2)
+
+	if err := svc.HeightService.CreateHeight(ownerID, CreateHeightRequest{HeightCM: 170}); err != nil {
+		t.Fatalf("创建身高记录失败: %v", err)
+	}
+	height, err := repos.UserHeightDAO.GetCurrentHeight(ownerID)
+	if err != nil || height == nil {
+		t.Fatalf("获取身高记录失败: %v", err)
+	}
+
+	if err := svc.HeightService.DeleteHeight(otherID, height.ID); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("其他用户删除身高记录应返回 gorm.ErrRecordNotFound，实际为 %v", err)
+	}
+	if err := svc.HeightService.DeleteHeight(ownerID, height.ID+1); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("删除不存在的身高记录应返回 gorm.ErrRecordNotFound，实际为 %v", err)
+	}
+
+	// 记录保持不变，所有者可以删除
+	if got, err := repos.UserHeightDAO.GetCurrentHeight(ownerID); err != nil || got == nil || got.ID != height.ID {
+		t.Fatalf("身高记录被其他用户删除: %v", err)
+	}
+	if err := svc.HeightService.DeleteHeight(ownerID, height.ID); err != nil {
+		t.Errorf("所有者删除身高记录失败: %v", err)
+	}
+}
@@ -0,0 +1,319 @@
+package services
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/draw"
+)
+
+// EXIF 相关标记
+const (
+	exifTagOrientation = 0x0112
+	exifTagGPSInfo     = 0x8825
+	jpegMarkerAPP1     = 0xE1
+	jpegMarkerSOS      = 0xDA
+)
+
+// exifTypeSizes EXIF 各数据类型单个值占用的字节数
+var exifTypeSizes = map[uint16]uint32{
+	1: 1, 2: 1, 3: 2, 4: 4, 5: 8, 6: 1, 7: 1, 8: 2, 9: 4, 10: 8, 11: 4, 12: 8,
+}
+
+// exifHeader JPEG APP1 段中 EXIF 数据的标识
+var exifHeader = []byte("Exif\x00\x00")
+
+// findJPEGExif 返回 JPEG 中 EXIF TIFF 数据的起止位置，不存在时返回 -1
+func findJPEGExif(data []byte) (start, end int) {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return -1, -1
+	}
+	pos := 2
+	for pos+4 <= len(data) {
+		if data[pos] != 0xFF {
+			return -1, -1
+		}
+		marker := data[pos+1]
+		if marker == jpegMarkerSOS {
+			return -1, -1
+		}
+		length := int(binary.BigEndian.Uint16(data[pos+2 : pos+4]))
+		segmentEnd := pos + 2 + length
+		if length < 2 || segmentEnd > len(data) {
+			return -1, -1
+		}
+		payload := data[pos+4 : segmentEnd]
+		if marker == jpegMarkerAPP1 && bytes.HasPrefix(payload, exifHeader) {
+			return pos + 4 + len(exifHeader), segmentEnd
+		}
+		pos = segmentEnd
+	}
+	return -1, -1
+}
+
+// exifReader 读取 TIFF 结构中的 IFD 条目
+type exifReader struct {
+	tiff  []byte
+	order binary.ByteOrder
+}
+
+func newExifReader(tiff []byte) *exifReader {
+	if len(tiff) < 8 {
+		return nil
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return nil
+	}
+	return &exifReader{tiff: tiff, order: order}
+}
+
+// ifdEntries 返回指定偏移处 IFD 的条目数量与条目起始位置
+func (r *exifReader) ifdEntries(offset uint32) (count int, start int, ok bool) {
+	if int(offset)+2 > len(r.tiff) {
+		return 0, 0, false
+	}
+	count = int(r.order.Uint16(r.tiff[offset:]))
+	start = int(offset) + 2
+	if start+count*12 > len(r.tiff) {
+		return 0, 0, false
+	}
+	return count, start, true
+}
+
+// findTag 在 IFD0 中查找指定标签，返回条目位置
+func (r *exifReader) findTag(tag uint16) (int, bool) {
+	count, start, ok := r.ifdEntries(r.order.Uint32(r.tiff[4:]))
+	if !ok {
+		return 0, false
+	}
+	for i := 0; i < count; i++ {
+		entry := start + i*12
+		if r.order.Uint16(r.tiff[entry:]) == tag {
+			return entry, true
+		}
+	}
+	return 0, false
+}
+
+// jpegOrientation 读取 JPEG 的 EXIF 方向标记，缺失或无效时返回1
+func jpegOrientation(data []byte) int {
+	start, end := findJPEGExif(data)
+	if start < 0 {
+		return 1
+	}
+	r := newExifReader(data[start:end])
+	if r == nil {
+		return 1
+	}
+	entry, ok := r.findTag(exifTagOrientation)
+	if !ok {
+		return 1
+	}
+	orientation := int(r.order.Uint16(r.tiff[entry+8:]))
+	if orientation < 1 || orientation > 8 {
+		return 1
+	}
+	return orientation
+}
+
+// stripJPEGGPS 返回清除了 EXIF GPS 信息的 JPEG 数据副本，其余元数据保持不变
+func stripJPEGGPS(data []byte) []byte {
+	result := append([]byte(nil), data...)
+	start, end := findJPEGExif(result)
+	if start < 0 {
+		return result
+	}
+	r := newExifReader(result[start:end])
+	if r == nil {
+		return result
+	}
+	entry, ok := r.findTag(exifTagGPSInfo)
+	if !ok {
+		return result
+	}
+	count, entriesStart, ok := r.ifdEntries(r.order.Uint32(r.tiff[entry+8:]))
+	if !ok {
+		return result
+	}
+
+	// 先清除条目引用的外部数据，再清空条目本身
+	for i := 0; i < count; i++ {
+		e := entriesStart + i*12
+		size := exifTypeSizes[r.order.Uint16(r.tiff[e+2:])] * r.order.Uint32(r.tiff[e+4:])
+		if size > 4 {
+			offset := r.order.Uint32(r.tiff[e+8:])
+			if uint64(offset)+uint64(size) <= uint64(len(r.tiff)) {
+				clear(r.tiff[offset : offset+size])
+			}
+		}
+	}
+	clear(r.tiff[entriesStart : entriesStart+count*12])
+	r.order.PutUint16(r.tiff[entriesStart-2:], 0)
+	return result
+}
+
+// applyOrientation 按 EXIF 方向标记旋转/翻转图片，使其按正常方向显示
+func applyOrientation(img image.Image, orientation int) image.Image {
+	if orientation <= 1 || orientation > 8 {
+		return img
+	}
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	src := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(src, src.Bounds(), img, b.Min, draw.Src)
+
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		for x := 0; x < dw; x++ {
+			var sx, sy int
+			switch orientation {
+			case 2: // 水平翻转
+				sx, sy = w-1-x, y
+			case 3: // 旋转180度
+				sx, sy = w-1-x, h-1-y
+			case 4: // 垂直翻转
+				sx, sy = x, h-1-y
+			case 5: // 沿主对角线翻转
+				sx, sy = y, x
+			case 6: // 顺时针旋转90度
+				sx, sy = y, h-1-x
+			case 7: // 沿副对角线翻转
+				sx, sy = w-1-y, h-1-x
+			case 8: // 逆时针旋转90度
+				sx, sy = w-1-y, x
+			}
+			dst.SetRGBA(x, y, src.RGBAAt(sx, sy))
+		}
+	}
+	return dst
+}
+
+// 元数据块标识
+var (
+	xmpHeader         = []byte("http://ns.adobe.com/xap/1.0/\x00")
+	xmpExtendedHeader = []byte("http://ns.adobe.com/xmp/extension/\x00")
+	pngSignature      = []byte("\x89PNG\r\n\x1a\n")
+)
+
+// pngMetadataChunks PNG 中可能携带定位信息的元数据块（EXIF 及文本块，XMP 存放在 iTXt 中）
+var pngMetadataChunks = map[string]bool{"eXIf": true, "tEXt": true, "zTXt": true, "iTXt": true}
+
+// stripImageMetadata 返回清除了定位等元数据的原图副本
+// JPEG 清除 EXIF GPS 与 XMP，PNG/WebP 去掉 EXIF 与 XMP 数据块，GIF/BMP 不含此类元数据原样返回；
+// 格式不支持或结构无法解析时返回 false，调用方应改为保存重新编码的图片
+func stripImageMetadata(data []byte, mimeType string) ([]byte, bool) {
+	switch mimeType {
+	case mimeJPEG:
+		return stripJPEGXMP(stripJPEGGPS(data))
+	case mimePNG:
+		return stripPNGMetadata(data)
+	case mimeWebP:
+		return stripWebPMetadata(data)
+	case mimeGIF, mimeBMP:
+		return data, true
+	default:
+		return nil, false
+	}
+}
+
+// stripJPEGXMP 去掉 JPEG 中存放 XMP 的 APP1 段
+func stripJPEGXMP(data []byte) ([]byte, bool) {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return nil, false
+	}
+	result := make([]byte, 0, len(data))
+	result = append(result, data[:2]...)
+	pos := 2
+	for pos+4 <= len(data) {
+		if data[pos] != 0xFF {
+			return nil, false
+		}
+		if data[pos+1] == jpegMarkerSOS {
+			return append(result, data[pos:]...), true
+		}
+		length := int(binary.BigEndian.Uint16(data[pos+2 : pos+4]))
+		segmentEnd := pos + 2 + length
+		if length < 2 || segmentEnd > len(data) {
+			return nil, false
+		}
+		payload := data[pos+4 : segmentEnd]
+		if data[pos+1] != jpegMarkerAPP1 || !(bytes.HasPrefix(payload, xmpHeader) || bytes.HasPrefix(payload, xmpExtendedHeader)) {
+			result = append(result, data[pos:segmentEnd]...)
+		}
+		pos = segmentEnd
+	}
+	return nil, false
+}
+
+// stripPNGMetadata 去掉 PNG 中的 EXIF 与文本块，IEND 之后的数据一并丢弃
+func stripPNGMetadata(data []byte) ([]byte, bool) {
+	if !bytes.HasPrefix(data, pngSignature) {
+		return nil, false
+	}
+	result := make([]byte, 0, len(data))
+	result = append(result, pngSignature...)
+	pos := len(pngSignature)
+	for pos+12 <= len(data) {
+		length := binary.BigEndian.Uint32(data[pos:])
+		chunkType := string(data[pos+4 : pos+8])
+		chunkEnd := uint64(pos) + 12 + uint64(length)
+		if chunkEnd > uint64(len(data)) {
+			return nil, false
+		}
+		if !pngMetadataChunks[chunkType] {
+			result = append(result, data[pos:chunkEnd]...)
+		}
+		if chunkType == "IEND" {
+			return result, true
+		}
+		pos = int(chunkEnd)
+	}
+	return nil, false
+}
+
+// stripWebPMetadata 去掉 WebP 中的 EXIF 与 XMP 块，并清除 VP8X 中对应的标志位
+func stripWebPMetadata(data []byte) ([]byte, bool) {
+	if len(data) < 12 || string(data[:4]) != "RIFF" || string(data[8:12]) != "WEBP" {
+		return nil, false
+	}
+	riffEnd := uint64(binary.LittleEndian.Uint32(data[4:])) + 8
+	if riffEnd > uint64(len(data)) {
+		return nil, false
+	}
+	result := make([]byte, 0, riffEnd)
+	result = append(result, data[:12]...)
+	pos := 12
+	for uint64(pos)+8 <= riffEnd {
+		chunkType := string(data[pos : pos+4])
+		size := uint64(binary.LittleEndian.Uint32(data[pos+4:]))
+		chunkEnd := uint64(pos) + 8 + size + size%2
+		if chunkEnd > riffEnd {
+			return nil, false
+		}
+		switch chunkType {
+		case "EXIF", "XMP ":
+		case "VP8X":
+			start := len(result)
+			result = append(result, data[pos:chunkEnd]...)
+			if size > 0 {
+				// 第1字节的第3、4位分别表示含有 XMP、EXIF
+				result[start+8] &^= 0x0C
+			}
+		default:
+			result = append(result, data[pos:chunkEnd]...)
+		}
+		pos = int(chunkEnd)
+	}
+	binary.LittleEndian.PutUint32(result[4:], uint32(len(result)-8))
+	return result, true
+}
@@ -0,0 +1,43 @@
+package services
+
+import (
+	"fmt"
+	"image"
+	"math/bits"
+	"strconv"
+
+	"golang.org/x/image/draw"
+)
+
+// 感知哈希采用差异哈希(dHash)：缩放为9x8灰度图，比较每行相邻像素的亮度得到64位
+const (
+	imageHashWidth  = 9
+	imageHashHeight = 8
+)
+
+// perceptualHash 计算图片的感知哈希，内容相近的图片哈希的汉明距离较小
+func perceptualHash(img image.Image) string {
+	gray := image.NewGray(image.Rect(0, 0, imageHashWidth, imageHashHeight))
+	draw.CatmullRom.Scale(gray, gray.Bounds(), img, img.Bounds(), draw.Src, nil)
+
+	var hash uint64
+	for y := 0; y < imageHashHeight; y++ {
+		for x := 0; x < imageHashWidth-1; x++ {
+			hash <<= 1
+			if gray.GrayAt(x, y).Y > gray.GrayAt(x+1, y).Y {
+				hash |= 1
+			}
+		}
+	}
+	return fmt.Sprintf("%016x", hash)
+}
+
+// imageHashDistance 计算两个感知哈希的汉明距离，哈希无效时返回 -1
+func imageHashDistance(a, b string) int {
+	x, errA := strconv.ParseUint(a, 16, 64)
+	y, errB := strconv.ParseUint(b, 16, 64)
+	if errA != nil || errB != nil {
+		return -1
+	}
+	return bits.OnesCount64(x ^ y)
+}
@@ -0,0 +1,192 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	// 注册可解码的图片格式
+	_ "image/gif"
+	_ "image/png"
+
+	_ "golang.org/x/image/bmp"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// 图片处理错误
+var (
+	ErrInvalidImage           = errors.New("上传的文件不是有效的图片")
+	ErrUnsupportedImageFormat = errors.New("不支持的图片格式")
+)
+
+// 支持的图片MIME类型
+const (
+	mimeJPEG = "image/jpeg"
+	mimePNG  = "image/png"
+	mimeGIF  = "image/gif"
+	mimeWebP = "image/webp"
+	mimeBMP  = "image/bmp"
+	mimeHEIC = "image/heic"
+)
+
+// heicConvertTimeout HEIC外部转换命令的超时时间
+const heicConvertTimeout = 30 * time.Second
+
+// originalJPEGQuality 原图无法原样清除元数据、改为重新编码保存时使用的JPEG质量
+const originalJPEGQuality = 95
+
+// imageExtensions 各MIME类型保存原图时使用的扩展名
+var imageExtensions = map[string]string{
+	mimeJPEG: ".jpg",
+	mimePNG:  ".png",
+	mimeGIF:  ".gif",
+	mimeWebP: ".webp",
+	mimeBMP:  ".bmp",
+	mimeHEIC: ".heic",
+}
+
+// heicBrands HEIF容器中表示HEIC图片的品牌标识
+var heicBrands = map[string]bool{
+	"heic": true, "heix": true, "hevc": true, "hevx": true,
+	"heim": true, "heis": true, "mif1": true, "msf1": true,
+}
+
+// ProcessedImage 预处理后的图片信息
+type ProcessedImage struct {
+	Path         string // 处理后的JPEG相对路径，用于AI识别和展示
+	OriginalPath string // 原图相对路径
+	OriginalMIME string // 保存的原图MIME类型，HEIC等无法原样清除元数据的原图保存为 image/jpeg
+	MIMEType     string // 处理后的MIME类型，始终为 image/jpeg
+	Width        int    // 处理后宽度
+	Height       int    // 处理后高度
+	Hash         string // 摆正方向后图片的感知哈希(16位十六进制)
+}
+
+// detectImageMIME 根据文件内容识别图片的真实MIME类型，不是支持的图片时返回空字符串
+func detectImageMIME(data []byte) string {
+	// HEIC 为ISO BMFF容器：第4-8字节为 ftyp，随后为品牌标识
+	if len(data) >= 12 && string(data[4:8]) == "ftyp" && heicBrands[string(data[8:12])] {
+		return mimeHEIC
+	}
+	mimeType := http.DetectContentType(data)
+	if _, ok := imageExtensions[mimeType]; ok {
+		return mimeType
+	}
+	return ""
+}
+
+// decodeImage 解码图片并按EXIF方向摆正；HEIC通过外部命令转为JPEG后解码
+func (s *FileService) decodeImage(data []byte, mimeType string) (image.Image, error) {
+	orientation := 1
+	if mimeType == mimeHEIC {
+		converted, err := s.convertHEIC(data)
+		if err != nil {
+			return nil, err
+		}
+		// 转换工具输出的JPEG已按HEIC的旋转信息摆正
+		data = converted
+	} else if mimeType == mimeJPEG {
+		orientation = jpegOrientation(data)
+	}
+
+	// 先只读取尺寸，避免体积很小但像素极多的图片在解码时耗尽内存
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImage, err)
+	}
+	if maxPixels := s.imageCfg.GetMaxPixels(); cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width > maxPixels/cfg.Height {
+		return nil, fmt.Errorf("%w: 图片尺寸%dx%d超过上限(%d像素)", ErrInvalidImage, cfg.Width, cfg.Height, maxPixels)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImage, err)
+	}
+	return applyOrientation(img, orientation), nil
+}
+
+// convertHEIC 调用配置的外部命令将HEIC转为JPEG
+func (s *FileService) convertHEIC(data []byte) ([]byte, error) {
+	args := strings.Fields(s.imageCfg.GetHEICConverter())
+	if len(args) == 0 {
+		return nil, fmt.Errorf("%w: 未配置HEIC转换工具", ErrUnsupportedImageFormat)
+	}
+	if _, err := exec.LookPath(args[0]); err != nil {
+		return nil, fmt.Errorf("%w: 服务器未安装HEIC转换工具", ErrUnsupportedImageFormat)
+	}
+
+	tmpDir, err := os.MkdirTemp("", "heic_")
+	if err != nil {
+		return nil, fmt.Errorf("创建临时目录失败: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	input := filepath.Join(tmpDir, "input.heic")
+	output := filepath.Join(tmpDir, "output.jpg")
+	if err := os.WriteFile(input, data, 0644); err != nil {
+		return nil, fmt.Errorf("写入临时文件失败: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), heicConvertTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, args[0], append(args[1:], input, output)...)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("%w: HEIC转换失败: %v, %s", ErrInvalidImage, err, truncateString(string(out), 200))
+	}
+
+	converted, err := os.ReadFile(output)
+	if err != nil {
+		return nil, fmt.Errorf("读取HEIC转换结果失败: %v", err)
+	}
+	return converted, nil
+}
+
+// resizeAndEncode 将图片缩放到最长边不超过配置值，透明区域填充为白色，并压缩为JPEG
+func (s *FileService) resizeAndEncode(img image.Image) ([]byte, int, int, error) {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w == 0 || h == 0 {
+		return nil, 0, 0, ErrInvalidImage
+	}
+	if maxDim := s.imageCfg.GetMaxDimension(); w > maxDim || h > maxDim {
+		if w >= h {
+			w, h = maxDim, max(1, h*maxDim/b.Dx())
+		} else {
+			w, h = max(1, w*maxDim/b.Dy()), maxDim
+		}
+	}
+
+	data, err := encodeJPEG(img, w, h, s.imageCfg.GetJPEGQuality())
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	return data, w, h, nil
+}
+
+// encodeJPEG 将图片缩放到指定尺寸，透明区域填充为白色后编码为JPEG，输出不包含任何元数据
+func encodeJPEG(img image.Image, w, h, quality int) ([]byte, error) {
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	if b := img.Bounds(); b.Dx() == w && b.Dy() == h {
+		draw.Draw(dst, dst.Bounds(), img, b.Min, draw.Over)
+	} else {
+		draw.CatmullRom.Scale(dst, dst.Bounds(), img, b, draw.Over, nil)
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: quality}); err != nil {
+		return nil, fmt.Errorf("图片压缩失败: %v", err)
+	}
+	return buf.Bytes(), nil
+}
@@ -18,30 +18,96 @@ type Services struct {
 	MoodService            *MoodService
 	WeightService          *WeightService
 	HeightService          *HeightService
+	WaterService           *WaterService
+	DashboardService       *DashboardService
+	NutritionReportService *NutritionReportService
+	FoodService            *FoodService
+	RecipeService          *RecipeService
+	MealPlanService        *MealPlanService
+	RecommendationService  *RecommendationService
+	UserSettingService     *UserSettingService
+	FavoriteFoodService    *FavoriteFoodService
+	DietScoreService       *DietScoreService
+	AdminService           *AdminService
 }
 
 // Init 初始化所有业务服务
 func Init(repos *repositories.Repositories, cfg *config.Config) *Services {
 	// 初始化基础服务
 	fileService := NewFileService(&cfg.Upload)
-	aiService := NewAIService(&cfg.AI)
+	aiService := NewAIService(&cfg.AI, &cfg.Nutrition)
 
 	// 初始化业务服务
-	userService := NewUserService(repos.AppUserDAO, repos.UserWeightDAO, repos.UserGoalDAO)
-	healthAnalysisService := NewHealthAnalysisService(repos.AppUserDAO, repos.UserWeightDAO, repos.UserHeightDAO, repos.UserGoalDAO, repos.HealthAnalysisDAO)
-	nutritionService := NewNutritionService(repos.DailyNutritionDAO, repos.HealthAnalysisDAO)
-	chatService := NewChatService(repos.ChatDAO, aiService)
+	userSettingService := NewUserSettingService(repos.UserSettingDAO)
+	userService := NewUserService(repos.AppUserDAO, repos.UserWeightDAO, repos.UserGoalDAO, userSettingService)
+	healthAnalysisService := NewHealthAnalysisService(repos.AppUserDAO, repos.UserWeightDAO, repos.UserHeightDAO, repos.UserGoalDAO, repos.HealthAnalysisDAO, repos.DailyNutritionDAO, userSettingService, &cfg.Nutrition)
+	waterService := NewWaterService(repos.WaterRecordDAO, repos.UserWeightDAO, repos.UserExerciseDAO, userSettingService)
+	allergenService := NewAllergenService(repos.UserGoalDAO)
+	nutritionService := NewNutritionService(
+		repos.DailyNutritionDAO,
+		repos.HealthAnalysisDAO,
+		repos.NutritionEntryDAO,
+		repos.UserExerciseDAO,
+		waterService,
+		allergenService,
+		userSettingService,
+		&cfg.Nutrition,
+	)
 	foodRecognitionService := NewFoodRecognitionService(
 		repos.FoodRecognitionDAO,
 		repos.DailyNutritionDAO,
+		repos.NutritionEntryDAO,
 		repos.HealthAnalysisDAO,
+		repos.FoodItemDAO,
+		repos.ChatDAO,
+		nutritionService,
 		fileService,
 		aiService,
+		allergenService,
+		userSettingService,
+		&cfg.AI,
+		&cfg.Nutrition,
+	)
+	chatService := NewChatService(repos.ChatDAO, aiService, foodRecognitionService)
+	exerciseService := NewExerciseService(repos.UserExerciseDAO, userSettingService)
+	moodService := NewMoodService(repos.MoodRecordDAO, userSettingService)
+	weightService := NewWeightService(repos.UserWeightDAO, userSettingService)
+	heightService := NewHeightService(repos.UserHeightDAO, userSettingService)
+	nutritionReportService := NewNutritionReportService(repos.DailyNutritionDAO, userSettingService)
+	foodService := NewFoodService(repos.FoodItemDAO, &cfg.Nutrition)
+	recipeService := NewRecipeService(repos.RecipeDAO, repos.FoodItemDAO, nutritionService, userSettingService, &cfg.Nutrition)
+	mealPlanService := NewMealPlanService(
+		repos.MealPlanDAO,
+		repos.UserGoalDAO,
+		repos.HealthAnalysisDAO,
+		nutritionService,
+		userSettingService,
+		aiService,
+	)
+	recommendationService := NewRecommendationService(
+		nutritionService,
+		userSettingService,
+		repos.UserGoalDAO,
+		repos.FoodRecognitionDAO,
+		repos.RecipeDAO,
+		aiService,
+	)
+	favoriteFoodService := NewFavoriteFoodService(
+		repos.FavoriteFoodDAO,
+		repos.NutritionEntryDAO,
+		repos.FoodRecognitionDAO,
+		nutritionService,
+		userSettingService,
+	)
+	dietScoreService := NewDietScoreService(
+		repos.DailyNutritionDAO,
+		repos.NutritionEntryDAO,
+		repos.FoodRecognitionDAO,
+		userSettingService,
+		&cfg.Nutrition,
 	)
-	exerciseService := NewExerciseService(repos.UserExerciseDAO)
-	moodService := NewMoodService(repos.MoodRecordDAO)
-	weightService := NewWeightService(repos.UserWeightDAO)
-	heightService := NewHeightService(repos.UserHeightDAO)
+	dashboardService := NewDashboardService(nutritionService, waterService, exerciseService, recommendationService)
+	adminService := NewAdminService(repos.FoodRecognitionDAO, userSettingService, &cfg.Admin)
 
 	return &Services{
 		UserService:            userService,
@@ -55,5 +121,16 @@ func Init(repos *repositories.Repositories, cfg *config.Config) *Services {
 		MoodService:            moodService,
 		WeightService:          weightService,
 		HeightService:          heightService,
+		WaterService:           waterService,
+		DashboardService:       dashboardService,
+		NutritionReportService: nutritionReportService,
+		FoodService:            foodService,
+		RecipeService:          recipeService,
+		MealPlanService:        mealPlanService,
+		RecommendationService:  recommendationService,
+		UserSettingService:     userSettingService,
+		FavoriteFoodService:    favoriteFoodService,
+		DietScoreService:       dietScoreService,
+		AdminService:           adminService,
 	}
 }
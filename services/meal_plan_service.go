@@ -0,0 +1,569 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"ome-app-back/models"
+	"ome-app-back/models/constant"
+	"ome-app-back/repositories"
+)
+
+// 饮食计划生成参数
+const (
+	mealPlanDays             = 7    // 每次生成的天数
+	mealPlanCalorieTolerance = 0.15 // 每日热量允许偏离目标的比例
+	mealPlanMinProteinRatio  = 0.80 // 每日蛋白质至少达到目标的比例
+	mealPlanMaxAttempts      = 2    // AI生成不合格时的最大尝试次数
+)
+
+// 自定义错误，表示AI生成的饮食计划未通过校验
+var ErrMealPlanInvalid = errors.New("AI生成的饮食计划未通过校验，请稍后重试")
+
+// MealPlanService 饮食计划服务
+type MealPlanService struct {
+	mealPlanDAO       *repositories.MealPlanDAO
+	userGoalDAO       *repositories.UserGoalDAO
+	healthAnalysisDAO *repositories.HealthAnalysisDAO
+	nutritionService  *NutritionService
+	settingService    *UserSettingService
+	aiService         *AIService
+}
+
+// NewMealPlanService 创建饮食计划服务实例
+func NewMealPlanService(
+	mealPlanDAO *repositories.MealPlanDAO,
+	userGoalDAO *repositories.UserGoalDAO,
+	healthAnalysisDAO *repositories.HealthAnalysisDAO,
+	nutritionService *NutritionService,
+	settingService *UserSettingService,
+	aiService *AIService,
+) *MealPlanService {
+	return &MealPlanService{
+		mealPlanDAO:       mealPlanDAO,
+		userGoalDAO:       userGoalDAO,
+		healthAnalysisDAO: healthAnalysisDAO,
+		nutritionService:  nutritionService,
+		settingService:    settingService,
+		aiService:         aiService,
+	}
+}
+
+// GenerateMealPlanRequest 生成饮食计划请求
+type GenerateMealPlanRequest struct {
+	StartDate string `json:"start_date"` // 格式: 2023-12-01，为空时从今天开始
+}
+
+// RegenerateMealRequest 重新生成单餐请求
+type RegenerateMealRequest struct {
+	Hint string `json:"hint" binding:"max=200"` // 用户对新餐次的额外要求，如"想吃面食"
+}
+
+// LogPlannedMealRequest 记录计划餐次请求
+type LogPlannedMealRequest struct {
+	Date     string  `json:"date"`     // 格式: 2023-12-01，为空时为今天
+	Servings float64 `json:"servings"` // 食用份数，为空时为1
+}
+
+// MealPlanListRequest 饮食计划列表请求
+type MealPlanListRequest struct {
+	Limit int `form:"limit"`
+}
+
+// aiMealPlan AI返回的饮食计划结构
+type aiMealPlan struct {
+	Days []aiMealPlanDay `json:"days"`
+}
+
+// aiMealPlanDay AI返回的单日计划
+type aiMealPlanDay struct {
+	Day   int      `json:"day"`
+	Meals []aiMeal `json:"meals"`
+}
+
+// aiMeal AI返回的单餐
+type aiMeal struct {
+	MealType    string   `json:"meal_type"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Ingredients []string `json:"ingredients"`
+	Calories    float64  `json:"calories"`
+	ProteinG    float64  `json:"protein_g"`
+	CarbG       float64  `json:"carb_g"`
+	FatG        float64  `json:"fat_g"`
+}
+
+// mealPlanContext 生成计划所需的目标与偏好
+type mealPlanContext struct {
+	goal     *models.UserGoal
+	analysis *models.HealthAnalysis
+}
+
+// GenerateMealPlan 根据健康分析目标与饮食偏好生成一周饮食计划
+func (s *MealPlanService) GenerateMealPlan(userID int64, req *GenerateMealPlanRequest) (*models.MealPlan, error) {
+	startDate, err := parseMealPlanDate(req.StartDate, s.settingService.Today(userID))
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, err := s.loadContext(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	messages := []models.OpenAIMessage{
+		{Role: "system", Content: mealPlanSystemPrompt(ctx)},
+		{Role: "user", Content: fmt.Sprintf("请为我生成从 %s 开始的%d天饮食计划。", startDate.Format("2006-01-02"), mealPlanDays)},
+	}
+
+	var plan aiMealPlan
+	err = s.generateValidated(messages, buildTestMealPlan(ctx), &plan, func() []string {
+		return validateMealPlan(&plan, ctx)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	mealPlan := &models.MealPlan{
+		UserID:           userID,
+		StartDate:        startDate,
+		EndDate:          startDate.AddDate(0, 0, mealPlanDays-1),
+		TargetCalories:   ctx.analysis.RecommendedCalories,
+		TargetProteinG:   ctx.analysis.ProteinNeedG,
+		TargetCarbG:      ctx.analysis.CarbNeedG,
+		TargetFatG:       ctx.analysis.FatNeedG,
+		DietType:         ctx.goal.DietType,
+		FoodIntolerances: ctx.goal.FoodIntolerances,
+	}
+	for _, day := range plan.Days {
+		date := startDate.AddDate(0, 0, day.Day-1)
+		for _, meal := range day.Meals {
+			item := models.MealPlanItem{UserID: userID, Date: date}
+			applyAIMeal(&item, &meal)
+			mealPlan.Meals = append(mealPlan.Meals, item)
+		}
+	}
+
+	if err := s.mealPlanDAO.Create(mealPlan); err != nil {
+		log.Printf("[饮食计划] 用户(ID:%d)保存饮食计划失败: %v", userID, err)
+		return nil, err
+	}
+
+	log.Printf("[饮食计划] 用户(ID:%d)生成饮食计划(ID:%d)，共%d餐", userID, mealPlan.ID, len(mealPlan.Meals))
+	return mealPlan, nil
+}
+
+// GetMealPlan 获取饮食计划详情
+func (s *MealPlanService) GetMealPlan(userID, planID int64) (*models.MealPlan, error) {
+	return s.mealPlanDAO.GetByID(userID, planID)
+}
+
+// GetLatestMealPlan 获取最新的饮食计划
+func (s *MealPlanService) GetLatestMealPlan(userID int64) (*models.MealPlan, error) {
+	return s.mealPlanDAO.GetLatest(userID)
+}
+
+// GetMealPlans 获取饮食计划列表
+func (s *MealPlanService) GetMealPlans(userID int64, req *MealPlanListRequest) ([]models.MealPlan, error) {
+	limit := 10
+	if req.Limit > 0 && req.Limit <= 50 {
+		limit = req.Limit
+	}
+	return s.mealPlanDAO.List(userID, limit)
+}
+
+// RegenerateMeal 重新生成计划中的某一餐，保持餐次类型与热量基本不变
+func (s *MealPlanService) RegenerateMeal(userID, itemID int64, req *RegenerateMealRequest) (*models.MealPlanItem, error) {
+	item, err := s.mealPlanDAO.GetItem(userID, itemID)
+	if err != nil {
+		return nil, err
+	}
+	if item.LoggedEntryID != 0 {
+		return nil, errors.New("该餐次已记录到饮食记录，无法重新生成")
+	}
+
+	ctx, err := s.loadContext(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 同一天的其他餐次，避免生成重复的菜品
+	sameDay, err := s.mealPlanDAO.GetItemsByDate(item.PlanID, item.Date.Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+	avoidNames := make([]string, 0, len(sameDay))
+	for _, other := range sameDay {
+		avoidNames = append(avoidNames, other.Name)
+	}
+
+	userPrompt := fmt.Sprintf(`请重新生成一份%s，热量约%.0f千卡（允许±%.0f%%），蛋白质约%.0f克，不要与以下菜品重复：%s。`,
+		mealTypeName(item.MealType), item.Calories, mealPlanCalorieTolerance*100, item.ProteinG, strings.Join(avoidNames, "、"))
+	if req.Hint != "" {
+		userPrompt += "额外要求：" + req.Hint
+	}
+
+	messages := []models.OpenAIMessage{
+		{Role: "system", Content: singleMealSystemPrompt(ctx)},
+		{Role: "user", Content: userPrompt},
+	}
+
+	var meal aiMeal
+	err = s.generateValidated(messages, buildTestMeal(item, avoidNames), &meal, func() []string {
+		problems := validateMeal(&meal, ctx.goal.FoodIntolerances)
+		if meal.MealType != item.MealType {
+			problems = append(problems, fmt.Sprintf("餐次类型应为 %s", item.MealType))
+		}
+		if item.Calories > 0 && math.Abs(meal.Calories-item.Calories) > item.Calories*mealPlanCalorieTolerance {
+			problems = append(problems, fmt.Sprintf("热量%.0f千卡偏离目标%.0f千卡过多", meal.Calories, item.Calories))
+		}
+		for _, name := range avoidNames {
+			if meal.Name == name {
+				problems = append(problems, "菜品与当天已有餐次重复: "+name)
+			}
+		}
+		return problems
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	applyAIMeal(item, &meal)
+	if err := s.mealPlanDAO.UpdateItem(item); err != nil {
+		return nil, err
+	}
+
+	log.Printf("[饮食计划] 用户(ID:%d)重新生成计划餐次(ID:%d): %s", userID, item.ID, item.Name)
+	return item, nil
+}
+
+// LogPlannedMeal 将计划中的一餐记录到饮食记录
+func (s *MealPlanService) LogPlannedMeal(userID, itemID int64, req *LogPlannedMealRequest) (*NutritionEntryResponse, error) {
+	item, err := s.mealPlanDAO.GetItem(userID, itemID)
+	if err != nil {
+		return nil, err
+	}
+	if item.LoggedEntryID != 0 {
+		return nil, errors.New("该餐次已记录到饮食记录")
+	}
+
+	dateStr := req.Date
+	if dateStr == "" {
+		dateStr = s.settingService.Today(userID).Format("2006-01-02")
+	}
+	servings := req.Servings
+	if servings <= 0 {
+		servings = 1
+	}
+
+	entryReq := &AddNutritionEntryRequest{
+		MealType:       item.MealType,
+		FoodName:       item.Name,
+		Quantity:       strconv.FormatFloat(servings, 'f', -1, 64) + "份",
+		CaloriesIntake: item.Calories * servings,
+		ProteinIntakeG: item.ProteinG * servings,
+		CarbIntakeG:    item.CarbG * servings,
+		FatIntakeG:     item.FatG * servings,
+	}
+
+	resp, err := s.nutritionService.AddSourcedEntry(userID, dateStr, entryReq, constant.EntrySourceMealPlan, item.ID, item.Ingredients...)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	item.LoggedEntryID = resp.Entry.ID
+	item.LoggedAt = &now
+	if err := s.mealPlanDAO.UpdateItem(item); err != nil {
+		log.Printf("[饮食计划] 用户(ID:%d)更新计划餐次(ID:%d)记录状态失败: %v", userID, item.ID, err)
+	}
+
+	return resp, nil
+}
+
+// loadContext 加载用户的健康目标与最新健康分析
+func (s *MealPlanService) loadContext(userID int64) (*mealPlanContext, error) {
+	goal, err := s.userGoalDAO.GetByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	analysis, err := s.healthAnalysisDAO.GetLatestByUserID(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNoHealthAnalysis
+		}
+		return nil, err
+	}
+
+	return &mealPlanContext{goal: goal, analysis: analysis}, nil
+}
+
+// generateValidated 请求AI生成JSON并解析校验，不合格时把问题反馈给AI重试
+func (s *MealPlanService) generateValidated(messages []models.OpenAIMessage, testResponse string, out interface{}, validate func() []string) error {
+	for attempt := 1; attempt <= mealPlanMaxAttempts; attempt++ {
+		content, err := s.aiService.GenerateJSON(messages, testResponse)
+		if err != nil {
+			return err
+		}
+
+		var problems []string
+		if err := json.Unmarshal([]byte(content), out); err != nil {
+			problems = []string{"返回内容不是有效的JSON: " + err.Error()}
+		} else {
+			problems = validate()
+		}
+		if len(problems) == 0 {
+			return nil
+		}
+
+		log.Printf("[饮食计划] 第%d次生成未通过校验: %s", attempt, strings.Join(problems, "; "))
+		messages = append(messages,
+			models.OpenAIMessage{Role: "assistant", Content: content},
+			models.OpenAIMessage{Role: "user", Content: "上面的结果存在以下问题，请修正后按相同JSON格式完整返回：\n" + strings.Join(problems, "\n")},
+		)
+	}
+	return ErrMealPlanInvalid
+}
+
+// validateMealPlan 校验一周计划的结构、目标热量、蛋白质及食物不耐受
+func validateMealPlan(plan *aiMealPlan, ctx *mealPlanContext) []string {
+	var problems []string
+	if len(plan.Days) != mealPlanDays {
+		return []string{fmt.Sprintf("需要恰好%d天的计划，实际为%d天", mealPlanDays, len(plan.Days))}
+	}
+
+	seenDays := make(map[int]bool)
+	for _, day := range plan.Days {
+		if day.Day < 1 || day.Day > mealPlanDays || seenDays[day.Day] {
+			problems = append(problems, fmt.Sprintf("day 字段无效或重复: %d", day.Day))
+			continue
+		}
+		seenDays[day.Day] = true
+
+		mealTypes := make(map[string]bool)
+		var calories, protein float64
+		for i := range day.Meals {
+			meal := &day.Meals[i]
+			for _, p := range validateMeal(meal, ctx.goal.FoodIntolerances) {
+				problems = append(problems, fmt.Sprintf("第%d天: %s", day.Day, p))
+			}
+			mealTypes[meal.MealType] = true
+			calories += meal.Calories
+			protein += meal.ProteinG
+		}
+
+		for _, required := range []string{constant.MealTypeBreakfast, constant.MealTypeLunch, constant.MealTypeDinner} {
+			if !mealTypes[required] {
+				problems = append(problems, fmt.Sprintf("第%d天缺少%s", day.Day, mealTypeName(required)))
+			}
+		}
+
+		target := ctx.analysis.RecommendedCalories
+		if target > 0 && math.Abs(calories-target) > target*mealPlanCalorieTolerance {
+			problems = append(problems, fmt.Sprintf("第%d天总热量%.0f千卡，应在目标%.0f千卡的±%.0f%%以内", day.Day, calories, target, mealPlanCalorieTolerance*100))
+		}
+		if ctx.analysis.ProteinNeedG > 0 && protein < ctx.analysis.ProteinNeedG*mealPlanMinProteinRatio {
+			problems = append(problems, fmt.Sprintf("第%d天蛋白质%.0f克，低于目标%.0f克的%.0f%%", day.Day, protein, ctx.analysis.ProteinNeedG, mealPlanMinProteinRatio*100))
+		}
+	}
+	return problems
+}
+
+// validateMeal 校验单餐的字段与食物不耐受
+func validateMeal(meal *aiMeal, intolerances []string) []string {
+	var problems []string
+	if !constant.ValidMealTypesMap[meal.MealType] {
+		problems = append(problems, "无效的餐次类型: "+meal.MealType)
+	}
+	if strings.TrimSpace(meal.Name) == "" {
+		problems = append(problems, "菜品名称为空")
+	}
+	if meal.Calories <= 0 || meal.ProteinG < 0 || meal.CarbG < 0 || meal.FatG < 0 {
+		problems = append(problems, fmt.Sprintf("%s 的营养数值无效", meal.Name))
+	}
+
+	text := meal.Name + " " + meal.Description + " " + strings.Join(meal.Ingredients, " ")
+	for _, intolerance := range matchIntolerances(text, intolerances) {
+		problems = append(problems, fmt.Sprintf("%s 含有用户不耐受的食物: %s", meal.Name, intolerance))
+	}
+	return problems
+}
+
+// applyAIMeal 将AI返回的单餐写入计划餐次
+func applyAIMeal(item *models.MealPlanItem, meal *aiMeal) {
+	item.MealType = meal.MealType
+	item.Name = meal.Name
+	item.Description = meal.Description
+	item.Ingredients = meal.Ingredients
+	item.Calories = meal.Calories
+	item.ProteinG = meal.ProteinG
+	item.CarbG = meal.CarbG
+	item.FatG = meal.FatG
+}
+
+// mealPlanSystemPrompt 生成一周计划的系统提示词
+func mealPlanSystemPrompt(ctx *mealPlanContext) string {
+	return fmt.Sprintf(`你是一名专业的营养师，需要为用户制定一周的饮食计划。
+用户信息：
+- 健康目标：%s
+- 饮食类型：%s
+- 口味偏好：%s
+- 食物不耐受（绝对不能出现）：%s
+- 每日目标：热量%.0f千卡，蛋白质%.0f克，碳水%.0f克，脂肪%.0f克
+
+要求：
+1. 生成%d天的计划，每天必须包含早餐、午餐、晚餐，可以包含加餐
+2. 每天总热量在目标的±%.0f%%以内，蛋白质不低于目标的%.0f%%
+3. 菜品尽量多样，符合中国家庭常见做法
+
+请严格按以下JSON格式输出，只返回JSON：
+{
+  "days": [
+    {
+      "day": 1,
+      "meals": [
+        {"meal_type": "breakfast/lunch/dinner/snack", "name": "菜品名称", "description": "简要做法", "ingredients": ["食材及用量"], "calories": 热量, "protein_g": 蛋白质克数, "carb_g": 碳水克数, "fat_g": 脂肪克数}
+      ]
+    }
+  ]
+}`,
+		ctx.goal.GoalType, ctx.goal.DietType,
+		joinOrNone(ctx.goal.TastePreferences), joinOrNone(ctx.goal.FoodIntolerances),
+		ctx.analysis.RecommendedCalories, ctx.analysis.ProteinNeedG, ctx.analysis.CarbNeedG, ctx.analysis.FatNeedG,
+		mealPlanDays, mealPlanCalorieTolerance*100, mealPlanMinProteinRatio*100)
+}
+
+// singleMealSystemPrompt 重新生成单餐的系统提示词
+func singleMealSystemPrompt(ctx *mealPlanContext) string {
+	return fmt.Sprintf(`你是一名专业的营养师，需要为用户替换饮食计划中的一餐。
+用户信息：
+- 健康目标：%s
+- 饮食类型：%s
+- 口味偏好：%s
+- 食物不耐受（绝对不能出现）：%s
+
+请严格按以下JSON格式输出，只返回JSON：
+{"meal_type": "breakfast/lunch/dinner/snack", "name": "菜品名称", "description": "简要做法", "ingredients": ["食材及用量"], "calories": 热量, "protein_g": 蛋白质克数, "carb_g": 碳水克数, "fat_g": 脂肪克数}`,
+		ctx.goal.GoalType, ctx.goal.DietType,
+		joinOrNone(ctx.goal.TastePreferences), joinOrNone(ctx.goal.FoodIntolerances))
+}
+
+// mealTypeName 餐次类型的中文名称
+func mealTypeName(mealType string) string {
+	switch mealType {
+	case constant.MealTypeBreakfast:
+		return "早餐"
+	case constant.MealTypeLunch:
+		return "午餐"
+	case constant.MealTypeDinner:
+		return "晚餐"
+	case constant.MealTypeSnack:
+		return "加餐"
+	default:
+		return mealType
+	}
+}
+
+// joinOrNone 拼接列表，为空时返回"无"
+func joinOrNone(items []string) string {
+	if len(items) == 0 {
+		return "无"
+	}
+	return strings.Join(items, "、")
+}
+
+// parseMealPlanDate 解析计划开始日期，为空时为 today
+func parseMealPlanDate(dateStr string, today time.Time) (time.Time, error) {
+	if dateStr == "" {
+		return today, nil
+	}
+	date, err := time.Parse("2006-01-02", dateStr)
+	if err != nil {
+		return time.Time{}, errors.New("日期格式错误，请使用 YYYY-MM-DD 格式")
+	}
+	return date, nil
+}
+
+// 测试模式下使用的菜品，按餐次轮换
+var testPlanMeals = map[string][]string{
+	constant.MealTypeBreakfast: {"燕麦牛奶配水煮蛋", "全麦三明治", "小米粥配鸡蛋饼", "玉米配豆浆"},
+	constant.MealTypeLunch:     {"鸡胸肉糙米饭", "番茄牛腩配米饭", "清蒸鲈鱼配杂粮饭", "虾仁炒西兰花配米饭"},
+	constant.MealTypeDinner:    {"清炒时蔬配豆腐", "菌菇鸡汤面", "凉拌鸡丝配红薯", "冬瓜排骨汤配米饭"},
+	constant.MealTypeSnack:     {"希腊酸奶", "苹果配坚果", "香蕉", "无糖豆浆"},
+}
+
+// 测试模式下各餐次的热量占比
+var testPlanMealRatios = []struct {
+	mealType string
+	ratio    float64
+}{
+	{constant.MealTypeBreakfast, 0.25},
+	{constant.MealTypeLunch, 0.35},
+	{constant.MealTypeDinner, 0.30},
+	{constant.MealTypeSnack, 0.10},
+}
+
+// buildTestMealPlan 测试模式下根据目标值构造一份合格的饮食计划
+func buildTestMealPlan(ctx *mealPlanContext) string {
+	plan := aiMealPlan{Days: make([]aiMealPlanDay, 0, mealPlanDays)}
+	for day := 1; day <= mealPlanDays; day++ {
+		meals := make([]aiMeal, 0, len(testPlanMealRatios))
+		for _, r := range testPlanMealRatios {
+			names := testPlanMeals[r.mealType]
+			meals = append(meals, aiMeal{
+				MealType:    r.mealType,
+				Name:        names[(day-1)%len(names)],
+				Description: "测试模式生成的示例餐",
+				Calories:    math.Round(ctx.analysis.RecommendedCalories * r.ratio),
+				ProteinG:    math.Round(ctx.analysis.ProteinNeedG * r.ratio),
+				CarbG:       math.Round(ctx.analysis.CarbNeedG * r.ratio),
+				FatG:        math.Round(ctx.analysis.FatNeedG * r.ratio),
+			})
+		}
+		plan.Days = append(plan.Days, aiMealPlanDay{Day: day, Meals: meals})
+	}
+
+	data, _ := json.Marshal(plan)
+	return string(data)
+}
+
+// buildTestMeal 测试模式下构造一份替换餐，选用与当天已有餐次不同的菜品
+func buildTestMeal(item *models.MealPlanItem, avoidNames []string) string {
+	meal := aiMeal{
+		MealType:    item.MealType,
+		Name:        item.Name + "（替换）",
+		Description: "测试模式生成的示例餐",
+		Calories:    item.Calories,
+		ProteinG:    item.ProteinG,
+		CarbG:       item.CarbG,
+		FatG:        item.FatG,
+	}
+	for _, name := range testPlanMeals[item.MealType] {
+		if !containsString(avoidNames, name) {
+			meal.Name = name
+			break
+		}
+	}
+
+	data, _ := json.Marshal(meal)
+	return string(data)
+}
+
+// containsString 判断字符串切片是否包含指定值
+func containsString(items []string, target string) bool {
+	for _, item := range items {
+		if item == target {
+			return true
+		}
+	}
+	return false
+}
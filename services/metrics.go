@@ -0,0 +1,7 @@
+package services
+
+import "expvar"
+
+// recognitionMetrics 食物识别相关计数，通过管理接口 /api/v1/admin/debug/vars 暴露
+// cache_hit: 命中缓存复用历史结果；cache_miss: 未命中，调用AI识别；cache_bypass: 用户要求强制重新识别
+var recognitionMetrics = expvar.NewMap("food_recognition")
@@ -11,13 +11,15 @@ import (
 
 // MoodService 心情服务
 type MoodService struct {
-	moodDAO *repositories.MoodRecordDAO
+	moodDAO        *repositories.MoodRecordDAO
+	settingService *UserSettingService
 }
 
 // NewMoodService 创建心情服务实例
-func NewMoodService(moodDAO *repositories.MoodRecordDAO) *MoodService {
+func NewMoodService(moodDAO *repositories.MoodRecordDAO, settingService *UserSettingService) *MoodService {
 	return &MoodService{
-		moodDAO: moodDAO,
+		moodDAO:        moodDAO,
+		settingService: settingService,
 	}
 }
 
@@ -91,17 +93,17 @@ func (s *MoodService) GetMood(userID, moodID int64) (*models.MoodRecord, error)
 // GetMoodHistory 获取心情历史记录
 func (s *MoodService) GetMoodHistory(userID int64, req *MoodHistoryRequest) ([]models.MoodRecord, error) {
 	// 解析日期
-	startDate, err := time.Parse("2006-01-02", req.StartDate)
+	startDate, err := s.settingService.ParseDate(userID, req.StartDate)
 	if err != nil {
 		return nil, errors.New("开始日期格式错误，请使用 YYYY-MM-DD 格式")
 	}
 
-	endDate, err := time.Parse("2006-01-02", req.EndDate)
+	endDate, err := s.settingService.ParseDate(userID, req.EndDate)
 	if err != nil {
 		return nil, errors.New("结束日期格式错误，请使用 YYYY-MM-DD 格式")
 	}
 
-	// 将结束日期设置为当天的最后一秒
+	// 按用户时区将结束日期设置为当天的最后一秒
 	endDate = endDate.Add(23*time.Hour + 59*time.Minute + 59*time.Second)
 
 	return s.moodDAO.GetHistory(userID, startDate, endDate, req.Limit)
@@ -109,7 +111,7 @@ func (s *MoodService) GetMoodHistory(userID int64, req *MoodHistoryRequest) ([]m
 
 // GetTodayMoods 获取今日心情记录
 func (s *MoodService) GetTodayMoods(userID int64) ([]models.MoodRecord, error) {
-	return s.moodDAO.GetTodayMoods(userID)
+	return s.moodDAO.GetTodayMoods(userID, s.settingService.Now(userID))
 }
 
 // DeleteMood 删除心情记录
@@ -120,17 +122,17 @@ func (s *MoodService) DeleteMood(userID, moodID int64) error {
 // GetMoodStatistics 获取心情统计数据
 func (s *MoodService) GetMoodStatistics(userID int64, startDate, endDate string) (map[string]interface{}, error) {
 	// 解析日期
-	start, err := time.Parse("2006-01-02", startDate)
+	start, err := s.settingService.ParseDate(userID, startDate)
 	if err != nil {
 		return nil, errors.New("开始日期格式错误，请使用 YYYY-MM-DD 格式")
 	}
 
-	end, err := time.Parse("2006-01-02", endDate)
+	end, err := s.settingService.ParseDate(userID, endDate)
 	if err != nil {
 		return nil, errors.New("结束日期格式错误，请使用 YYYY-MM-DD 格式")
 	}
 
-	// 将结束日期设置为当天的最后一秒
+	// 按用户时区将结束日期设置为当天的最后一秒
 	end = end.Add(23*time.Hour + 59*time.Minute + 59*time.Second)
 
 	return s.moodDAO.GetMoodStatistics(userID, start, end)
@@ -0,0 +1,331 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"ome-app-back/models"
+	"ome-app-back/repositories"
+)
+
+// 报表分桶周期
+const (
+	ReportPeriodDay   = "day"
+	ReportPeriodWeek  = "week"
+	ReportPeriodMonth = "month"
+)
+
+// 报表参数限制
+const (
+	maxReportRangeDays     = 366  // 单次报表最多覆盖的天数
+	streakLookbackDays     = 365  // 计算当前连续记录天数时向前回溯的天数
+	onTargetTolerance      = 0.10 // 达标判定的允许偏差(±10%)
+	reportDateLayout       = "2006-01-02"
+	reportMonthLabelLayout = "2006-01"
+)
+
+// 自定义错误，表示报表查询参数无效
+var ErrInvalidReportRange = errors.New("报表日期范围无效")
+
+// NutritionReportService 营养报表服务，按任意日期范围和周期汇总营养数据
+type NutritionReportService struct {
+	nutritionDAO   *repositories.DailyNutritionDAO
+	settingService *UserSettingService
+}
+
+// NewNutritionReportService 创建营养报表服务实例
+func NewNutritionReportService(nutritionDAO *repositories.DailyNutritionDAO, settingService *UserSettingService) *NutritionReportService {
+	return &NutritionReportService{
+		nutritionDAO:   nutritionDAO,
+		settingService: settingService,
+	}
+}
+
+// MacroValues 热量与三大宏量营养素数值
+type MacroValues struct {
+	Calories float64 `json:"calories"`
+	ProteinG float64 `json:"protein_g"`
+	CarbG    float64 `json:"carb_g"`
+	FatG     float64 `json:"fat_g"`
+}
+
+// ReportDay 报表中的单日数据
+type ReportDay struct {
+	Date           string  `json:"date"`
+	CaloriesIntake float64 `json:"calories_intake"`
+	TargetCalories float64 `json:"target_calories"`
+	DeviationPct   float64 `json:"deviation_pct"` // 热量相对目标的偏差(%)，正数为超出
+}
+
+// ReportStreaks 连续记录天数
+type ReportStreaks struct {
+	Current int `json:"current"` // 截至结束日期的当前连续记录天数
+	Longest int `json:"longest"` // 报表范围内最长连续记录天数
+}
+
+// ReportBucket 按周期分桶的统计数据
+type ReportBucket struct {
+	Label        string      `json:"label"`
+	StartDate    string      `json:"start_date"`
+	EndDate      string      `json:"end_date"`
+	LoggedDays   int         `json:"logged_days"`
+	Averages     MacroValues `json:"averages"`
+	Adherence    MacroValues `json:"adherence"`
+	OnTargetDays int         `json:"on_target_days"`
+}
+
+// NutritionReport 营养报表
+type NutritionReport struct {
+	StartDate    string         `json:"start_date"`
+	EndDate      string         `json:"end_date"`
+	Period       string         `json:"period"`
+	TotalDays    int            `json:"total_days"`
+	LoggedDays   int            `json:"logged_days"`
+	Averages     MacroValues    `json:"averages"`       // 有记录日的平均摄入
+	Adherence    MacroValues    `json:"adherence"`      // 各项摄入在目标±10%以内的天数占比(%)
+	OnTargetDays int            `json:"on_target_days"` // 热量在目标±10%以内的天数
+	Streaks      ReportStreaks  `json:"streaks"`
+	BestDay      *ReportDay     `json:"best_day"`  // 热量最接近目标的一天
+	WorstDay     *ReportDay     `json:"worst_day"` // 热量偏离目标最多的一天
+	Buckets      []ReportBucket `json:"buckets"`
+}
+
+// GetReport 生成指定日期范围的营养报表，period 为 day/week/month；未指定范围时默认为用户时区下最近30天
+func (s *NutritionReportService) GetReport(userID int64, startDate, endDate time.Time, period string) (*NutritionReport, error) {
+	if startDate.IsZero() && endDate.IsZero() {
+		endDate = s.settingService.Today(userID)
+		startDate = endDate.AddDate(0, 0, -29)
+	}
+	startDate = reportDateOnly(startDate)
+	endDate = reportDateOnly(endDate)
+	if endDate.Before(startDate) {
+		return nil, fmt.Errorf("%w: 结束日期不能早于开始日期", ErrInvalidReportRange)
+	}
+	totalDays := int(endDate.Sub(startDate).Hours()/24) + 1
+	if totalDays > maxReportRangeDays {
+		return nil, fmt.Errorf("%w: 日期范围不能超过%d天", ErrInvalidReportRange, maxReportRangeDays)
+	}
+	if period == "" {
+		period = ReportPeriodDay
+	}
+
+	// 多取一段历史数据用于计算当前连续记录天数
+	lookbackStart := endDate.AddDate(0, 0, -streakLookbackDays)
+	if startDate.Before(lookbackStart) {
+		lookbackStart = startDate
+	}
+	records, err := s.nutritionDAO.GetHistory(userID, lookbackStart, endDate)
+	if err != nil {
+		return nil, err
+	}
+
+	loggedDates := make(map[string]bool)
+	inRange := make([]models.DailyNutrition, 0, len(records))
+	for _, record := range records {
+		if !isLogged(&record) {
+			continue
+		}
+		loggedDates[record.Date.Format(reportDateLayout)] = true
+		day := reportDateOnly(record.Date)
+		if !day.Before(startDate) && !day.After(endDate) {
+			inRange = append(inRange, record)
+		}
+	}
+
+	summary := summarizeDays(inRange)
+	report := &NutritionReport{
+		StartDate:    startDate.Format(reportDateLayout),
+		EndDate:      endDate.Format(reportDateLayout),
+		Period:       period,
+		TotalDays:    totalDays,
+		LoggedDays:   summary.LoggedDays,
+		Averages:     summary.Averages,
+		Adherence:    summary.Adherence,
+		OnTargetDays: summary.OnTargetDays,
+		Streaks: ReportStreaks{
+			Current: currentStreak(loggedDates, endDate),
+			Longest: longestStreak(loggedDates, startDate, endDate),
+		},
+		Buckets: buildBuckets(inRange, startDate, endDate, period),
+	}
+	report.BestDay, report.WorstDay = bestAndWorstDays(inRange)
+
+	return report, nil
+}
+
+// isLogged 判断当天是否有饮食记录
+func isLogged(record *models.DailyNutrition) bool {
+	return record.CaloriesIntake > 0 || record.ProteinIntakeG > 0 || record.CarbIntakeG > 0 || record.FatIntakeG > 0
+}
+
+// summarizeDays 汇总一组有记录日的平均值与达标情况
+func summarizeDays(records []models.DailyNutrition) ReportBucket {
+	bucket := ReportBucket{LoggedDays: len(records)}
+	if len(records) == 0 {
+		return bucket
+	}
+
+	var sum MacroValues
+	var onTarget [4]int
+	var withTarget [4]int
+	for _, r := range records {
+		sum.Calories += r.CaloriesIntake
+		sum.ProteinG += r.ProteinIntakeG
+		sum.CarbG += r.CarbIntakeG
+		sum.FatG += r.FatIntakeG
+
+		pairs := [4][2]float64{
+			{r.CaloriesIntake, r.TargetCalories},
+			{r.ProteinIntakeG, r.TargetProteinG},
+			{r.CarbIntakeG, r.TargetCarbG},
+			{r.FatIntakeG, r.TargetFatG},
+		}
+		for i, pair := range pairs {
+			if pair[1] <= 0 {
+				continue
+			}
+			withTarget[i]++
+			if withinTolerance(pair[0], pair[1]) {
+				onTarget[i]++
+			}
+		}
+	}
+
+	days := float64(len(records))
+	bucket.Averages = MacroValues{
+		Calories: roundTo(sum.Calories/days, 1),
+		ProteinG: roundTo(sum.ProteinG/days, 1),
+		CarbG:    roundTo(sum.CarbG/days, 1),
+		FatG:     roundTo(sum.FatG/days, 1),
+	}
+	bucket.Adherence = MacroValues{
+		Calories: percentage(onTarget[0], withTarget[0]),
+		ProteinG: percentage(onTarget[1], withTarget[1]),
+		CarbG:    percentage(onTarget[2], withTarget[2]),
+		FatG:     percentage(onTarget[3], withTarget[3]),
+	}
+	bucket.OnTargetDays = onTarget[0]
+	return bucket
+}
+
+// buildBuckets 按周期将记录分桶，桶的边界会被裁剪到报表范围内
+func buildBuckets(records []models.DailyNutrition, startDate, endDate time.Time, period string) []ReportBucket {
+	buckets := make([]ReportBucket, 0)
+	for bucketStart := startDate; !bucketStart.After(endDate); {
+		var bucketEnd time.Time
+		var label string
+		switch period {
+		case ReportPeriodWeek:
+			// 以周一作为一周的开始
+			offset := (int(bucketStart.Weekday()) + 6) % 7
+			bucketEnd = bucketStart.AddDate(0, 0, 6-offset)
+			year, week := bucketStart.ISOWeek()
+			label = fmt.Sprintf("%d-W%02d", year, week)
+		case ReportPeriodMonth:
+			bucketEnd = time.Date(bucketStart.Year(), bucketStart.Month()+1, 1, 0, 0, 0, 0, time.UTC).AddDate(0, 0, -1)
+			label = bucketStart.Format(reportMonthLabelLayout)
+		default:
+			bucketEnd = bucketStart
+			label = bucketStart.Format(reportDateLayout)
+		}
+		if bucketEnd.After(endDate) {
+			bucketEnd = endDate
+		}
+
+		bucketRecords := make([]models.DailyNutrition, 0)
+		for _, r := range records {
+			day := reportDateOnly(r.Date)
+			if !day.Before(bucketStart) && !day.After(bucketEnd) {
+				bucketRecords = append(bucketRecords, r)
+			}
+		}
+
+		bucket := summarizeDays(bucketRecords)
+		bucket.Label = label
+		bucket.StartDate = bucketStart.Format(reportDateLayout)
+		bucket.EndDate = bucketEnd.Format(reportDateLayout)
+		buckets = append(buckets, bucket)
+
+		bucketStart = bucketEnd.AddDate(0, 0, 1)
+	}
+	return buckets
+}
+
+// bestAndWorstDays 找出热量最接近目标和偏离目标最多的一天
+func bestAndWorstDays(records []models.DailyNutrition) (best, worst *ReportDay) {
+	for _, r := range records {
+		if r.TargetCalories <= 0 {
+			continue
+		}
+		day := &ReportDay{
+			Date:           r.Date.Format(reportDateLayout),
+			CaloriesIntake: r.CaloriesIntake,
+			TargetCalories: r.TargetCalories,
+			DeviationPct:   roundTo((r.CaloriesIntake-r.TargetCalories)/r.TargetCalories*100, 2),
+		}
+		if best == nil || math.Abs(day.DeviationPct) < math.Abs(best.DeviationPct) {
+			best = day
+		}
+		if worst == nil || math.Abs(day.DeviationPct) > math.Abs(worst.DeviationPct) {
+			worst = day
+		}
+	}
+	return best, worst
+}
+
+// currentStreak 计算截至结束日期的连续记录天数；结束日期当天尚未记录时从前一天开始计算
+func currentStreak(loggedDates map[string]bool, endDate time.Time) int {
+	day := endDate
+	if !loggedDates[day.Format(reportDateLayout)] {
+		day = day.AddDate(0, 0, -1)
+	}
+
+	streak := 0
+	for loggedDates[day.Format(reportDateLayout)] {
+		streak++
+		day = day.AddDate(0, 0, -1)
+	}
+	return streak
+}
+
+// longestStreak 计算指定范围内最长的连续记录天数
+func longestStreak(loggedDates map[string]bool, startDate, endDate time.Time) int {
+	longest, current := 0, 0
+	for day := startDate; !day.After(endDate); day = day.AddDate(0, 0, 1) {
+		if loggedDates[day.Format(reportDateLayout)] {
+			current++
+			if current > longest {
+				longest = current
+			}
+		} else {
+			current = 0
+		}
+	}
+	return longest
+}
+
+// withinTolerance 判断摄入量是否在目标的允许偏差范围内
+func withinTolerance(intake, target float64) bool {
+	return math.Abs(intake-target) <= target*onTargetTolerance
+}
+
+// percentage 计算百分比，保留一位小数
+func percentage(part, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return roundTo(float64(part)/float64(total)*100, 1)
+}
+
+// roundTo 四舍五入保留指定位数小数
+func roundTo(value float64, digits int) float64 {
+	factor := math.Pow(10, float64(digits))
+	return math.Round(value*factor) / factor
+}
+
+// reportDateOnly 将时间截断为UTC日期，与每日营养记录的存储方式一致
+func reportDateOnly(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
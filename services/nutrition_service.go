@@ -7,38 +7,137 @@ import (
 
 	"gorm.io/gorm"
 
-	"ome-app-back/repositories"
+	"ome-app-back/config"
 	"ome-app-back/models"
+	"ome-app-back/models/constant"
+	"ome-app-back/repositories"
 )
 
 // NutritionService 处理用户营养服务
 type NutritionService struct {
 	nutritionDAO      *repositories.DailyNutritionDAO
 	healthAnalysisDAO *repositories.HealthAnalysisDAO // 添加健康分析DAO依赖
+	entryDAO          *repositories.NutritionEntryDAO
+	exerciseDAO       *repositories.UserExerciseDAO
+	waterService      *WaterService
+	allergenService   *AllergenService
+	settingService    *UserSettingService
+	nutritionCfg      *config.NutritionConfig
 }
 
 // NewNutritionService 创建营养服务实例
-func NewNutritionService(nutritionDAO *repositories.DailyNutritionDAO, healthAnalysisDAO *repositories.HealthAnalysisDAO) *NutritionService {
+func NewNutritionService(nutritionDAO *repositories.DailyNutritionDAO, healthAnalysisDAO *repositories.HealthAnalysisDAO, entryDAO *repositories.NutritionEntryDAO, exerciseDAO *repositories.UserExerciseDAO, waterService *WaterService, allergenService *AllergenService, settingService *UserSettingService, nutritionCfg *config.NutritionConfig) *NutritionService {
 	return &NutritionService{
 		nutritionDAO:      nutritionDAO,
 		healthAnalysisDAO: healthAnalysisDAO,
+		entryDAO:          entryDAO,
+		exerciseDAO:       exerciseDAO,
+		waterService:      waterService,
+		allergenService:   allergenService,
+		settingService:    settingService,
+		nutritionCfg:      nutritionCfg,
 	}
 }
 
 // 自定义错误，表示用户尚未生成健康分析报告
 var ErrNoHealthAnalysis = errors.New("用户尚未生成健康分析报告，请先生成健康分析")
 
-// GetTodayNutrition 获取用户今日营养数据
+// 自定义错误，表示请求的日期超出允许补录的范围
+var ErrNutritionDateOutOfRange = errors.New("日期超出允许补录的范围")
+
+// 自定义错误，表示要复制的餐次没有饮食记录
+var ErrNoEntriesToCopy = errors.New("来源日期的该餐次没有饮食记录")
+
+// AddNutritionEntryRequest 添加饮食记录条目请求
+type AddNutritionEntryRequest struct {
+	MealType       string                `json:"meal_type" binding:"required,oneof=breakfast lunch dinner snack"`
+	FoodName       string                `json:"food_name" binding:"required,max=100"`
+	Quantity       string                `json:"quantity" binding:"max=50"`
+	CaloriesIntake float64               `json:"calories_intake" binding:"gte=0"`
+	ProteinIntakeG float64               `json:"protein_intake_g" binding:"gte=0"`
+	CarbIntakeG    float64               `json:"carb_intake_g" binding:"gte=0"`
+	FatIntakeG     float64               `json:"fat_intake_g" binding:"gte=0"`
+	Micronutrients models.Micronutrients `json:"micronutrients"`
+}
+
+// NutritionEntryResponse 饮食记录条目变更响应
+type NutritionEntryResponse struct {
+	Entry          *models.NutritionEntry `json:"entry"`
+	DailyNutrition *models.DailyNutrition `json:"daily_nutrition"`
+
+	AllergenWarnings []models.AllergenWarning `json:"allergen_warnings,omitempty"` // 过敏原/不耐受警告
+}
+
+// CopyMealRequest 复制历史餐次请求
+type CopyMealRequest struct {
+	SourceDate     string `json:"source_date" binding:"required"` // 格式: 2023-12-01
+	MealType       string `json:"meal_type" binding:"required,oneof=breakfast lunch dinner snack"`
+	TargetDate     string `json:"target_date"`                                                             // 为空时为今天
+	TargetMealType string `json:"target_meal_type" binding:"omitempty,oneof=breakfast lunch dinner snack"` // 为空时与来源餐次相同
+}
+
+// CopyMealResponse 复制历史餐次响应
+type CopyMealResponse struct {
+	Entries        []models.NutritionEntry `json:"entries"`
+	DailyNutrition *models.DailyNutrition  `json:"daily_nutrition"`
+
+	AllergenWarnings []models.AllergenWarning `json:"allergen_warnings,omitempty"` // 过敏原/不耐受警告
+}
+
+// GetTodayNutrition 获取用户今日营养数据，附带结合运动消耗的能量收支
 func (s *NutritionService) GetTodayNutrition(userID int64) (*models.DailyNutrition, error) {
 	log.Printf("[营养服务] 开始获取用户(ID:%d)今日营养数据", userID)
+	nutrition, err := s.getOrCreateByDate(userID, s.settingService.Today(userID))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.attachDayEnergyBalance(userID, nutrition); err != nil {
+		return nil, err
+	}
+	return nutrition, nil
+}
 
-	// 先尝试直接获取今天的记录
-	today := time.Now()
-	nutrition, err := s.nutritionDAO.GetByDate(userID, today)
+// GetNutritionByDate 获取用户指定日期的营养数据，附带结合运动消耗的能量收支，日期需在允许补录的范围内
+func (s *NutritionService) GetNutritionByDate(userID int64, dateStr string) (*models.DailyNutrition, error) {
+	nutrition, err := s.getEditableNutrition(userID, dateStr)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.attachDayEnergyBalance(userID, nutrition); err != nil {
+		return nil, err
+	}
+	return nutrition, nil
+}
+
+// getEditableNutrition 获取允许补录范围内指定日期的营养数据，不计算能量收支，供修改摄入量的流程使用
+func (s *NutritionService) getEditableNutrition(userID int64, dateStr string) (*models.DailyNutrition, error) {
+	date, err := s.parseEditableDate(userID, dateStr)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("[营养服务] 开始获取用户(ID:%d)%s的营养数据", userID, dateStr)
+	return s.getOrCreateByDate(userID, date)
+}
+
+// attachDayEnergyBalance 为单日营养记录计算能量收支
+func (s *NutritionService) attachDayEnergyBalance(userID int64, nutrition *models.DailyNutrition) error {
+	records := []models.DailyNutrition{*nutrition}
+	if err := s.attachEnergyBalance(userID, records); err != nil {
+		return err
+	}
+	nutrition.EnergyBalance = records[0].EnergyBalance
+	return nil
+}
+
+// getOrCreateByDate 获取指定日期的营养记录，不存在时按当天生效的健康分析创建
+func (s *NutritionService) getOrCreateByDate(userID int64, date time.Time) (*models.DailyNutrition, error) {
+	// 先尝试直接获取当天的记录
+	nutrition, err := s.nutritionDAO.GetByDate(userID, date)
 
 	// 如果记录存在，直接返回
 	if err == nil {
-		log.Printf("[营养服务] 用户(ID:%d)今日营养记录已存在(ID:%d)", userID, nutrition.ID)
+		log.Printf("[营养服务] 用户(ID:%d)营养记录已存在(ID:%d)", userID, nutrition.ID)
 		return nutrition, nil
 	}
 
@@ -49,36 +148,31 @@ func (s *NutritionService) GetTodayNutrition(userID int64) (*models.DailyNutriti
 		return nil, err
 	}
 
-	log.Printf("[营养服务] 用户(ID:%d)今日营养记录不存在，准备创建新记录", userID)
+	log.Printf("[营养服务] 用户(ID:%d)%s营养记录不存在，准备创建新记录", userID, date.Format("2006-01-02"))
 
 	// 记录不存在，需要创建新记录
-	// 先获取用户最新的健康分析数据
-	analysis, err := s.healthAnalysisDAO.GetLatestByUserID(userID)
+	// 先获取当天生效的健康分析数据
+	analysis, err := s.getActiveAnalysis(userID, date)
 	if err != nil {
-		// 如果用户没有健康分析数据，返回特定错误
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			log.Printf("[营养服务] 用户(ID:%d)没有健康分析数据", userID)
-			return nil, ErrNoHealthAnalysis
-		}
-		log.Printf("[营养服务] 用户(ID:%d)获取健康分析数据失败: %v", userID, err)
-		// 不要使用fmt.Errorf包装错误，直接返回原错误以保持错误类型
 		return nil, err
 	}
 
 	log.Printf("[营养服务] 用户(ID:%d)健康分析数据获取成功(ID:%d)，目标热量:%.2f", userID, analysis.ID, analysis.RecommendedCalories)
 
-	// 使用健康分析数据中的目标值创建今日营养记录
+	// 使用健康分析数据中的目标值创建营养记录
 	createParams := &repositories.CreateNutritionParams{
 		UserID:         userID,
-		Date:           today,
+		Date:           date,
 		TargetCalories: analysis.RecommendedCalories,
 		TargetProteinG: analysis.ProteinNeedG,
 		TargetCarbG:    analysis.CarbNeedG,
 		TargetFatG:     analysis.FatNeedG,
+
+		TargetMicronutrients: analysis.MicronutrientTargets,
 	}
 
 	log.Printf("[营养服务] 用户(ID:%d)开始调用GetOrCreate创建营养记录", userID)
-	nutrition, err = s.nutritionDAO.GetOrCreate(userID, today, createParams)
+	nutrition, err = s.nutritionDAO.GetOrCreate(userID, date, createParams)
 	if err != nil {
 		log.Printf("[营养服务] 用户(ID:%d)GetOrCreate失败: %v", userID, err)
 		return nil, err
@@ -88,19 +182,79 @@ func (s *NutritionService) GetTodayNutrition(userID int64) (*models.DailyNutriti
 	return nutrition, nil
 }
 
-// UpdateTodayNutrition 更新今日营养摄入数据
-func (s *NutritionService) UpdateTodayNutrition(userID int64, caloriesIntake, proteinIntakeG, carbIntakeG, fatIntakeG float64) (*models.DailyNutrition, error) {
+// getActiveAnalysis 获取指定日期生效的健康分析；该日期早于首次分析时使用最早的一次分析
+func (s *NutritionService) getActiveAnalysis(userID int64, date time.Time) (*models.HealthAnalysis, error) {
+	// date 为日历日期，换算到用户时区后再按分析生成时间判断
+	localDate := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, s.settingService.Location(userID))
+	analysis, err := s.healthAnalysisDAO.GetActiveByDate(userID, localDate)
+	if err == nil {
+		return analysis, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		log.Printf("[营养服务] 用户(ID:%d)获取健康分析数据失败: %v", userID, err)
+		return nil, err
+	}
+
+	analysis, err = s.healthAnalysisDAO.GetEarliestByUserID(userID)
+	if err != nil {
+		// 如果用户没有健康分析数据，返回特定错误
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Printf("[营养服务] 用户(ID:%d)没有健康分析数据", userID)
+			return nil, ErrNoHealthAnalysis
+		}
+		log.Printf("[营养服务] 用户(ID:%d)获取健康分析数据失败: %v", userID, err)
+		return nil, err
+	}
+	log.Printf("[营养服务] 用户(ID:%d)在%s之前没有健康分析，使用最早的分析(ID:%d)", userID, date.Format("2006-01-02"), analysis.ID)
+	return analysis, nil
+}
+
+// parseEditableDate 解析日期并校验是否在允许补录的范围内（用户时区的今天及之前的N天）
+func (s *NutritionService) parseEditableDate(userID int64, dateStr string) (time.Time, error) {
+	date, err := time.Parse("2006-01-02", dateStr)
+	if err != nil {
+		return time.Time{}, errors.New("日期格式错误，请使用 YYYY-MM-DD 格式")
+	}
+
+	today := s.settingService.Today(userID)
+	earliest := today.AddDate(0, 0, -s.nutritionCfg.GetBackfillDays())
+	if date.After(today) || date.Before(earliest) {
+		return time.Time{}, ErrNutritionDateOutOfRange
+	}
+	return date, nil
+}
+
+// UpdateTodayNutrition 更新今日营养摄入数据，micronutrientIntake 为空时保留原有微量营养素数据
+func (s *NutritionService) UpdateTodayNutrition(userID int64, caloriesIntake, proteinIntakeG, carbIntakeG, fatIntakeG float64, micronutrientIntake models.Micronutrients) (*models.DailyNutrition, error) {
 	// 先获取今日营养记录
 	nutrition, err := s.GetTodayNutrition(userID)
 	if err != nil {
 		return nil, err
 	}
 
+	return s.updateNutrition(nutrition, caloriesIntake, proteinIntakeG, carbIntakeG, fatIntakeG, micronutrientIntake)
+}
+
+// UpdateNutritionByDate 更新指定日期的营养摄入数据，日期需在允许补录的范围内
+func (s *NutritionService) UpdateNutritionByDate(userID int64, dateStr string, caloriesIntake, proteinIntakeG, carbIntakeG, fatIntakeG float64, micronutrientIntake models.Micronutrients) (*models.DailyNutrition, error) {
+	nutrition, err := s.getEditableNutrition(userID, dateStr)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.updateNutrition(nutrition, caloriesIntake, proteinIntakeG, carbIntakeG, fatIntakeG, micronutrientIntake)
+}
+
+// updateNutrition 覆盖写入营养记录的摄入数据
+func (s *NutritionService) updateNutrition(nutrition *models.DailyNutrition, caloriesIntake, proteinIntakeG, carbIntakeG, fatIntakeG float64, micronutrientIntake models.Micronutrients) (*models.DailyNutrition, error) {
 	// 更新数据
 	nutrition.CaloriesIntake = caloriesIntake
 	nutrition.ProteinIntakeG = proteinIntakeG
 	nutrition.CarbIntakeG = carbIntakeG
 	nutrition.FatIntakeG = fatIntakeG
+	if micronutrientIntake != nil {
+		nutrition.MicronutrientIntake = micronutrientIntake.Pick(s.nutritionCfg.MicronutrientKeys())
+	}
 
 	// 保存更新
 	if err := s.nutritionDAO.Update(nutrition); err != nil {
@@ -110,12 +264,243 @@ func (s *NutritionService) UpdateTodayNutrition(userID int64, caloriesIntake, pr
 	return nutrition, nil
 }
 
+// AddNutritionEntry 向指定日期添加手动录入的饮食记录条目，并累加到当日营养摄入
+func (s *NutritionService) AddNutritionEntry(userID int64, dateStr string, req *AddNutritionEntryRequest) (*NutritionEntryResponse, error) {
+	return s.AddSourcedEntry(userID, dateStr, req, constant.EntrySourceManual, 0)
+}
+
+// AddSourcedEntry 向指定日期添加带来源的饮食记录条目（如食谱、食物识别），并累加到当日营养摄入
+// ingredients 为食材名称，与食物名称一起用于过敏原检查
+func (s *NutritionService) AddSourcedEntry(userID int64, dateStr string, req *AddNutritionEntryRequest, source string, sourceID int64, ingredients ...string) (*NutritionEntryResponse, error) {
+	nutrition, err := s.getEditableNutrition(userID, dateStr)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := &models.NutritionEntry{
+		UserID:         userID,
+		Date:           nutrition.Date,
+		MealType:       req.MealType,
+		FoodName:       req.FoodName,
+		Quantity:       req.Quantity,
+		CaloriesIntake: req.CaloriesIntake,
+		ProteinIntakeG: req.ProteinIntakeG,
+		CarbIntakeG:    req.CarbIntakeG,
+		FatIntakeG:     req.FatIntakeG,
+		Micronutrients: req.Micronutrients.Pick(s.nutritionCfg.MicronutrientKeys()),
+		Source:         source,
+		SourceID:       sourceID,
+	}
+
+	if err := s.entryDAO.AddEntry(entry, nutrition); err != nil {
+		log.Printf("[营养服务] 用户(ID:%d)添加饮食记录失败: %v", userID, err)
+		return nil, err
+	}
+
+	log.Printf("[营养服务] 用户(ID:%d)在%s添加饮食记录(ID:%d)，热量:%.2f", userID, dateStr, entry.ID, entry.CaloriesIntake)
+	return &NutritionEntryResponse{
+		Entry:            entry,
+		DailyNutrition:   nutrition,
+		AllergenWarnings: s.allergenService.CheckNames(userID, append([]string{req.FoodName}, ingredients...)...),
+	}, nil
+}
+
+// GetNutritionEntries 获取指定日期的饮食记录条目
+func (s *NutritionService) GetNutritionEntries(userID int64, dateStr string) ([]models.NutritionEntry, error) {
+	date, err := time.Parse("2006-01-02", dateStr)
+	if err != nil {
+		return nil, errors.New("日期格式错误，请使用 YYYY-MM-DD 格式")
+	}
+	return s.entryDAO.ListByDate(userID, date)
+}
+
+// DeleteNutritionEntry 删除饮食记录条目，并从当日营养摄入中扣除
+func (s *NutritionService) DeleteNutritionEntry(userID, entryID int64) (*models.DailyNutrition, error) {
+	entry, err := s.entryDAO.GetByID(userID, entryID)
+	if err != nil {
+		return nil, err
+	}
+
+	nutrition, err := s.getEditableNutrition(userID, entry.Date.Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.entryDAO.RemoveEntry(entry, nutrition); err != nil {
+		log.Printf("[营养服务] 用户(ID:%d)删除饮食记录(ID:%d)失败: %v", userID, entryID, err)
+		return nil, err
+	}
+
+	return nutrition, nil
+}
+
+// CopyMeal 将某天某餐次的饮食记录复制到目标日期（默认今天），并累加到目标日期的营养摄入
+func (s *NutritionService) CopyMeal(userID int64, req *CopyMealRequest) (*CopyMealResponse, error) {
+	sourceDate, err := time.Parse("2006-01-02", req.SourceDate)
+	if err != nil {
+		return nil, errors.New("来源日期格式错误，请使用 YYYY-MM-DD 格式")
+	}
+
+	targetDate := req.TargetDate
+	if targetDate == "" {
+		targetDate = s.settingService.Today(userID).Format("2006-01-02")
+	}
+	targetMealType := req.TargetMealType
+	if targetMealType == "" {
+		targetMealType = req.MealType
+	}
+
+	nutrition, err := s.getEditableNutrition(userID, targetDate)
+	if err != nil {
+		return nil, err
+	}
+
+	sourceEntries, err := s.entryDAO.ListByDate(userID, sourceDate)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]models.NutritionEntry, 0, len(sourceEntries))
+	foodNames := make([]string, 0, len(sourceEntries))
+	for _, source := range sourceEntries {
+		if source.MealType != req.MealType {
+			continue
+		}
+		entries = append(entries, models.NutritionEntry{
+			UserID:         userID,
+			Date:           nutrition.Date,
+			MealType:       targetMealType,
+			FoodName:       source.FoodName,
+			Quantity:       source.Quantity,
+			CaloriesIntake: source.CaloriesIntake,
+			ProteinIntakeG: source.ProteinIntakeG,
+			CarbIntakeG:    source.CarbIntakeG,
+			FatIntakeG:     source.FatIntakeG,
+			Micronutrients: source.Micronutrients.Pick(s.nutritionCfg.MicronutrientKeys()),
+			Source:         constant.EntrySourceCopy,
+			SourceID:       source.ID,
+		})
+		foodNames = append(foodNames, source.FoodName)
+	}
+	if len(entries) == 0 {
+		return nil, ErrNoEntriesToCopy
+	}
+
+	if err := s.entryDAO.AddEntries(entries, nutrition); err != nil {
+		log.Printf("[营养服务] 用户(ID:%d)复制%s的%s失败: %v", userID, req.SourceDate, req.MealType, err)
+		return nil, err
+	}
+
+	log.Printf("[营养服务] 用户(ID:%d)已将%s的%s复制到%s，共%d条", userID, req.SourceDate, req.MealType, targetDate, len(entries))
+	return &CopyMealResponse{
+		Entries:          entries,
+		DailyNutrition:   nutrition,
+		AllergenWarnings: s.allergenService.CheckNames(userID, foodNames...),
+	}, nil
+}
+
 // GetNutritionHistory 获取营养历史记录
 func (s *NutritionService) GetNutritionHistory(userID int64, startDate, endDate time.Time) ([]models.DailyNutrition, error) {
-	return s.nutritionDAO.GetHistory(userID, startDate, endDate)
+	records, err := s.nutritionDAO.GetHistory(userID, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.attachEnergyBalance(userID, records); err != nil {
+		return nil, err
+	}
+	return records, nil
 }
 
-// GetWeekSummary 获取一周营养摄入统计
+// GetWeekSummary 获取一周营养摄入统计，包含平均每日饮水量
 func (s *NutritionService) GetWeekSummary(userID int64) (map[string]float64, error) {
-	return s.nutritionDAO.GetWeekSummary(userID)
+	today := s.settingService.Today(userID)
+	summary, err := s.nutritionDAO.GetWeekSummary(userID, today, s.nutritionCfg.MicronutrientKeys())
+	if err != nil {
+		return nil, err
+	}
+
+	avgWaterML, avgWaterTargetML, err := s.waterService.GetAverageDaily(userID, 7)
+	if err != nil {
+		return nil, err
+	}
+	summary["avg_water_ml"] = avgWaterML
+	summary["avg_water_target_ml"] = avgWaterTargetML
+
+	// 能量收支平均值，与热量均值一样按有营养记录的天数计算
+	records, err := s.nutritionDAO.GetHistory(userID, today.AddDate(0, 0, -6), today)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.attachEnergyBalance(userID, records); err != nil {
+		return nil, err
+	}
+	var burned, earned, net, remaining float64
+	for _, r := range records {
+		burned += r.EnergyBalance.BurnedCalories
+		earned += r.EnergyBalance.EarnedCalories
+		net += r.EnergyBalance.NetCalories
+		remaining += r.EnergyBalance.RemainingCalories
+	}
+	summary["avg_calories_burned"] = 0
+	summary["avg_earned_calories"] = 0
+	summary["avg_net_calories"] = 0
+	summary["avg_remaining_calories"] = 0
+	if len(records) > 0 {
+		days := float64(len(records))
+		summary["avg_calories_burned"] = burned / days
+		summary["avg_earned_calories"] = earned / days
+		summary["avg_net_calories"] = net / days
+		summary["avg_remaining_calories"] = remaining / days
+	}
+
+	return summary, nil
+}
+
+// attachEnergyBalance 按用户的运动消耗回补设置，为每日营养记录计算能量收支
+func (s *NutritionService) attachEnergyBalance(userID int64, records []models.DailyNutrition) error {
+	if len(records) == 0 {
+		return nil
+	}
+
+	setting, err := s.settingService.GetSettings(userID)
+	if err != nil {
+		return err
+	}
+	factor := ExerciseCalorieFactor(setting)
+
+	startDate, endDate := records[0].Date, records[0].Date
+	for _, r := range records {
+		if r.Date.Before(startDate) {
+			startDate = r.Date
+		}
+		if r.Date.After(endDate) {
+			endDate = r.Date
+		}
+	}
+	// 营养记录的日期不含时区，按日历日期换算为用户时区的时间范围
+	loc := s.settingService.Location(userID)
+	startDate = time.Date(startDate.Year(), startDate.Month(), startDate.Day(), 0, 0, 0, 0, loc)
+	endDate = time.Date(endDate.Year(), endDate.Month(), endDate.Day(), 0, 0, 0, 0, loc).AddDate(0, 0, 1)
+
+	exercises, err := s.exerciseDAO.GetHistory(userID, startDate, endDate, 0)
+	if err != nil {
+		log.Printf("[营养服务] 用户(ID:%d)获取运动记录失败: %v", userID, err)
+		return err
+	}
+	burnedByDate := make(map[string]float64)
+	for _, exercise := range exercises {
+		burnedByDate[exercise.StartTime.In(loc).Format("2006-01-02")] += exercise.CaloriesBurned
+	}
+
+	for i := range records {
+		r := &records[i]
+		burned := burnedByDate[r.Date.Format("2006-01-02")]
+		r.EnergyBalance = models.NewEnergyBalance(r.TargetCalories, r.CaloriesIntake, burned, factor, setting.ExerciseCalorieMode)
+	}
+	return nil
+}
+
+// GetMicronutrientOptions 获取追踪的微量营养素配置（用于前端显示）
+func (s *NutritionService) GetMicronutrientOptions() []config.MicronutrientConfig {
+	return s.nutritionCfg.GetMicronutrients()
 }
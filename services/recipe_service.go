@@ -0,0 +1,214 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+
+	"ome-app-back/config"
+	"ome-app-back/models"
+	"ome-app-back/models/constant"
+	"ome-app-back/repositories"
+)
+
+// RecipeService 食谱服务
+type RecipeService struct {
+	recipeDAO        *repositories.RecipeDAO
+	foodItemDAO      *repositories.FoodItemDAO
+	nutritionService *NutritionService
+	settingService   *UserSettingService
+	nutritionCfg     *config.NutritionConfig
+}
+
+// NewRecipeService 创建食谱服务实例
+func NewRecipeService(recipeDAO *repositories.RecipeDAO, foodItemDAO *repositories.FoodItemDAO, nutritionService *NutritionService, settingService *UserSettingService, nutritionCfg *config.NutritionConfig) *RecipeService {
+	return &RecipeService{
+		recipeDAO:        recipeDAO,
+		foodItemDAO:      foodItemDAO,
+		nutritionService: nutritionService,
+		settingService:   settingService,
+		nutritionCfg:     nutritionCfg,
+	}
+}
+
+// RecipeIngredientRequest 食谱食材请求
+// 指定 food_item_id 时按食物库的每100克营养计算；否则为自定义食材，需填写该用量下的营养数值
+type RecipeIngredientRequest struct {
+	FoodItemID     int64                 `json:"food_item_id"`
+	Name           string                `json:"name" binding:"max=100"`
+	Grams          float64               `json:"grams" binding:"required,gt=0"`
+	Calories       float64               `json:"calories" binding:"gte=0"`
+	ProteinG       float64               `json:"protein_g" binding:"gte=0"`
+	CarbG          float64               `json:"carb_g" binding:"gte=0"`
+	FatG           float64               `json:"fat_g" binding:"gte=0"`
+	Micronutrients models.Micronutrients `json:"micronutrients"`
+}
+
+// SaveRecipeRequest 创建/更新食谱请求
+type SaveRecipeRequest struct {
+	Name        string                    `json:"name" binding:"required,max=100"`
+	Description string                    `json:"description"`
+	Servings    float64                   `json:"servings" binding:"required,gt=0"`
+	IsPublic    bool                      `json:"is_public"`
+	Ingredients []RecipeIngredientRequest `json:"ingredients" binding:"required,min=1,dive"`
+}
+
+// PublicRecipeRequest 搜索公开食谱请求
+type PublicRecipeRequest struct {
+	Keyword string `form:"keyword"`
+	Limit   int    `form:"limit"`
+}
+
+// LogRecipeRequest 按食谱记录饮食请求
+type LogRecipeRequest struct {
+	Date     string  `json:"date"` // 格式: 2023-12-01，为空时为今天
+	MealType string  `json:"meal_type" binding:"required,oneof=breakfast lunch dinner snack"`
+	Servings float64 `json:"servings" binding:"required,gt=0"`
+}
+
+// CreateRecipe 创建食谱
+func (s *RecipeService) CreateRecipe(userID int64, req *SaveRecipeRequest) (*models.Recipe, error) {
+	recipe := &models.Recipe{UserID: userID}
+	if err := s.applyRequest(userID, recipe, req); err != nil {
+		return nil, err
+	}
+
+	if err := s.recipeDAO.Create(recipe); err != nil {
+		return nil, err
+	}
+	return recipe, nil
+}
+
+// UpdateRecipe 更新食谱，食材列表整体替换
+func (s *RecipeService) UpdateRecipe(userID, recipeID int64, req *SaveRecipeRequest) (*models.Recipe, error) {
+	recipe, err := s.recipeDAO.GetOwnedByID(userID, recipeID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.applyRequest(userID, recipe, req); err != nil {
+		return nil, err
+	}
+
+	if err := s.recipeDAO.Update(recipe); err != nil {
+		return nil, err
+	}
+	return recipe, nil
+}
+
+// GetRecipe 获取食谱详情（自己的或公开的）
+func (s *RecipeService) GetRecipe(userID, recipeID int64) (*models.Recipe, error) {
+	return s.recipeDAO.GetByID(userID, recipeID)
+}
+
+// GetMyRecipes 获取用户自己的食谱列表
+func (s *RecipeService) GetMyRecipes(userID int64) ([]models.Recipe, error) {
+	return s.recipeDAO.ListByUser(userID)
+}
+
+// GetPublicRecipes 搜索公开分享的食谱
+func (s *RecipeService) GetPublicRecipes(req *PublicRecipeRequest) ([]models.Recipe, error) {
+	limit := 20
+	if req.Limit > 0 && req.Limit <= 100 {
+		limit = req.Limit
+	}
+	return s.recipeDAO.ListPublic(req.Keyword, limit)
+}
+
+// DeleteRecipe 删除食谱
+func (s *RecipeService) DeleteRecipe(userID, recipeID int64) error {
+	return s.recipeDAO.Delete(userID, recipeID)
+}
+
+// LogRecipe 按份数记录食谱到指定日期的饮食记录
+func (s *RecipeService) LogRecipe(userID, recipeID int64, req *LogRecipeRequest) (*NutritionEntryResponse, error) {
+	recipe, err := s.recipeDAO.GetByID(userID, recipeID)
+	if err != nil {
+		return nil, err
+	}
+
+	dateStr := req.Date
+	if dateStr == "" {
+		dateStr = s.settingService.Today(userID).Format("2006-01-02")
+	}
+
+	entryReq := &AddNutritionEntryRequest{
+		MealType:       req.MealType,
+		FoodName:       recipe.Name,
+		Quantity:       strconv.FormatFloat(req.Servings, 'f', -1, 64) + "份",
+		CaloriesIntake: recipe.CaloriesPerServing * req.Servings,
+		ProteinIntakeG: recipe.ProteinPerServingG * req.Servings,
+		CarbIntakeG:    recipe.CarbPerServingG * req.Servings,
+		FatIntakeG:     recipe.FatPerServingG * req.Servings,
+		Micronutrients: recipe.MicronutrientsPerServing.Scale(req.Servings),
+	}
+
+	ingredients := make([]string, 0, len(recipe.Ingredients))
+	for _, ingredient := range recipe.Ingredients {
+		ingredients = append(ingredients, ingredient.Name)
+	}
+	resp, err := s.nutritionService.AddSourcedEntry(userID, dateStr, entryReq, constant.EntrySourceRecipe, recipe.ID, ingredients...)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("[食谱服务] 用户(ID:%d)记录食谱(ID:%d) %.2f份到%s", userID, recipe.ID, req.Servings, dateStr)
+	return resp, nil
+}
+
+// applyRequest 将请求内容写入食谱，解析食材营养并重新计算每份营养
+func (s *RecipeService) applyRequest(userID int64, recipe *models.Recipe, req *SaveRecipeRequest) error {
+	ingredients := make([]models.RecipeIngredient, 0, len(req.Ingredients))
+	for i, item := range req.Ingredients {
+		ingredient, err := s.buildIngredient(userID, &item)
+		if err != nil {
+			return fmt.Errorf("第%d个食材: %w", i+1, err)
+		}
+		ingredients = append(ingredients, *ingredient)
+	}
+
+	recipe.Name = req.Name
+	recipe.Description = req.Description
+	recipe.Servings = req.Servings
+	recipe.IsPublic = req.IsPublic
+	recipe.Ingredients = ingredients
+	recipe.CalculateNutrition()
+	return nil
+}
+
+// buildIngredient 构建食材：食物库食材按克数折算营养，自定义食材直接使用填写的营养
+func (s *RecipeService) buildIngredient(userID int64, req *RecipeIngredientRequest) (*models.RecipeIngredient, error) {
+	keys := s.nutritionCfg.MicronutrientKeys()
+
+	if req.FoodItemID == 0 {
+		if req.Name == "" {
+			return nil, errors.New("自定义食材需要填写名称")
+		}
+		return &models.RecipeIngredient{
+			Name:           req.Name,
+			Grams:          req.Grams,
+			Calories:       req.Calories,
+			ProteinG:       req.ProteinG,
+			CarbG:          req.CarbG,
+			FatG:           req.FatG,
+			Micronutrients: req.Micronutrients.Pick(keys),
+		}, nil
+	}
+
+	item, err := s.foodItemDAO.GetByID(userID, req.FoodItemID)
+	if err != nil {
+		return nil, err
+	}
+
+	factor := req.Grams / 100
+	return &models.RecipeIngredient{
+		FoodItemID:     item.ID,
+		Name:           item.Name,
+		Grams:          req.Grams,
+		Calories:       item.CaloriesPer100G * factor,
+		ProteinG:       item.ProteinPer100G * factor,
+		CarbG:          item.CarbPer100G * factor,
+		FatG:           item.FatPer100G * factor,
+		Micronutrients: item.Micronutrients.Pick(keys).Scale(factor),
+	}, nil
+}
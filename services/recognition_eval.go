@@ -0,0 +1,176 @@
+package services
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"strings"
+
+	"ome-app-back/models"
+	"ome-app-back/models/constant"
+)
+
+// RecognitionEvaluator 识别准确度离线评测：用当前配置的模型重新识别评测数据集中的样本，与用户修正后的结果比较
+// 文字样本只评测模型解析结果，不包含食物库校准
+type RecognitionEvaluator struct {
+	aiService   *AIService
+	fileService *FileService
+}
+
+// NewRecognitionEvaluator 创建离线评测实例
+func NewRecognitionEvaluator(aiService *AIService, fileService *FileService) *RecognitionEvaluator {
+	return &RecognitionEvaluator{
+		aiService:   aiService,
+		fileService: fileService,
+	}
+}
+
+// RecognitionEvalMetrics 一组识别结果相对正确结果的准确度指标
+// 热量误差按整餐总热量计算；食物项按名称一一匹配，precision=匹配数/识别出的食物数，recall=匹配数/正确结果中的食物数
+type RecognitionEvalMetrics struct {
+	Samples        int     `json:"samples"`         // 参与统计的样本数
+	CalorieMAE     float64 `json:"calorie_mae"`     // 总热量平均绝对误差(千卡)
+	MatchedItems   int     `json:"matched_items"`   // 名称匹配上的食物数
+	PredictedItems int     `json:"predicted_items"` // 识别出的食物数
+	ExpectedItems  int     `json:"expected_items"`  // 正确结果中的食物数
+	Precision      float64 `json:"precision"`
+	Recall         float64 `json:"recall"`
+	F1             float64 `json:"f1"`
+
+	calorieErrorSum float64
+}
+
+// RecognitionEvalFailure 重新识别失败的样本
+type RecognitionEvalFailure struct {
+	RecognitionID int64  `json:"recognition_id"`
+	Error         string `json:"error"`
+}
+
+// RecognitionEvalReport 离线评测报告
+type RecognitionEvalReport struct {
+	Total    int                      `json:"total"`              // 数据集样本数
+	Skipped  int                      `json:"skipped"`            // 缺少正确结果或输入而跳过的样本数
+	Replayed RecognitionEvalMetrics   `json:"replayed"`           // 当前模型重新识别的结果
+	Baseline RecognitionEvalMetrics   `json:"baseline"`           // 同一批样本在数据集中记录的线上识别结果，用于对比
+	Failures []RecognitionEvalFailure `json:"failures,omitempty"` // 重新识别失败的样本
+}
+
+// ReadRecognitionEvalDataset 读取导出的评测数据集（JSON Lines），忽略空行
+func ReadRecognitionEvalDataset(r io.Reader) ([]models.RecognitionEvalSample, error) {
+	var samples []models.RecognitionEvalSample
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		var sample models.RecognitionEvalSample
+		if err := json.Unmarshal([]byte(text), &sample); err != nil {
+			return nil, fmt.Errorf("第%d行解析失败: %v", line, err)
+		}
+		samples = append(samples, sample)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return samples, nil
+}
+
+// Evaluate 逐条重新识别样本并汇总准确度指标，单条样本识别失败时记录原因并继续
+func (e *RecognitionEvaluator) Evaluate(samples []models.RecognitionEvalSample) *RecognitionEvalReport {
+	report := &RecognitionEvalReport{Total: len(samples)}
+	for i := range samples {
+		sample := &samples[i]
+		if len(sample.Corrected.Foods) == 0 {
+			report.Skipped++
+			continue
+		}
+		log.Printf("[离线评测] (%d/%d) 重新识别样本(识别记录ID:%d)", i+1, len(samples), sample.RecognitionID)
+
+		predicted, err := e.recognize(sample)
+		if err != nil {
+			log.Printf("[离线评测] 警告: 样本(识别记录ID:%d)识别失败: %v", sample.RecognitionID, err)
+			report.Failures = append(report.Failures, RecognitionEvalFailure{RecognitionID: sample.RecognitionID, Error: err.Error()})
+			continue
+		}
+		report.Replayed.add(models.RecognitionGroundTruth{Foods: predicted.Foods, Nutrition: predicted.Nutrition}, sample.Corrected)
+		report.Baseline.add(sample.AIOutput, sample.Corrected)
+	}
+	report.Replayed.finish()
+	report.Baseline.finish()
+	return report
+}
+
+// recognize 按样本来源调用与线上相同的识别流程
+func (e *RecognitionEvaluator) recognize(sample *models.RecognitionEvalSample) (*AIAnalysisResult, error) {
+	if sample.Source == constant.RecognitionSourceText {
+		if strings.TrimSpace(sample.InputText) == "" {
+			return nil, errors.New("文字样本缺少输入描述")
+		}
+		return analyzeMealText(e.aiService, sample.InputText)
+	}
+	if len(sample.ImagePaths) == 0 {
+		return nil, errors.New("图片样本缺少图片路径")
+	}
+	return analyzeMealImages(e.aiService, e.fileService, sample.ImagePaths)
+}
+
+// add 累加一条样本的误差与食物匹配数
+func (m *RecognitionEvalMetrics) add(predicted, expected models.RecognitionGroundTruth) {
+	m.Samples++
+	m.calorieErrorSum += math.Abs(predicted.Nutrition.CaloriesIntake - expected.Nutrition.CaloriesIntake)
+	m.PredictedItems += len(predicted.Foods)
+	m.ExpectedItems += len(expected.Foods)
+	m.MatchedItems += matchFoodNames(predicted.Foods, expected.Foods)
+}
+
+// finish 根据累计值计算各项指标
+func (m *RecognitionEvalMetrics) finish() {
+	if m.Samples > 0 {
+		m.CalorieMAE = roundTo(m.calorieErrorSum/float64(m.Samples), 2)
+	}
+	if m.PredictedItems > 0 {
+		m.Precision = roundTo(float64(m.MatchedItems)/float64(m.PredictedItems), 4)
+	}
+	if m.ExpectedItems > 0 {
+		m.Recall = roundTo(float64(m.MatchedItems)/float64(m.ExpectedItems), 4)
+	}
+	if m.Precision+m.Recall > 0 {
+		m.F1 = roundTo(2*m.Precision*m.Recall/(m.Precision+m.Recall), 4)
+	}
+}
+
+// matchFoodNames 按名称将识别出的食物与正确结果一一匹配，返回匹配数
+// 先匹配规范化后完全相同的名称，再匹配互相包含的名称（如"米饭"与"白米饭"）
+func matchFoodNames(predicted, expected []models.RecognizedFoodItem) int {
+	used := make([]bool, len(predicted))
+	matched := make([]bool, len(expected))
+	count := 0
+	for _, exact := range []bool{true, false} {
+		for i, want := range expected {
+			if matched[i] {
+				continue
+			}
+			wantKey := normalizeFoodKey(want.Name)
+			for j, got := range predicted {
+				if used[j] {
+					continue
+				}
+				gotKey := normalizeFoodKey(got.Name)
+				if gotKey == wantKey || (!exact && gotKey != "" && wantKey != "" &&
+					(strings.Contains(gotKey, wantKey) || strings.Contains(wantKey, gotKey))) {
+					used[j] = true
+					matched[i] = true
+					count++
+					break
+				}
+			}
+		}
+	}
+	return count
+}
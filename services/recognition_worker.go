@@ -0,0 +1,98 @@
+package services
+
+import (
+	"log"
+	"sync"
+
+	"ome-app-back/models"
+)
+
+// recognitionEventBuffer 每个订阅者缓存的状态事件数量
+const recognitionEventBuffer = 4
+
+// recognitionWorkerPool 有界的异步食物识别任务队列，由固定数量的工作协程处理
+type recognitionWorkerPool struct {
+	jobs chan int64
+}
+
+// newRecognitionWorkerPool 创建任务队列并启动工作协程
+func newRecognitionWorkerPool(workers, queueSize int, process func(recognitionID int64)) *recognitionWorkerPool {
+	pool := &recognitionWorkerPool{jobs: make(chan int64, queueSize)}
+	for i := 0; i < workers; i++ {
+		go func() {
+			for recognitionID := range pool.jobs {
+				runRecognitionJob(recognitionID, process)
+			}
+		}()
+	}
+	log.Printf("[食物识别] 启动%d个识别工作协程，队列上限%d", workers, queueSize)
+	return pool
+}
+
+// runRecognitionJob 执行单个识别任务，防止单个任务的panic导致工作协程退出
+func runRecognitionJob(recognitionID int64, process func(int64)) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("[食物识别] 错误: 识别任务(ID:%d)异常: %v", recognitionID, r)
+		}
+	}()
+	process(recognitionID)
+}
+
+// submit 提交识别任务，队列已满时返回 false
+func (p *recognitionWorkerPool) submit(recognitionID int64) bool {
+	select {
+	case p.jobs <- recognitionID:
+		return true
+	default:
+		return false
+	}
+}
+
+// recognitionEventHub 识别状态变化的订阅中心，用于SSE推送
+type recognitionEventHub struct {
+	mu          sync.Mutex
+	subscribers map[int64]map[chan *models.FoodRecognitionResult]struct{}
+}
+
+func newRecognitionEventHub() *recognitionEventHub {
+	return &recognitionEventHub{subscribers: make(map[int64]map[chan *models.FoodRecognitionResult]struct{})}
+}
+
+// subscribe 订阅指定识别记录的状态变化，返回事件通道和取消订阅函数
+func (h *recognitionEventHub) subscribe(recognitionID int64) (<-chan *models.FoodRecognitionResult, func()) {
+	ch := make(chan *models.FoodRecognitionResult, recognitionEventBuffer)
+
+	h.mu.Lock()
+	if h.subscribers[recognitionID] == nil {
+		h.subscribers[recognitionID] = make(map[chan *models.FoodRecognitionResult]struct{})
+	}
+	h.subscribers[recognitionID][ch] = struct{}{}
+	h.mu.Unlock()
+
+	var once sync.Once
+	cancel := func() {
+		once.Do(func() {
+			h.mu.Lock()
+			delete(h.subscribers[recognitionID], ch)
+			if len(h.subscribers[recognitionID]) == 0 {
+				delete(h.subscribers, recognitionID)
+			}
+			h.mu.Unlock()
+			close(ch)
+		})
+	}
+	return ch, cancel
+}
+
+// publish 向订阅者推送最新状态，订阅者处理不及时时丢弃该事件
+func (h *recognitionEventHub) publish(result *models.FoodRecognitionResult) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.subscribers[result.ID] {
+		select {
+		case ch <- result:
+		default:
+		}
+	}
+}
@@ -0,0 +1,500 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"ome-app-back/models"
+	"ome-app-back/models/constant"
+	"ome-app-back/repositories"
+)
+
+// 推荐评分参数
+const (
+	recommendationDefaultLimit = 5
+	recommendationMaxLimit     = 20
+	recommendationRerankSize   = 10 // 交给AI重排的候选数量
+	recentRecognitionLimit     = 10 // 用于判断重复的最近识别记录数量
+
+	scoreWeightCalories = 40.0
+	scoreWeightProtein  = 25.0
+	scoreWeightGoal     = 15.0
+	scoreWeightTaste    = 10.0
+	scoreWeightNovelty  = 10.0
+)
+
+// 各餐次占每日热量的比例
+var mealSlotShares = map[string]float64{
+	constant.MealTypeBreakfast: 0.25,
+	constant.MealTypeLunch:     0.35,
+	constant.MealTypeDinner:    0.30,
+	constant.MealTypeSnack:     0.10,
+}
+
+// recommendationCandidate 推荐候选餐
+type recommendationCandidate struct {
+	Name        string
+	MealTypes   []string
+	Ingredients []string
+	Tags        []string // 口味及特征标签，如 清淡、辣、素食、肉类
+	Calories    float64
+	ProteinG    float64
+	CarbG       float64
+	FatG        float64
+	RecipeID    int64 // 来自用户食谱时为食谱ID
+}
+
+// 内置推荐候选餐
+var recommendationCatalog = []recommendationCandidate{
+	{Name: "燕麦牛奶配水煮蛋", MealTypes: []string{constant.MealTypeBreakfast}, Ingredients: []string{"燕麦", "牛奶", "鸡蛋"}, Tags: []string{"清淡", "素食"}, Calories: 420, ProteinG: 26, CarbG: 48, FatG: 13},
+	{Name: "全麦鸡肉三明治", MealTypes: []string{constant.MealTypeBreakfast, constant.MealTypeLunch}, Ingredients: []string{"全麦面包", "鸡胸肉", "生菜"}, Tags: []string{"清淡", "肉类"}, Calories: 380, ProteinG: 30, CarbG: 40, FatG: 9},
+	{Name: "小米粥配鸡蛋饼", MealTypes: []string{constant.MealTypeBreakfast}, Ingredients: []string{"小米", "鸡蛋", "面粉"}, Tags: []string{"清淡", "素食"}, Calories: 450, ProteinG: 16, CarbG: 68, FatG: 12},
+	{Name: "豆浆配杂粮包", MealTypes: []string{constant.MealTypeBreakfast}, Ingredients: []string{"大豆", "杂粮"}, Tags: []string{"清淡", "素食"}, Calories: 360, ProteinG: 15, CarbG: 58, FatG: 7},
+	{Name: "希腊酸奶坚果碗", MealTypes: []string{constant.MealTypeBreakfast, constant.MealTypeSnack}, Ingredients: []string{"酸奶", "坚果", "蓝莓"}, Tags: []string{"甜", "素食"}, Calories: 300, ProteinG: 18, CarbG: 22, FatG: 15},
+	{Name: "牛油果鸡蛋吐司", MealTypes: []string{constant.MealTypeBreakfast}, Ingredients: []string{"牛油果", "鸡蛋", "全麦面包"}, Tags: []string{"清淡", "素食", "低碳水"}, Calories: 410, ProteinG: 17, CarbG: 28, FatG: 24},
+	{Name: "鸡胸肉糙米饭", MealTypes: []string{constant.MealTypeLunch, constant.MealTypeDinner}, Ingredients: []string{"鸡胸肉", "糙米", "西兰花"}, Tags: []string{"清淡", "肉类"}, Calories: 560, ProteinG: 45, CarbG: 62, FatG: 11},
+	{Name: "番茄牛腩配米饭", MealTypes: []string{constant.MealTypeLunch, constant.MealTypeDinner}, Ingredients: []string{"牛腩", "番茄", "米饭"}, Tags: []string{"酸", "肉类"}, Calories: 680, ProteinG: 38, CarbG: 70, FatG: 25},
+	{Name: "清蒸鲈鱼配杂粮饭", MealTypes: []string{constant.MealTypeLunch, constant.MealTypeDinner}, Ingredients: []string{"鲈鱼", "杂粮", "姜"}, Tags: []string{"清淡", "海鲜"}, Calories: 520, ProteinG: 40, CarbG: 55, FatG: 12},
+	{Name: "虾仁炒西兰花", MealTypes: []string{constant.MealTypeLunch, constant.MealTypeDinner}, Ingredients: []string{"虾仁", "西兰花"}, Tags: []string{"清淡", "海鲜", "低碳水"}, Calories: 320, ProteinG: 32, CarbG: 14, FatG: 14},
+	{Name: "麻婆豆腐配米饭", MealTypes: []string{constant.MealTypeLunch, constant.MealTypeDinner}, Ingredients: []string{"豆腐", "猪肉末", "米饭"}, Tags: []string{"辣", "肉类"}, Calories: 620, ProteinG: 26, CarbG: 72, FatG: 24},
+	{Name: "香菇青菜豆腐煲", MealTypes: []string{constant.MealTypeLunch, constant.MealTypeDinner}, Ingredients: []string{"豆腐", "香菇", "青菜"}, Tags: []string{"清淡", "素食", "低碳水"}, Calories: 300, ProteinG: 20, CarbG: 16, FatG: 16},
+	{Name: "宫保鸡丁配米饭", MealTypes: []string{constant.MealTypeLunch, constant.MealTypeDinner}, Ingredients: []string{"鸡肉", "花生", "米饭"}, Tags: []string{"辣", "甜", "肉类"}, Calories: 700, ProteinG: 35, CarbG: 75, FatG: 27},
+	{Name: "牛肉荞麦面", MealTypes: []string{constant.MealTypeLunch, constant.MealTypeDinner}, Ingredients: []string{"牛肉", "荞麦面", "青菜"}, Tags: []string{"咸", "肉类"}, Calories: 580, ProteinG: 36, CarbG: 70, FatG: 15},
+	{Name: "凉拌鸡丝配红薯", MealTypes: []string{constant.MealTypeDinner, constant.MealTypeLunch}, Ingredients: []string{"鸡胸肉", "黄瓜", "红薯"}, Tags: []string{"酸", "辣", "肉类"}, Calories: 430, ProteinG: 34, CarbG: 46, FatG: 10},
+	{Name: "三文鱼蔬菜沙拉", MealTypes: []string{constant.MealTypeDinner, constant.MealTypeLunch}, Ingredients: []string{"三文鱼", "生菜", "橄榄油"}, Tags: []string{"清淡", "海鲜", "低碳水"}, Calories: 420, ProteinG: 30, CarbG: 12, FatG: 27},
+	{Name: "冬瓜排骨汤配米饭", MealTypes: []string{constant.MealTypeDinner}, Ingredients: []string{"排骨", "冬瓜", "米饭"}, Tags: []string{"清淡", "肉类"}, Calories: 550, ProteinG: 28, CarbG: 58, FatG: 21},
+	{Name: "番茄鸡蛋面", MealTypes: []string{constant.MealTypeDinner, constant.MealTypeLunch}, Ingredients: []string{"番茄", "鸡蛋", "面条"}, Tags: []string{"酸", "素食"}, Calories: 480, ProteinG: 19, CarbG: 72, FatG: 12},
+	{Name: "水煮蛋", MealTypes: []string{constant.MealTypeSnack}, Ingredients: []string{"鸡蛋"}, Tags: []string{"清淡", "素食", "低碳水"}, Calories: 140, ProteinG: 12, CarbG: 1, FatG: 10},
+	{Name: "苹果配坚果", MealTypes: []string{constant.MealTypeSnack}, Ingredients: []string{"苹果", "坚果"}, Tags: []string{"甜", "素食"}, Calories: 200, ProteinG: 4, CarbG: 24, FatG: 11},
+	{Name: "无糖酸奶", MealTypes: []string{constant.MealTypeSnack}, Ingredients: []string{"酸奶"}, Tags: []string{"酸", "素食"}, Calories: 120, ProteinG: 10, CarbG: 9, FatG: 5},
+	{Name: "蛋白粉奶昔", MealTypes: []string{constant.MealTypeSnack}, Ingredients: []string{"蛋白粉", "牛奶"}, Tags: []string{"甜", "素食", "低碳水"}, Calories: 230, ProteinG: 30, CarbG: 12, FatG: 6},
+	{Name: "香蕉", MealTypes: []string{constant.MealTypeSnack}, Ingredients: []string{"香蕉"}, Tags: []string{"甜", "素食"}, Calories: 105, ProteinG: 1, CarbG: 27, FatG: 0},
+	{Name: "黄瓜圣女果", MealTypes: []string{constant.MealTypeSnack}, Ingredients: []string{"黄瓜", "圣女果"}, Tags: []string{"清淡", "素食", "低碳水"}, Calories: 50, ProteinG: 2, CarbG: 10, FatG: 0},
+}
+
+// RecommendationService 今日推荐服务（规则打分，可选AI重排）
+type RecommendationService struct {
+	nutritionService   *NutritionService
+	settingService     *UserSettingService
+	userGoalDAO        *repositories.UserGoalDAO
+	foodRecognitionDAO *repositories.FoodRecognitionDAO
+	recipeDAO          *repositories.RecipeDAO
+	aiService          *AIService
+}
+
+// NewRecommendationService 创建推荐服务实例
+func NewRecommendationService(
+	nutritionService *NutritionService,
+	settingService *UserSettingService,
+	userGoalDAO *repositories.UserGoalDAO,
+	foodRecognitionDAO *repositories.FoodRecognitionDAO,
+	recipeDAO *repositories.RecipeDAO,
+	aiService *AIService,
+) *RecommendationService {
+	return &RecommendationService{
+		nutritionService:   nutritionService,
+		settingService:     settingService,
+		userGoalDAO:        userGoalDAO,
+		foodRecognitionDAO: foodRecognitionDAO,
+		recipeDAO:          recipeDAO,
+		aiService:          aiService,
+	}
+}
+
+// RecommendationRequest 今日推荐请求
+type RecommendationRequest struct {
+	MealType string `form:"meal_type" binding:"omitempty,oneof=breakfast lunch dinner snack"` // 为空时按当前时间判断
+	Limit    int    `form:"limit"`
+	AIRerank bool   `form:"ai_rerank"` // 是否使用AI对规则结果重新排序
+}
+
+// Recommendation 单条推荐
+type Recommendation struct {
+	Name     string   `json:"name"`
+	MealType string   `json:"meal_type"`
+	Calories float64  `json:"calories"`
+	ProteinG float64  `json:"protein_g"`
+	CarbG    float64  `json:"carb_g"`
+	FatG     float64  `json:"fat_g"`
+	RecipeID int64    `json:"recipe_id,omitempty"` // 来自用户食谱时的食谱ID
+	Score    float64  `json:"score"`
+	Reasons  []string `json:"reasons"`
+}
+
+// RecommendationResponse 今日推荐响应
+type RecommendationResponse struct {
+	MealType    string           `json:"meal_type"`
+	Remaining   MacroValues      `json:"remaining"`   // 今日剩余可摄入量
+	SlotBudget  MacroValues      `json:"slot_budget"` // 本餐建议摄入量
+	Suggestions []Recommendation `json:"suggestions"`
+	AIReranked  bool             `json:"ai_reranked"`
+}
+
+// GetTodayRecommendations 根据今日剩余营养、目标与偏好生成推荐列表
+func (s *RecommendationService) GetTodayRecommendations(userID int64, req *RecommendationRequest) (*RecommendationResponse, error) {
+	nutrition, err := s.nutritionService.GetTodayNutrition(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var goal *models.UserGoal
+	if g, err := s.userGoalDAO.GetByUserID(userID); err == nil {
+		goal = g
+	} else {
+		log.Printf("[今日推荐] 用户(ID:%d)未获取到健康目标，按默认偏好推荐: %v", userID, err)
+	}
+
+	mealType := req.MealType
+	if mealType == "" {
+		mealType = currentMealSlot(s.settingService.Now(userID))
+	}
+	limit := recommendationDefaultLimit
+	if req.Limit > 0 && req.Limit <= recommendationMaxLimit {
+		limit = req.Limit
+	}
+
+	remaining := MacroValues{
+		Calories: math.Max(nutrition.TargetCalories-nutrition.CaloriesIntake, 0),
+		ProteinG: math.Max(nutrition.TargetProteinG-nutrition.ProteinIntakeG, 0),
+		CarbG:    math.Max(nutrition.TargetCarbG-nutrition.CarbIntakeG, 0),
+		FatG:     math.Max(nutrition.TargetFatG-nutrition.FatIntakeG, 0),
+	}
+	// 热量剩余额度计入按设置回补的运动消耗
+	if nutrition.EnergyBalance != nil {
+		remaining.Calories = math.Max(nutrition.EnergyBalance.RemainingCalories, 0)
+	}
+	budget := slotBudget(nutrition, remaining, mealType)
+
+	candidates := s.loadCandidates(userID)
+	recent := s.recentFoodNames(userID)
+
+	scored := make([]Recommendation, 0, len(candidates))
+	for _, candidate := range candidates {
+		if !candidateFitsMeal(&candidate, mealType) || !candidateAllowed(&candidate, goal) {
+			continue
+		}
+		scored = append(scored, scoreCandidate(&candidate, mealType, budget, goal, recent))
+	}
+	sort.SliceStable(scored, func(i, j int) bool {
+		return scored[i].Score > scored[j].Score
+	})
+
+	resp := &RecommendationResponse{
+		MealType:   mealType,
+		Remaining:  roundMacros(remaining),
+		SlotBudget: roundMacros(budget),
+	}
+
+	if req.AIRerank && len(scored) > 1 {
+		if reranked, err := s.rerankWithAI(scored, mealType, budget, goal); err != nil {
+			log.Printf("[今日推荐] 用户(ID:%d)AI重排失败，使用规则排序结果: %v", userID, err)
+		} else {
+			scored = reranked
+			resp.AIReranked = true
+		}
+	}
+
+	if len(scored) > limit {
+		scored = scored[:limit]
+	}
+	resp.Suggestions = scored
+	return resp, nil
+}
+
+// loadCandidates 合并内置候选餐与用户自己的食谱
+func (s *RecommendationService) loadCandidates(userID int64) []recommendationCandidate {
+	candidates := make([]recommendationCandidate, len(recommendationCatalog))
+	copy(candidates, recommendationCatalog)
+
+	recipes, err := s.recipeDAO.ListByUser(userID)
+	if err != nil {
+		log.Printf("[今日推荐] 用户(ID:%d)获取食谱失败: %v", userID, err)
+		return candidates
+	}
+	for _, recipe := range recipes {
+		ingredients := make([]string, 0, len(recipe.Ingredients))
+		for _, ingredient := range recipe.Ingredients {
+			ingredients = append(ingredients, ingredient.Name)
+		}
+		candidates = append(candidates, recommendationCandidate{
+			Name:        recipe.Name,
+			Ingredients: ingredients,
+			Tags:        []string{"我的食谱"},
+			Calories:    recipe.CaloriesPerServing,
+			ProteinG:    recipe.ProteinPerServingG,
+			CarbG:       recipe.CarbPerServingG,
+			FatG:        recipe.FatPerServingG,
+			RecipeID:    recipe.ID,
+		})
+	}
+	return candidates
+}
+
+// recentFoodNames 收集最近识别及今天记录过的食物名称，用于降低重复推荐
+func (s *RecommendationService) recentFoodNames(userID int64) []string {
+	names := make([]string, 0)
+
+	recognitions, err := s.foodRecognitionDAO.GetUserRecentRecognitions(userID, recentRecognitionLimit)
+	if err != nil {
+		log.Printf("[今日推荐] 用户(ID:%d)获取最近识别记录失败: %v", userID, err)
+	}
+	for _, recognition := range recognitions {
+		for _, food := range recognition.CurrentFoods() {
+			names = append(names, food.Name)
+		}
+	}
+
+	entries, err := s.nutritionService.GetNutritionEntries(userID, s.settingService.Today(userID).Format("2006-01-02"))
+	if err != nil {
+		log.Printf("[今日推荐] 用户(ID:%d)获取今日饮食记录失败: %v", userID, err)
+	}
+	for _, entry := range entries {
+		names = append(names, entry.FoodName)
+	}
+	return names
+}
+
+// aiRerankResult AI重排返回结构
+type aiRerankResult struct {
+	Ranking []aiRankedItem `json:"ranking"`
+}
+
+// aiRankedItem AI重排中的单项
+type aiRankedItem struct {
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+}
+
+// rerankWithAI 将规则排序的前若干项交给AI重排，未被AI提及的候选保持原顺序排在后面
+func (s *RecommendationService) rerankWithAI(scored []Recommendation, mealType string, budget MacroValues, goal *models.UserGoal) ([]Recommendation, error) {
+	top := scored
+	if len(top) > recommendationRerankSize {
+		top = top[:recommendationRerankSize]
+	}
+
+	lines := make([]string, 0, len(top))
+	for _, r := range top {
+		lines = append(lines, fmt.Sprintf("- %s：热量%.0f千卡，蛋白质%.0f克，碳水%.0f克，脂肪%.0f克", r.Name, r.Calories, r.ProteinG, r.CarbG, r.FatG))
+	}
+
+	preference := "无"
+	if goal != nil {
+		preference = fmt.Sprintf("目标%s，饮食类型%s，口味偏好%s", goal.GoalType, goal.DietType, joinOrNone(goal.TastePreferences))
+	}
+
+	messages := []models.OpenAIMessage{
+		{Role: "system", Content: `你是一名营养师，请根据用户情况对候选餐进行排序，只能使用候选列表中的名称。
+请严格按以下JSON格式输出，只返回JSON：
+{"ranking": [{"name": "候选名称", "reason": "一句话推荐理由"}]}`},
+		{Role: "user", Content: fmt.Sprintf("用户偏好：%s\n本餐（%s）建议摄入：热量%.0f千卡，蛋白质%.0f克\n候选：\n%s",
+			preference, mealTypeName(mealType), budget.Calories, budget.ProteinG, strings.Join(lines, "\n"))},
+	}
+
+	// 测试模式下保持规则排序
+	testResult := aiRerankResult{}
+	for _, r := range top {
+		testResult.Ranking = append(testResult.Ranking, aiRankedItem{Name: r.Name})
+	}
+	testResponse, _ := json.Marshal(testResult)
+
+	content, err := s.aiService.GenerateJSON(messages, string(testResponse))
+	if err != nil {
+		return nil, err
+	}
+
+	var result aiRerankResult
+	if err := json.Unmarshal([]byte(content), &result); err != nil {
+		return nil, fmt.Errorf("解析AI重排结果失败: %w", err)
+	}
+
+	byName := make(map[string]int, len(scored))
+	for i, r := range scored {
+		byName[r.Name] = i
+	}
+	used := make(map[int]bool, len(scored))
+	reranked := make([]Recommendation, 0, len(scored))
+	for _, item := range result.Ranking {
+		idx, ok := byName[item.Name]
+		if !ok || used[idx] {
+			continue
+		}
+		used[idx] = true
+		r := scored[idx]
+		if item.Reason != "" {
+			r.Reasons = append([]string{item.Reason}, r.Reasons...)
+		}
+		reranked = append(reranked, r)
+	}
+	if len(reranked) == 0 {
+		return nil, fmt.Errorf("AI重排结果中没有有效的候选")
+	}
+	for i, r := range scored {
+		if !used[i] {
+			reranked = append(reranked, r)
+		}
+	}
+	return reranked, nil
+}
+
+// currentMealSlot 根据当前时间判断餐次
+func currentMealSlot(now time.Time) string {
+	hour := now.Hour()
+	switch {
+	case hour >= 5 && hour < 10:
+		return constant.MealTypeBreakfast
+	case hour >= 10 && hour < 14:
+		return constant.MealTypeLunch
+	case hour >= 17 && hour < 21:
+		return constant.MealTypeDinner
+	default:
+		return constant.MealTypeSnack
+	}
+}
+
+// slotBudget 计算本餐建议摄入量：按餐次占比分配每日目标，但不超过今日剩余量
+func slotBudget(nutrition *models.DailyNutrition, remaining MacroValues, mealType string) MacroValues {
+	share := mealSlotShares[mealType]
+	return MacroValues{
+		Calories: math.Min(nutrition.TargetCalories*share, remaining.Calories),
+		ProteinG: math.Min(nutrition.TargetProteinG*share, remaining.ProteinG),
+		CarbG:    math.Min(nutrition.TargetCarbG*share, remaining.CarbG),
+		FatG:     math.Min(nutrition.TargetFatG*share, remaining.FatG),
+	}
+}
+
+// candidateFitsMeal 判断候选是否适合该餐次；用户食谱不限餐次
+func candidateFitsMeal(candidate *recommendationCandidate, mealType string) bool {
+	if len(candidate.MealTypes) == 0 {
+		return true
+	}
+	return containsString(candidate.MealTypes, mealType)
+}
+
+// candidateAllowed 按饮食类型与食物不耐受过滤候选
+func candidateAllowed(candidate *recommendationCandidate, goal *models.UserGoal) bool {
+	if goal == nil {
+		return true
+	}
+
+	switch goal.DietType {
+	case "vegetarian":
+		if containsString(candidate.Tags, "肉类") || containsString(candidate.Tags, "海鲜") {
+			return false
+		}
+	case "low_carb":
+		if candidate.Calories > 0 && candidate.CarbG*4/candidate.Calories > 0.45 {
+			return false
+		}
+	}
+
+	text := candidate.Name + " " + strings.Join(candidate.Ingredients, " ")
+	return len(matchIntolerances(text, goal.FoodIntolerances)) == 0
+}
+
+// scoreCandidate 对候选打分并生成推荐理由
+func scoreCandidate(candidate *recommendationCandidate, mealType string, budget MacroValues, goal *models.UserGoal, recent []string) Recommendation {
+	var score float64
+	reasons := make([]string, 0, 4)
+
+	// 热量匹配度
+	if budget.Calories > 0 {
+		deviation := math.Abs(candidate.Calories-budget.Calories) / budget.Calories
+		score += scoreWeightCalories * math.Max(0, 1-deviation)
+		if deviation <= 0.15 {
+			reasons = append(reasons, fmt.Sprintf("热量%.0f千卡，贴合本餐建议的%.0f千卡", candidate.Calories, budget.Calories))
+		}
+	} else if candidate.Calories <= 150 {
+		// 今日热量已用完，只给低热量加餐加分
+		score += scoreWeightCalories * 0.5
+		reasons = append(reasons, "今日热量已基本达标，选择低热量食物")
+	}
+
+	// 蛋白质补足程度
+	if budget.ProteinG > 0 {
+		ratio := math.Min(candidate.ProteinG/budget.ProteinG, 1)
+		score += scoreWeightProtein * ratio
+		if ratio >= 0.8 {
+			reasons = append(reasons, fmt.Sprintf("提供%.0f克蛋白质，补足今日剩余需求", candidate.ProteinG))
+		}
+	}
+
+	// 健康目标匹配度
+	if goal != nil && candidate.Calories > 0 {
+		proteinDensity := candidate.ProteinG * 4 / candidate.Calories
+		fatDensity := candidate.FatG * 9 / candidate.Calories
+		switch goal.GoalType {
+		case "lose_fat":
+			fit := math.Min(proteinDensity/0.35, 1)*0.6 + math.Max(0, 1-fatDensity/0.4)*0.4
+			score += scoreWeightGoal * fit
+			if proteinDensity >= 0.3 && fatDensity <= 0.3 {
+				reasons = append(reasons, "高蛋白低脂，适合减脂")
+			}
+		case "gain_muscle":
+			fit := math.Min(proteinDensity/0.3, 1)
+			score += scoreWeightGoal * fit
+			if proteinDensity >= 0.25 {
+				reasons = append(reasons, "蛋白质充足，有助于增肌")
+			}
+		default:
+			carbDensity := candidate.CarbG * 4 / candidate.Calories
+			fit := 1 - math.Min(math.Abs(carbDensity-0.5)+math.Abs(fatDensity-0.25), 1)
+			score += scoreWeightGoal * fit
+		}
+	}
+
+	// 口味偏好匹配度
+	if goal != nil {
+		for _, taste := range goal.TastePreferences {
+			if containsString(candidate.Tags, taste) {
+				score += scoreWeightTaste
+				reasons = append(reasons, "符合你的口味偏好："+taste)
+				break
+			}
+		}
+	}
+
+	// 新鲜度：最近吃过的降低优先级
+	eatenRecently := false
+	for _, name := range recent {
+		if name != "" && (strings.Contains(candidate.Name, name) || strings.Contains(name, candidate.Name)) {
+			eatenRecently = true
+			break
+		}
+	}
+	if !eatenRecently {
+		score += scoreWeightNovelty
+	} else {
+		reasons = append(reasons, "最近吃过，已降低推荐优先级")
+	}
+
+	if candidate.RecipeID != 0 {
+		reasons = append(reasons, "来自你的食谱")
+	}
+
+	return Recommendation{
+		Name:     candidate.Name,
+		MealType: mealType,
+		Calories: candidate.Calories,
+		ProteinG: candidate.ProteinG,
+		CarbG:    candidate.CarbG,
+		FatG:     candidate.FatG,
+		RecipeID: candidate.RecipeID,
+		Score:    roundTo(score, 1),
+		Reasons:  reasons,
+	}
+}
+
+// roundMacros 宏量营养素保留一位小数
+func roundMacros(values MacroValues) MacroValues {
+	return MacroValues{
+		Calories: roundTo(values.Calories, 1),
+		ProteinG: roundTo(values.ProteinG, 1),
+		CarbG:    roundTo(values.CarbG, 1),
+		FatG:     roundTo(values.FatG, 1),
+	}
+}
@@ -0,0 +1,58 @@
+package services
+
+import (
+	"os"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"ome-app-back/config"
+	"ome-app-back/models"
+	"ome-app-back/repositories"
+)
+
+// chdirTemp 切换到临时目录，上传文件按工作目录下的 uploads/ 解析，测试结束后恢复
+func chdirTemp(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("获取工作目录失败: %v", err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("切换工作目录失败: %v", err)
+	}
+	t.Cleanup(func() {
+		os.Chdir(wd)
+	})
+	return dir
+}
+
+// newTestServices 使用内存SQLite数据库创建全部业务服务，AI为测试模式，上传目录位于临时目录
+func newTestServices(t *testing.T) (*Services, *repositories.Repositories) {
+	t.Helper()
+	chdirTemp(t)
+
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("打开测试数据库失败: %v", err)
+	}
+	// 内存数据库每个连接相互独立，只保留一个连接
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("获取数据库连接失败: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	if err := models.Init(db); err != nil {
+		t.Fatalf("初始化数据表失败: %v", err)
+	}
+
+	cfg := &config.Config{
+		AI:     config.AIConfig{TestMode: true},
+		Upload: config.UploadConfig{Dir: "uploads", MaxSize: 10 << 20},
+	}
+	repos := repositories.Init(db)
+	return Init(repos, cfg), repos
+}
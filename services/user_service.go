@@ -16,17 +16,19 @@ import (
 
 // UserService 处理用户相关业务逻辑
 type UserService struct {
-	userDAO       *repositories.AppUserDAO
-	userWeightDAO *repositories.UserWeightDAO
-	userGoalDAO   *repositories.UserGoalDAO
+	userDAO        *repositories.AppUserDAO
+	userWeightDAO  *repositories.UserWeightDAO
+	userGoalDAO    *repositories.UserGoalDAO
+	settingService *UserSettingService
 }
 
 // NewUserService 创建用户服务实例
-func NewUserService(userDAO *repositories.AppUserDAO, userWeightDAO *repositories.UserWeightDAO, userGoalDAO *repositories.UserGoalDAO) *UserService {
+func NewUserService(userDAO *repositories.AppUserDAO, userWeightDAO *repositories.UserWeightDAO, userGoalDAO *repositories.UserGoalDAO, settingService *UserSettingService) *UserService {
 	return &UserService{
-		userDAO:       userDAO,
-		userWeightDAO: userWeightDAO,
-		userGoalDAO:   userGoalDAO,
+		userDAO:        userDAO,
+		userWeightDAO:  userWeightDAO,
+		userGoalDAO:    userGoalDAO,
+		settingService: settingService,
 	}
 }
 
@@ -339,7 +341,7 @@ func (s *UserService) UpdateProfile(req UpdateProfileRequest) error {
 
 	// 记录体重
 	if req.WeightKG > 0 {
-		if err := s.userWeightDAO.Create(req.UserID, req.WeightKG); err != nil {
+		if err := s.userWeightDAO.Create(req.UserID, req.WeightKG, s.settingService.Today(req.UserID)); err != nil {
 			return errors.New("记录体重失败")
 		}
 	}
@@ -0,0 +1,186 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"ome-app-back/models"
+	"ome-app-back/models/constant"
+	"ome-app-back/repositories"
+)
+
+// 自定义错误，表示时区不是有效的IANA时区
+var ErrInvalidTimezone = errors.New("无效的时区，请使用IANA时区名称，如 Asia/Shanghai")
+
+// UserSettingService 用户设置服务，同时负责按用户时区计算"今天"与日期范围
+type UserSettingService struct {
+	userSettingDAO *repositories.UserSettingDAO
+
+	locations sync.Map // 用户时区缓存: userID -> *time.Location
+}
+
+// NewUserSettingService 创建用户设置服务实例
+func NewUserSettingService(userSettingDAO *repositories.UserSettingDAO) *UserSettingService {
+	return &UserSettingService{
+		userSettingDAO: userSettingDAO,
+	}
+}
+
+// UpdateUserSettingRequest 更新用户设置请求，未传的字段保持不变
+type UpdateUserSettingRequest struct {
+	ExerciseCalorieMode  *string  `json:"exercise_calorie_mode" binding:"omitempty,oneof=none partial full"`
+	ExerciseCalorieRatio *float64 `json:"exercise_calorie_ratio" binding:"omitempty,gt=0,lte=1"`
+	Timezone             *string  `json:"timezone" binding:"omitempty,max=64"`
+}
+
+// GetSettings 获取用户设置，未保存过时返回默认设置
+func (s *UserSettingService) GetSettings(userID int64) (*models.UserSetting, error) {
+	setting, err := s.userSettingDAO.GetByUserID(userID)
+	if err == nil {
+		return setting, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	return &models.UserSetting{
+		UserID:               userID,
+		ExerciseCalorieMode:  constant.ExerciseCalorieModeNone,
+		ExerciseCalorieRatio: constant.DefaultExerciseCalorieRatio,
+	}, nil
+}
+
+// UpdateSettings 更新用户设置
+func (s *UserSettingService) UpdateSettings(userID int64, req *UpdateUserSettingRequest) (*models.UserSetting, error) {
+	setting, err := s.GetSettings(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var loc *time.Location
+	if req.Timezone != nil {
+		if loc, err = loadTimezone(*req.Timezone); err != nil {
+			return nil, err
+		}
+		setting.Timezone = *req.Timezone
+	}
+	if req.ExerciseCalorieMode != nil {
+		setting.ExerciseCalorieMode = *req.ExerciseCalorieMode
+	}
+	if req.ExerciseCalorieRatio != nil {
+		setting.ExerciseCalorieRatio = *req.ExerciseCalorieRatio
+	}
+
+	if err := s.userSettingDAO.Save(setting); err != nil {
+		log.Printf("[用户设置] 用户(ID:%d)保存设置失败: %v", userID, err)
+		return nil, err
+	}
+	if loc != nil {
+		s.locations.Store(userID, loc)
+	}
+	return setting, nil
+}
+
+// loadTimezone 解析IANA时区名称，空值与服务器本地时区"Local"视为无效
+func loadTimezone(timezone string) (*time.Location, error) {
+	if timezone == "" || timezone == "Local" {
+		return nil, ErrInvalidTimezone
+	}
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, ErrInvalidTimezone
+	}
+	return loc, nil
+}
+
+// SyncTimezone 根据客户端上报的时区自动更新用户时区，与当前时区相同时不写库
+func (s *UserSettingService) SyncTimezone(userID int64, timezone string) error {
+	loc, err := loadTimezone(timezone)
+	if err != nil {
+		return err
+	}
+	if s.Location(userID).String() == loc.String() {
+		return nil
+	}
+
+	setting, err := s.GetSettings(userID)
+	if err != nil {
+		return err
+	}
+	setting.Timezone = timezone
+	if err := s.userSettingDAO.Save(setting); err != nil {
+		log.Printf("[用户设置] 用户(ID:%d)更新时区失败: %v", userID, err)
+		return err
+	}
+
+	log.Printf("[用户设置] 用户(ID:%d)时区已更新为%s", userID, timezone)
+	s.locations.Store(userID, loc)
+	return nil
+}
+
+// Location 获取用户时区，未设置或读取失败时使用默认时区
+// 读取失败时不缓存，下次调用重新读取，避免临时的数据库错误使用户永久落到默认时区
+func (s *UserSettingService) Location(userID int64) *time.Location {
+	if cached, ok := s.locations.Load(userID); ok {
+		return cached.(*time.Location)
+	}
+
+	timezone := constant.DefaultTimezone
+	setting, err := s.GetSettings(userID)
+	cacheable := err == nil
+	if err != nil {
+		log.Printf("[用户设置] 用户(ID:%d)获取时区失败，使用默认时区: %v", userID, err)
+	} else if setting.Timezone != "" {
+		timezone = setting.Timezone
+	}
+
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		log.Printf("[用户设置] 用户(ID:%d)时区%s无效，使用UTC: %v", userID, timezone, err)
+		loc = time.UTC
+	}
+	if cacheable {
+		s.locations.Store(userID, loc)
+	}
+	return loc
+}
+
+// Now 获取用户时区下的当前时间
+func (s *UserSettingService) Now(userID int64) time.Time {
+	return time.Now().In(s.Location(userID))
+}
+
+// Today 获取用户时区下今天的日历日期，用于 date 类型字段的查询与写入
+func (s *UserSettingService) Today(userID int64) time.Time {
+	return calendarDate(s.Now(userID))
+}
+
+// ParseDate 按用户时区将 YYYY-MM-DD 解析为当天0点，用于时间戳字段的范围查询
+func (s *UserSettingService) ParseDate(userID int64, dateStr string) (time.Time, error) {
+	return time.ParseInLocation("2006-01-02", dateStr, s.Location(userID))
+}
+
+// ExerciseCalorieFactor 运动消耗计入热量预算的比例
+func ExerciseCalorieFactor(setting *models.UserSetting) float64 {
+	switch setting.ExerciseCalorieMode {
+	case constant.ExerciseCalorieModeFull:
+		return 1
+	case constant.ExerciseCalorieModePartial:
+		return setting.ExerciseCalorieRatio
+	default:
+		return 0
+	}
+}
+
+// startOfDay 获取时间所在时区当天的0点
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// calendarDate 将时间所在时区的日期转换为UTC 0点，避免写入 date 字段时因时区换算变成前一天
+func calendarDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
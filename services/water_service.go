@@ -0,0 +1,315 @@
+package services
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	"gorm.io/gorm"
+
+	"ome-app-back/models"
+	"ome-app-back/models/constant"
+	"ome-app-back/repositories"
+)
+
+// WaterService 饮水服务
+type WaterService struct {
+	waterDAO      *repositories.WaterRecordDAO
+	userWeightDAO *repositories.UserWeightDAO
+	exerciseDAO   *repositories.UserExerciseDAO
+
+	settingService *UserSettingService
+}
+
+// NewWaterService 创建饮水服务实例
+func NewWaterService(waterDAO *repositories.WaterRecordDAO, userWeightDAO *repositories.UserWeightDAO, exerciseDAO *repositories.UserExerciseDAO, settingService *UserSettingService) *WaterService {
+	return &WaterService{
+		waterDAO:      waterDAO,
+		userWeightDAO: userWeightDAO,
+		exerciseDAO:   exerciseDAO,
+
+		settingService: settingService,
+	}
+}
+
+// CreateWaterRequest 创建饮水记录请求
+type CreateWaterRequest struct {
+	AmountML   int    `json:"amount_ml" binding:"required,gt=0"`
+	RecordTime string `json:"record_time,omitempty"` // 格式: "2023-12-01T10:30:00Z"，为空时使用当前时间
+}
+
+// WaterHistoryRequest 饮水历史记录请求
+type WaterHistoryRequest struct {
+	Days int `form:"days"`
+}
+
+// WaterStatisticsRequest 饮水统计请求
+type WaterStatisticsRequest struct {
+	Days int `form:"days"`
+}
+
+// WaterTodayResponse 今日饮水响应
+type WaterTodayResponse struct {
+	TotalML        int                  `json:"total_ml"`
+	TargetML       int                  `json:"target_ml"`
+	CompletionRate float64              `json:"completion_rate"`
+	RemainingML    int                  `json:"remaining_ml"`
+	Records        []models.WaterRecord `json:"records"`
+}
+
+// WaterDailyResponse 每日饮水汇总响应
+type WaterDailyResponse struct {
+	Date           string  `json:"date"`
+	TotalML        int     `json:"total_ml"`
+	TargetML       int     `json:"target_ml"`
+	CompletionRate float64 `json:"completion_rate"`
+}
+
+// WaterStatisticsResponse 饮水统计响应
+type WaterStatisticsResponse struct {
+	Days         int                  `json:"days"`
+	TotalML      int                  `json:"total_ml"`
+	AvgML        float64              `json:"avg_ml"`
+	AvgTargetML  float64              `json:"avg_target_ml"`
+	GoalMetDays  int                  `json:"goal_met_days"`
+	RecordedDays int                  `json:"recorded_days"`
+	MaxDailyML   int                  `json:"max_daily_ml"`
+	TrendData    []WaterDailyResponse `json:"trend_data"`
+}
+
+// CreateWater 创建饮水记录
+func (s *WaterService) CreateWater(userID int64, req *CreateWaterRequest) (*models.WaterRecord, error) {
+	if req.AmountML > constant.MaxSingleWaterRecordML {
+		return nil, errors.New("单次饮水量过大")
+	}
+
+	recordTime := time.Now()
+	if req.RecordTime != "" {
+		parsed, err := time.Parse(time.RFC3339, req.RecordTime)
+		if err != nil {
+			return nil, errors.New("时间格式错误，请使用 RFC3339 格式")
+		}
+		if parsed.After(time.Now()) {
+			return nil, errors.New("记录时间不能晚于当前时间")
+		}
+		recordTime = parsed
+	}
+
+	record := &models.WaterRecord{
+		UserID:     userID,
+		AmountML:   req.AmountML,
+		RecordTime: recordTime,
+	}
+
+	if err := s.waterDAO.Create(record); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// GetTodayWater 获取今日饮水情况
+func (s *WaterService) GetTodayWater(userID int64) (*WaterTodayResponse, error) {
+	dayStart := startOfDay(s.settingService.Now(userID))
+	dayEnd := dayStart.AddDate(0, 0, 1)
+
+	records, err := s.waterDAO.GetByDateRange(userID, dayStart, dayEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	targetML, err := s.GetDailyTarget(userID, dayStart)
+	if err != nil {
+		return nil, err
+	}
+
+	totalML := 0
+	for _, record := range records {
+		totalML += record.AmountML
+	}
+
+	remainingML := targetML - totalML
+	if remainingML < 0 {
+		remainingML = 0
+	}
+
+	return &WaterTodayResponse{
+		TotalML:        totalML,
+		TargetML:       targetML,
+		CompletionRate: waterCompletionRate(totalML, targetML),
+		RemainingML:    remainingML,
+		Records:        records,
+	}, nil
+}
+
+// GetWaterHistory 获取每日饮水历史（按日期倒序）
+func (s *WaterService) GetWaterHistory(userID int64, req *WaterHistoryRequest) ([]WaterDailyResponse, error) {
+	daily, err := s.getDailySummaries(userID, normalizeWaterDays(req.Days))
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]WaterDailyResponse, 0, len(daily))
+	for i := len(daily) - 1; i >= 0; i-- {
+		if daily[i].TotalML > 0 {
+			result = append(result, daily[i])
+		}
+	}
+	return result, nil
+}
+
+// GetWaterStatistics 获取饮水统计分析
+func (s *WaterService) GetWaterStatistics(userID int64, req *WaterStatisticsRequest) (*WaterStatisticsResponse, error) {
+	days := normalizeWaterDays(req.Days)
+	daily, err := s.getDailySummaries(userID, days)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &WaterStatisticsResponse{
+		Days:      days,
+		TrendData: daily,
+	}
+
+	totalTarget := 0
+	for _, day := range daily {
+		stats.TotalML += day.TotalML
+		totalTarget += day.TargetML
+		if day.TotalML > 0 {
+			stats.RecordedDays++
+		}
+		if day.TotalML >= day.TargetML {
+			stats.GoalMetDays++
+		}
+		if day.TotalML > stats.MaxDailyML {
+			stats.MaxDailyML = day.TotalML
+		}
+	}
+
+	if len(daily) > 0 {
+		stats.AvgML = math.Round(float64(stats.TotalML)/float64(len(daily))*10) / 10
+		stats.AvgTargetML = math.Round(float64(totalTarget)/float64(len(daily))*10) / 10
+	}
+	return stats, nil
+}
+
+// DeleteWater 删除饮水记录
+func (s *WaterService) DeleteWater(userID, recordID int64) error {
+	return s.waterDAO.Delete(userID, recordID)
+}
+
+// GetWaterOptions 获取饮水快捷选项（用于前端显示）
+func (s *WaterService) GetWaterOptions() map[string]interface{} {
+	return map[string]interface{}{
+		"quick_add_amounts": constant.QuickAddWaterAmounts,
+		"max_single_ml":     constant.MaxSingleWaterRecordML,
+	}
+}
+
+// GetDailyTarget 计算指定日期的饮水目标：体重×35ml + 当天运动分钟数×12ml
+func (s *WaterService) GetDailyTarget(userID int64, date time.Time) (int, error) {
+	baseML, err := s.getBaseTarget(userID)
+	if err != nil {
+		return 0, err
+	}
+
+	dayStart := startOfDay(date.In(s.settingService.Location(userID)))
+	exercises, err := s.exerciseDAO.GetHistory(userID, dayStart, dayStart.AddDate(0, 0, 1), 0)
+	if err != nil {
+		return 0, err
+	}
+
+	var exerciseMin float64
+	for _, exercise := range exercises {
+		exerciseMin += exercise.DurationMin
+	}
+
+	return capWaterTarget(baseML + int(exerciseMin*constant.WaterMLPerExerciseMinute)), nil
+}
+
+// GetAverageDaily 获取最近N天的平均每日饮水量及平均目标
+func (s *WaterService) GetAverageDaily(userID int64, days int) (avgML, avgTargetML float64, err error) {
+	stats, err := s.GetWaterStatistics(userID, &WaterStatisticsRequest{Days: days})
+	if err != nil {
+		return 0, 0, err
+	}
+	return stats.AvgML, stats.AvgTargetML, nil
+}
+
+// getBaseTarget 根据最新体重计算基础饮水目标，无体重记录时使用默认值
+func (s *WaterService) getBaseTarget(userID int64) (int, error) {
+	weight, err := s.userWeightDAO.GetLatest(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return constant.DefaultDailyWaterML, nil
+		}
+		return 0, err
+	}
+	return int(math.Round(weight.WeightKG * constant.WaterMLPerKG)), nil
+}
+
+// getDailySummaries 获取最近N天（含今天）每天的饮水量与目标，按日期正序
+func (s *WaterService) getDailySummaries(userID int64, days int) ([]WaterDailyResponse, error) {
+	today := startOfDay(s.settingService.Now(userID))
+	startDate := today.AddDate(0, 0, -(days - 1))
+	endDate := today.AddDate(0, 0, 1)
+
+	totals, err := s.waterDAO.GetDailyTotals(userID, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+	totalMap := make(map[string]int, len(totals))
+	for _, total := range totals {
+		totalMap[total.Date] = total.TotalML
+	}
+
+	baseML, err := s.getBaseTarget(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	exercises, err := s.exerciseDAO.GetHistory(userID, startDate, endDate, 0)
+	if err != nil {
+		return nil, err
+	}
+	exerciseMinMap := make(map[string]float64)
+	for _, exercise := range exercises {
+		exerciseMinMap[exercise.StartTime.In(today.Location()).Format("2006-01-02")] += exercise.DurationMin
+	}
+
+	result := make([]WaterDailyResponse, 0, days)
+	for date := startDate; date.Before(endDate); date = date.AddDate(0, 0, 1) {
+		dateKey := date.Format("2006-01-02")
+		targetML := capWaterTarget(baseML + int(exerciseMinMap[dateKey]*constant.WaterMLPerExerciseMinute))
+		result = append(result, WaterDailyResponse{
+			Date:           dateKey,
+			TotalML:        totalMap[dateKey],
+			TargetML:       targetML,
+			CompletionRate: waterCompletionRate(totalMap[dateKey], targetML),
+		})
+	}
+	return result, nil
+}
+
+// normalizeWaterDays 规范查询天数，默认7天，最多365天
+func normalizeWaterDays(days int) int {
+	if days > 0 && days <= 365 {
+		return days
+	}
+	return 7
+}
+
+// capWaterTarget 限制每日饮水目标上限
+func capWaterTarget(targetML int) int {
+	if targetML > constant.MaxDailyWaterTargetML {
+		return constant.MaxDailyWaterTargetML
+	}
+	return targetML
+}
+
+// waterCompletionRate 计算饮水完成率（百分比，保留一位小数）
+func waterCompletionRate(totalML, targetML int) float64 {
+	if targetML <= 0 {
+		return 0
+	}
+	return math.Round(float64(totalML)/float64(targetML)*1000) / 10
+}
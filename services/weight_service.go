@@ -10,13 +10,15 @@ import (
 
 // WeightService 体重服务
 type WeightService struct {
-	userWeightDAO *repositories.UserWeightDAO
+	userWeightDAO  *repositories.UserWeightDAO
+	settingService *UserSettingService
 }
 
 // NewWeightService 创建体重服务实例
-func NewWeightService(userWeightDAO *repositories.UserWeightDAO) *WeightService {
+func NewWeightService(userWeightDAO *repositories.UserWeightDAO, settingService *UserSettingService) *WeightService {
 	return &WeightService{
-		userWeightDAO: userWeightDAO,
+		userWeightDAO:  userWeightDAO,
+		settingService: settingService,
 	}
 }
 
@@ -68,7 +70,7 @@ type WeightTrendPoint struct {
 
 // CreateWeight 创建体重记录
 func (s *WeightService) CreateWeight(userID int64, req *CreateWeightRequest) error {
-	return s.userWeightDAO.Create(userID, req.WeightKG)
+	return s.userWeightDAO.Create(userID, req.WeightKG, s.settingService.Today(userID))
 }
 
 // GetWeightHistory 获取体重历史记录
@@ -79,8 +81,8 @@ func (s *WeightService) GetWeightHistory(userID int64, req *WeightHistoryRequest
 		limit = req.Limit
 	}
 
-	// 计算时间范围（最多一年）
-	endDate := time.Now()
+	// 计算时间范围（最多一年），按用户时区的日期计算
+	endDate := s.settingService.Today(userID)
 	startDate := endDate.AddDate(0, 0, -limit)
 
 	weights, err := s.userWeightDAO.GetHistory(userID, startDate, endDate)
@@ -110,8 +112,8 @@ func (s *WeightService) GetCurrentWeight(userID int64) (*CurrentWeightResponse,
 		return nil, err
 	}
 
-	// 计算距离现在多少天
-	daysAgo := int(time.Since(weight.RecordDate).Hours() / 24)
+	// 计算距离用户今天多少天
+	daysAgo := int(s.settingService.Today(userID).Sub(weight.RecordDate).Hours() / 24)
 
 	return &CurrentWeightResponse{
 		WeightKG:   weight.WeightKG,
@@ -133,8 +135,8 @@ func (s *WeightService) GetWeightStatistics(userID int64, req *WeightStatisticsR
 		days = req.Days
 	}
 
-	// 计算时间范围
-	endDate := time.Now()
+	// 计算时间范围，按用户时区的日期计算
+	endDate := s.settingService.Today(userID)
 	startDate := endDate.AddDate(0, 0, -days)
 
 	allWeights, err := s.userWeightDAO.GetHistory(userID, startDate, endDate)
@@ -233,12 +235,11 @@ func filterDailyLastRecords(weights []models.UserWeight) []models.UserWeight {
 	dailyLastRecord := make(map[string]models.UserWeight)
 
 	for _, weight := range weights {
-		// 使用本地时区获取日期字符串 (YYYY-MM-DD)，避免时区问题
-		localTime := weight.RecordDate.Local()
-		dateKey := localTime.Format("2006-01-02")
+		// record_date 为日历日期（UTC 0点），按UTC取日期字符串 (YYYY-MM-DD)，避免服务器时区导致错位
+		dateKey := weight.RecordDate.UTC().Format("2006-01-02")
 
-		// 如果该日期还没有记录，或者当前记录的时间更晚，则更新该日期的记录
-		if existing, exists := dailyLastRecord[dateKey]; !exists || weight.RecordDate.After(existing.RecordDate) {
+		// 如果该日期还没有记录，或者当前记录更晚，则更新该日期的记录
+		if existing, exists := dailyLastRecord[dateKey]; !exists || isLaterWeightRecord(weight, existing) {
 			dailyLastRecord[dateKey] = weight
 		}
 	}
@@ -262,3 +263,15 @@ func filterDailyLastRecords(weights []models.UserWeight) []models.UserWeight {
 
 	return result
 }
+
+// isLaterWeightRecord 判断 a 是否比 b 记录得更晚
+// record_date 只精确到日，同一天的记录按创建时间、再按ID区分先后
+func isLaterWeightRecord(a, b models.UserWeight) bool {
+	if !a.RecordDate.Equal(b.RecordDate) {
+		return a.RecordDate.After(b.RecordDate)
+	}
+	if !a.CreatedAt.Equal(b.CreatedAt) {
+		return a.CreatedAt.After(b.CreatedAt)
+	}
+	return a.ID > b.ID
+}